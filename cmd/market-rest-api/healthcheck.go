@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const healthcheckUsage = `usage: market-rest-api healthcheck [path]
+
+Requests path, /readyz by default, from the server on PORT and fails unless
+it answers 200. The image has no shell or curl, so container health checks
+run this instead.`
+
+func runHealthcheck(args []string) error {
+	path := "/readyz"
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		return errors.New(healthcheckUsage)
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://127.0.0.1:" + port + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", path, resp.Status)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,9 +16,31 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := runHealthcheck(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	config.Load()
+	shutdownTracing := server.InitTracing()
 
-	srv := server.SetupServer()
+	srv, healthChecker, closeServer := server.SetupServer()
+
+	// Requests derive their context from baseCtx, so work that is still
+	// running when the graceful shutdown times out gets cancelled.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
 
 	go func() {
 		logger.Info("Server is running on: http://localhost" + srv.Addr)
@@ -31,11 +55,27 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Report not ready first and keep serving while load balancers take
+	// the instance out of rotation.
+	healthChecker.SetShuttingDown()
+	time.Sleep(config.Config.Health.ShutdownDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown: " + err.Error())
 	}
+	cancelRequests()
+
+	// Requests are done or cancelled by now, so the connections they used
+	// can be closed.
+	if err := closeServer(); err != nil {
+		logger.Error("Failed to close connections: " + err.Error())
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces: " + err.Error())
+	}
 	logger.Info("The server shut down")
 }
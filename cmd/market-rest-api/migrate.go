@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/internal/migrations"
+	"github.com/DaniilKalts/market-rest-api/internal/server"
+)
+
+const migrateUsage = `usage: market-rest-api migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [steps]  revert the last applied migration, or the last steps ones
+  status        list migrations and when they were applied
+  create <name> add an empty up/down pair to ` + migrations.Dir
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		up, down, err := migrations.Create(migrations.Dir, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created " + up)
+		fmt.Println("Created " + down)
+		return nil
+	}
+
+	steps := 1
+	switch {
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return errors.New("steps must be a positive number")
+		}
+		steps = n
+	case args[0] == "up" || args[0] == "down" || args[0] == "status":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	all, err := migrations.Embedded()
+	if err != nil {
+		return err
+	}
+
+	config.LoadPostgres()
+	migrator := migrations.NewMigrator(server.InitDB(), all)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("The schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No applied migrations to revert")
+		}
+		return err
+	default:
+		return printStatus(ctx, migrator)
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Up == "" {
+			appliedAt += " (unknown to this build)"
+		}
+		fmt.Fprintf(
+			w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt,
+		)
+	}
+	return w.Flush()
+}
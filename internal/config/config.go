@@ -1,8 +1,12 @@
 package config
 
 import (
+	"net"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -10,10 +14,28 @@ import (
 )
 
 type ServerConfig struct {
-	Port    string
-	Secret  string
-	BaseURL string
-	Domain  string
+	Port           string
+	Secret         string
+	BaseURL        string
+	Domain         string
+	RequestTimeout time.Duration
+	Cookies        CookieConfig
+	// TrustedProxies are the addresses and CIDRs whose X-Forwarded-For
+	// and X-Real-IP headers name the client. Empty trusts none, so the
+	// client is always the peer of the connection.
+	TrustedProxies []string
+}
+
+// CookieConfig holds the attributes of the cookies browsers authenticate
+// with.
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+	// AccessTTL and RefreshTTL are the cookie lifetimes; they should match
+	// the lifetimes of the tokens they carry.
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
 }
 
 type PostgresConfig struct {
@@ -33,26 +55,357 @@ type AdminConfig struct {
 	PhoneNumber string
 }
 
+type PaymentConfig struct {
+	Provider string
+	// WebhookSecret signs the provider's webhooks. It must differ from
+	// SECRET, which signs access tokens.
+	WebhookSecret string
+	Currency      string
+	// SimulateEnabled serves the admin route completing fake payments. It
+	// is meant for development and requires the fake provider.
+	SimulateEnabled bool
+}
+
+type CartConfig struct {
+	ReservationTTL time.Duration
+}
+
+type MailConfig struct {
+	Driver       string
+	From         string
+	FilePath     string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// Values of AuthConfig.RequireVerifiedEmail.
+const (
+	RequireVerifiedNone     = "none"
+	RequireVerifiedLogin    = "login"
+	RequireVerifiedCheckout = "checkout"
+)
+
+type AuthConfig struct {
+	RequireVerifiedEmail string
+	VerificationTTL      time.Duration
+	VerificationURL      string
+	PasswordResetTTL     time.Duration
+	PasswordResetURL     string
+	MFAIssuer            string
+	MFAChallengeTTL      time.Duration
+	// AdminRequireMFA keeps routes guarded by a permission closed to
+	// sessions that did not pass two-factor authentication.
+	AdminRequireMFA bool
+}
+
+// LoginConfig limits failed logins, counted per email and per client IP
+// over FailureWindow.
+type LoginConfig struct {
+	FailureWindow time.Duration
+	// After BackoffAfter failures of an email, or IPBackoffAfter from an
+	// IP, the next attempt waits BackoffBase, doubled for every further
+	// failure up to BackoffMax.
+	BackoffAfter   int
+	IPBackoffAfter int
+	BackoffBase    time.Duration
+	BackoffMax     time.Duration
+	// LockoutAfter failures lock the account for LockoutDuration, unless
+	// it is unlocked earlier through the emailed link or by an admin.
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// UnlockURL is the page the emailed unlock token is appended to.
+	UnlockURL string
+}
+
+// RateLimit allows Limit requests per Window.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitConfig holds the policies of the route groups: Public and Auth
+// count per client IP, User per signed-in user and Admin per API key or
+// user.
+type RateLimitConfig struct {
+	Enabled bool
+	Public  RateLimit
+	Auth    RateLimit
+	User    RateLimit
+	Admin   RateLimit
+}
+
+// LogConfig selects how pkg/logger writes records.
+type LogConfig struct {
+	// Format is "json" or "text".
+	Format string
+	// Level is the minimum level written: debug, info, warn or error.
+	Level string
+}
+
+// MetricsConfig controls the Prometheus metrics served at /metrics.
+type MetricsConfig struct {
+	Enabled bool
+	// Addr is the listener of /metrics, separate from the API port so
+	// that only the monitoring network can reach it.
+	Addr string
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency check of /readyz.
+	CheckTimeout time.Duration
+	// ShutdownDelay is how long /readyz reports not ready before the
+	// server stops accepting requests, so that load balancers notice.
+	ShutdownDelay time.Duration
+}
+
+// Values of TracingConfig.Exporter.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	// Exporter is "none", "stdout" for local use or "otlp".
+	Exporter    string
+	ServiceName string
+	// OTLPEndpoint is the URL of the OTLP/HTTP collector. Empty falls back
+	// to the standard OTEL_EXPORTER_OTLP_* variables.
+	OTLPEndpoint string
+	// SampleRatio is the share of new traces recorded, from 0 to 1.
+	SampleRatio float64
+}
+
+// RefreshTokenTTL is how long a refresh token is valid and JWKSMaxAge how
+// long clients may cache /.well-known/jwks.json. A replaced signing key
+// must keep verifying for both together, or live refresh tokens stop
+// working after a rotation.
+const (
+	RefreshTokenTTL = 24 * time.Hour
+	JWKSMaxAge      = 5 * time.Minute
+)
+
+// Values of JWTConfig.Algorithm.
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+type JWTConfig struct {
+	Algorithm string
+	// KeysDir holds the PEM signing keys used by RS256 and EdDSA.
+	KeysDir string
+	// KeyRotation is how long a key signs before a new one replaces it.
+	KeyRotation time.Duration
+	// KeyRetention is how long a replaced key still verifies tokens. It
+	// must be at least RefreshTokenTTL plus JWKSMaxAge.
+	KeyRetention time.Duration
+	// HS256MigrationUntil is when tokens signed with SECRET stop being
+	// accepted after switching to RS256 or EdDSA. Zero rejects them right
+	// away.
+	HS256MigrationUntil time.Time
+}
+
 type AppConfig struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	Redis    RedisConfig
-	Admin    AdminConfig
+	Server    ServerConfig
+	Postgres  PostgresConfig
+	Redis     RedisConfig
+	Admin     AdminConfig
+	Payment   PaymentConfig
+	Cart      CartConfig
+	Mail      MailConfig
+	Auth      AuthConfig
+	Login     LoginConfig
+	RateLimit RateLimitConfig
+	JWT       JWTConfig
+	Log       LogConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	Health    HealthConfig
 }
 
 var Config AppConfig
 
-func Load() {
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvDuration(key, fallback string) time.Duration {
+	duration, err := time.ParseDuration(getEnv(key, fallback))
+	if err != nil {
+		logger.Error("Invalid duration in " + key + ": " + err.Error())
+		os.Exit(1)
+	}
+	return duration
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logger.Error("Invalid number in " + key + ": " + err.Error())
+		os.Exit(1)
+	}
+	return parsed
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Error("Invalid integer in " + key + ": " + err.Error())
+		os.Exit(1)
+	}
+	return parsed
+}
+
+// getEnvRateLimit parses a limit written as requests/window, such as
+// 100/1m.
+func getEnvRateLimit(key, fallback string) RateLimit {
+	value := getEnv(key, fallback)
+
+	limit, window, found := strings.Cut(value, "/")
+	parsedLimit, err := strconv.Atoi(limit)
+	if !found || err != nil || parsedLimit < 1 {
+		logger.Error(key + " must look like 100/1m")
+		os.Exit(1)
+	}
+	parsedWindow, err := time.ParseDuration(window)
+	if err != nil || parsedWindow <= 0 {
+		logger.Error(key + " must look like 100/1m")
+		os.Exit(1)
+	}
+
+	return RateLimit{Limit: parsedLimit, Window: parsedWindow}
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Error("Invalid boolean in " + key + ": " + err.Error())
+		os.Exit(1)
+	}
+	return parsed
+}
+
+// getEnvTime parses an RFC 3339 time; empty is the zero time.
+func getEnvTime(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logger.Error("Invalid time in " + key + ": " + err.Error())
+		os.Exit(1)
+	}
+	return parsed
+}
+
+// getEnvAddresses parses a comma-separated list of IP addresses and CIDRs.
+func getEnvAddresses(key string) []string {
+	var addresses []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(value); err != nil &&
+			net.ParseIP(value) == nil {
+			logger.Error("Invalid address in " + key + ": " + value)
+			os.Exit(1)
+		}
+		addresses = append(addresses, value)
+	}
+	return addresses
+}
+
+func getEnvSameSite(key, fallback string) http.SameSite {
+	switch strings.ToLower(getEnv(key, fallback)) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		logger.Error(key + " must be one of lax, strict, none")
+		os.Exit(1)
+		return http.SameSiteDefaultMode
+	}
+}
+
+func loadDotEnv() {
 	if err := godotenv.Load(); err != nil {
 		logger.Error("init: No .env file found " + err.Error())
 	}
+}
+
+// LoadPostgres reads only the database and logging settings, for commands
+// such as migrate that connect to Postgres without running the server.
+func LoadPostgres() {
+	loadDotEnv()
+
+	Config = AppConfig{
+		Postgres: PostgresConfig{
+			DSN: os.Getenv("POSTGRES_DSN"),
+		},
+		Log: LogConfig{
+			Format: getEnv("LOG_FORMAT", logger.FormatJSON),
+			Level:  getEnv("LOG_LEVEL", "info"),
+		},
+		Tracing: TracingConfig{
+			Exporter: TracingExporterNone,
+		},
+	}
+
+	if err := logger.Setup(
+		os.Stdout, Config.Log.Format, Config.Log.Level,
+	); err != nil {
+		logger.Error("LOG_FORMAT, LOG_LEVEL: " + err.Error())
+		os.Exit(1)
+	}
+
+	if Config.Postgres.DSN == "" {
+		logger.Error("Missing required environment variables: POSTGRES_DSN")
+		os.Exit(1)
+	}
+}
+
+func Load() {
+	loadDotEnv()
 
 	Config = AppConfig{
 		Server: ServerConfig{
-			Port:    os.Getenv("PORT"),
-			Secret:  os.Getenv("SECRET"),
-			BaseURL: os.Getenv("BASE_URL"),
-			Domain:  os.Getenv("DOMAIN"),
+			Port:           os.Getenv("PORT"),
+			Secret:         os.Getenv("SECRET"),
+			BaseURL:        os.Getenv("BASE_URL"),
+			Domain:         os.Getenv("DOMAIN"),
+			RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", "10s"),
+			Cookies: CookieConfig{
+				Domain:     getEnv("COOKIE_DOMAIN", os.Getenv("DOMAIN")),
+				Secure:     getEnvBool("COOKIE_SECURE", true),
+				SameSite:   getEnvSameSite("COOKIE_SAMESITE", "lax"),
+				AccessTTL:  getEnvDuration("ACCESS_COOKIE_TTL", "15m"),
+				RefreshTTL: getEnvDuration("REFRESH_COOKIE_TTL", "24h"),
+			},
+			TrustedProxies: getEnvAddresses("TRUSTED_PROXIES"),
 		},
 		Postgres: PostgresConfig{
 			DSN: os.Getenv("POSTGRES_DSN"),
@@ -68,21 +421,183 @@ func Load() {
 			Password:    os.Getenv("ADMIN_PASSWORD"),
 			PhoneNumber: os.Getenv("ADMIN_PHONE_NUMBER"),
 		},
+		Payment: PaymentConfig{
+			Provider:        os.Getenv("PAYMENT_PROVIDER"),
+			WebhookSecret:   os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+			Currency:        getEnv("PAYMENT_CURRENCY", "KZT"),
+			SimulateEnabled: getEnvBool("PAYMENT_SIMULATE_ENABLED", false),
+		},
+		Cart: CartConfig{
+			ReservationTTL: getEnvDuration("CART_RESERVATION_TTL", "15m"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "stdout"),
+			From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+			FilePath:     getEnv("MAIL_FILE_PATH", "mail.log"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnv("SMTP_PORT", "1025"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
+		Auth: AuthConfig{
+			RequireVerifiedEmail: getEnv(
+				"REQUIRE_VERIFIED_EMAIL", RequireVerifiedNone,
+			),
+			VerificationTTL:  getEnvDuration("EMAIL_VERIFICATION_TTL", "24h"),
+			VerificationURL:  os.Getenv("EMAIL_VERIFICATION_URL"),
+			PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", "1h"),
+			PasswordResetURL: os.Getenv("PASSWORD_RESET_URL"),
+			MFAIssuer:        getEnv("MFA_ISSUER", "Market REST API"),
+			MFAChallengeTTL:  getEnvDuration("MFA_CHALLENGE_TTL", "5m"),
+			AdminRequireMFA:  getEnvBool("ADMIN_REQUIRE_MFA", false),
+		},
+		Login: LoginConfig{
+			FailureWindow:   getEnvDuration("LOGIN_FAILURE_WINDOW", "1h"),
+			BackoffAfter:    getEnvInt("LOGIN_BACKOFF_AFTER", 3),
+			IPBackoffAfter:  getEnvInt("LOGIN_IP_BACKOFF_AFTER", 20),
+			BackoffBase:     getEnvDuration("LOGIN_BACKOFF_BASE", "1s"),
+			BackoffMax:      getEnvDuration("LOGIN_BACKOFF_MAX", "5m"),
+			LockoutAfter:    getEnvInt("LOGIN_LOCKOUT_AFTER", 10),
+			LockoutDuration: getEnvDuration("LOGIN_LOCKOUT_DURATION", "30m"),
+			UnlockURL:       os.Getenv("ACCOUNT_UNLOCK_URL"),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: getEnvBool("RATE_LIMIT_ENABLED", true),
+			Public:  getEnvRateLimit("RATE_LIMIT_PUBLIC", "300/1m"),
+			Auth:    getEnvRateLimit("RATE_LIMIT_AUTH", "20/1m"),
+			User:    getEnvRateLimit("RATE_LIMIT_USER", "600/1m"),
+			Admin:   getEnvRateLimit("RATE_LIMIT_ADMIN", "1200/1m"),
+		},
+		JWT: JWTConfig{
+			Algorithm:           getEnv("JWT_ALGORITHM", JWTAlgorithmHS256),
+			KeysDir:             getEnv("JWT_KEYS_DIR", "keys"),
+			KeyRotation:         getEnvDuration("JWT_KEY_ROTATION", "720h"),
+			KeyRetention:        getEnvDuration("JWT_KEY_RETENTION", "48h"),
+			HS256MigrationUntil: getEnvTime("JWT_HS256_MIGRATION_UNTIL"),
+		},
+		Log: LogConfig{
+			Format: getEnv("LOG_FORMAT", logger.FormatJSON),
+			Level:  getEnv("LOG_LEVEL", "info"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("METRICS_ENABLED", true),
+			Addr:    getEnv("METRICS_ADDR", ":9090"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", TracingExporterNone),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "market-rest-api"),
+			OTLPEndpoint: os.Getenv("TRACING_OTLP_ENDPOINT"),
+			SampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Health: HealthConfig{
+			CheckTimeout:  getEnvDuration("HEALTH_CHECK_TIMEOUT", "2s"),
+			ShutdownDelay: getEnvDuration("SHUTDOWN_DELAY", "5s"),
+		},
+	}
+
+	// Set up first, so the errors below are written in the chosen format.
+	if err := logger.Setup(
+		os.Stdout, Config.Log.Format, Config.Log.Level,
+	); err != nil {
+		logger.Error("LOG_FORMAT, LOG_LEVEL: " + err.Error())
+		os.Exit(1)
 	}
 
 	envFields := map[string]string{
-		"PORT":               Config.Server.Port,
-		"SECRET":             Config.Server.Secret,
-		"BASE_URL":           Config.Server.BaseURL,
-		"DOMAIN":             Config.Server.Domain,
-		"POSTGRES_DSN":       Config.Postgres.DSN,
-		"REDIS_DSN":          Config.Redis.DSN,
-		"REDIS_PASSWORD":     Config.Redis.RedisPassword,
-		"ADMIN_FIRST_NAME":   Config.Admin.FirstName,
-		"ADMIN_LAST_NAME":    Config.Admin.LastName,
-		"ADMIN_EMAIL":        Config.Admin.Email,
-		"ADMIN_PASSWORD":     Config.Admin.Password,
-		"ADMIN_PHONE_NUMBER": Config.Admin.PhoneNumber,
+		"PORT":                   Config.Server.Port,
+		"SECRET":                 Config.Server.Secret,
+		"BASE_URL":               Config.Server.BaseURL,
+		"DOMAIN":                 Config.Server.Domain,
+		"POSTGRES_DSN":           Config.Postgres.DSN,
+		"REDIS_DSN":              Config.Redis.DSN,
+		"REDIS_PASSWORD":         Config.Redis.RedisPassword,
+		"ADMIN_FIRST_NAME":       Config.Admin.FirstName,
+		"ADMIN_LAST_NAME":        Config.Admin.LastName,
+		"ADMIN_EMAIL":            Config.Admin.Email,
+		"ADMIN_PASSWORD":         Config.Admin.Password,
+		"ADMIN_PHONE_NUMBER":     Config.Admin.PhoneNumber,
+		"PAYMENT_PROVIDER":       Config.Payment.Provider,
+		"PAYMENT_WEBHOOK_SECRET": Config.Payment.WebhookSecret,
+	}
+
+	// Anyone holding the webhook secret can mark orders paid; sharing it
+	// with the token key would let either leak compromise both.
+	if Config.Payment.WebhookSecret != "" &&
+		Config.Payment.WebhookSecret == Config.Server.Secret {
+		logger.Error("PAYMENT_WEBHOOK_SECRET must differ from SECRET")
+		os.Exit(1)
+	}
+
+	if Config.Payment.SimulateEnabled && Config.Payment.Provider != "fake" {
+		logger.Error("PAYMENT_SIMULATE_ENABLED requires PAYMENT_PROVIDER=fake")
+		os.Exit(1)
+	}
+
+	if Config.Mail.Driver == "smtp" {
+		envFields["SMTP_HOST"] = Config.Mail.SMTPHost
+	}
+	if Config.Auth.VerificationURL == "" {
+		Config.Auth.VerificationURL = Config.Server.BaseURL + "verify-email"
+	}
+	if Config.Auth.PasswordResetURL == "" {
+		Config.Auth.PasswordResetURL = Config.Server.BaseURL + "reset-password"
+	}
+	if Config.Login.UnlockURL == "" {
+		Config.Login.UnlockURL = Config.Server.BaseURL + "unlock-account"
+	}
+
+	switch Config.Auth.RequireVerifiedEmail {
+	case RequireVerifiedNone, RequireVerifiedLogin, RequireVerifiedCheckout:
+	default:
+		logger.Error(
+			"REQUIRE_VERIFIED_EMAIL must be one of none, login, checkout",
+		)
+		os.Exit(1)
+	}
+
+	// Browsers drop SameSite=None cookies that are not Secure.
+	if Config.Server.Cookies.SameSite == http.SameSiteNoneMode &&
+		!Config.Server.Cookies.Secure {
+		logger.Error("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+		os.Exit(1)
+	}
+
+	if Config.Login.BackoffAfter < 1 || Config.Login.IPBackoffAfter < 1 ||
+		Config.Login.LockoutAfter < 1 {
+		logger.Error(
+			"LOGIN_BACKOFF_AFTER, LOGIN_IP_BACKOFF_AFTER and " +
+				"LOGIN_LOCKOUT_AFTER must be at least 1",
+		)
+		os.Exit(1)
+	}
+
+	switch Config.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		logger.Error("TRACING_EXPORTER must be one of none, stdout, otlp")
+		os.Exit(1)
+	}
+
+	if Config.Tracing.SampleRatio < 0 || Config.Tracing.SampleRatio > 1 {
+		logger.Error("TRACING_SAMPLE_RATIO must be between 0 and 1")
+		os.Exit(1)
+	}
+
+	switch Config.JWT.Algorithm {
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		logger.Error("JWT_ALGORITHM must be one of HS256, RS256, EdDSA")
+		os.Exit(1)
+	}
+
+	minRetention := RefreshTokenTTL + JWKSMaxAge
+	if Config.JWT.Algorithm != JWTAlgorithmHS256 &&
+		Config.JWT.KeyRetention < minRetention {
+		logger.Error(
+			"JWT_KEY_RETENTION must be at least " + minRetention.String() +
+				", the refresh token lifetime plus the JWKS cache time",
+		)
+		os.Exit(1)
 	}
 
 	missing := []string{}
@@ -4,17 +4,51 @@ import "errors"
 
 // Repository errors
 var (
-	ErrCartNotFound = errors.New("cart not found")
-	ErrItemNotFound = errors.New("item not found")
-	ErrUserNotFound = errors.New("user not found")
+	ErrCartNotFound    = errors.New("cart not found")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrAPIKeyNotFound  = errors.New("API key not found")
 )
 
 // Service errors
 var (
 	ErrUserExists         = errors.New("user exists")
 	ErrUserCreationFailed = errors.New("user creation failed")
-	ErrUserVerifyFailed   = errors.New("user verification failed")
 	ErrInvalidCreds       = errors.New("invalid credentials")
+	ErrEmailNotVerified   = errors.New("email address is not verified")
+
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+	ErrAccountLocked        = errors.New("account is temporarily locked after too many failed login attempts, follow the emailed link to unlock it")
+
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+
+	ErrRoleExists  = errors.New("role already exists")
+	ErrRoleInUse   = errors.New("role is assigned to users")
+	ErrRoleBuiltin = errors.New("built-in roles cannot be changed this way")
+
+	ErrScopeNotHeld = errors.New("API keys can only be given permissions you hold")
+
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrCursorMismatch   = errors.New("cursor was issued for a different sort_by or order")
+	ErrEmptySearchQuery = errors.New("search query is empty")
+
+	ErrCartEmpty         = errors.New("cart is empty")
+	ErrInsufficientStock = errors.New("insufficient stock")
+
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrOrderNotCancellable    = errors.New("only pending or failed orders can be cancelled")
+	ErrOrderNotPayable        = errors.New("only pending or failed orders can be paid")
+	ErrOrderNotRefundable     = errors.New("order cannot be refunded")
+
+	ErrPaymentProvider  = errors.New("payment provider error")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidWebhook   = errors.New("invalid webhook payload")
 
 	ErrTokenGeneration      = errors.New("token generation failed")
 	ErrTokenStorage         = errors.New("token storage failed")
@@ -22,6 +56,9 @@ var (
 	ErrInvalidTokenSub      = errors.New("invalid token subject")
 	ErrTokenDeletionFailed  = errors.New("token deletion failed")
 	ErrTokenValidityTooHigh = errors.New("token validity duration is too high")
+	ErrInvalidOneTimeToken  = errors.New("token is invalid, expired or already used")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrRefreshTokenReused   = errors.New("refresh token was already used, session revoked")
 )
 
 // Handler errors and messages
@@ -33,11 +70,15 @@ var (
 var (
 	ErrClaimsNotFound    = errors.New("claims not found")
 	ErrInvalidClaims     = errors.New("invalid claims")
-	ErrAdminOnly         = errors.New("admin only")
-	ErrAuthHeaderMissing = errors.New("authorization header missing or invalid")
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrMFARequired       = errors.New("two-factor authentication required")
+	ErrAuthHeaderMissing = errors.New("authorization header or access token cookie missing")
 	ErrTokenNotFound     = errors.New("token not found")
+	ErrInvalidCSRFToken  = errors.New("CSRF token missing or invalid")
 	ErrTokenTypeFailed   = errors.New("token type assertion failed")
 	ErrUnauthorizedToken = errors.New("unauthorized or invalid token")
+	ErrInvalidAPIKey     = errors.New("API key is invalid, expired or revoked")
+	ErrRateLimited       = errors.New("rate limit exceeded")
 )
 
 // Connection Errors (for external dependencies)
@@ -0,0 +1,34 @@
+package errors
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// LoginThrottledError refuses a login attempt for RetryAfter. It matches
+// ErrAccountLocked with errors.Is when the account is locked and
+// ErrTooManyLoginAttempts otherwise.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as the
+// Retry-After header carries it.
+func (e *LoginThrottledError) RetryAfterSeconds() int64 {
+	return int64(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf(
+		"%s, retry in %ds", e.Unwrap().Error(), e.RetryAfterSeconds(),
+	)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+	return ErrTooManyLoginAttempts
+}
@@ -0,0 +1,28 @@
+package errors
+
+import "fmt"
+
+// StockShortage describes a single cart line that cannot be fulfilled.
+type StockShortage struct {
+	ItemID    int    `json:"item_id" example:"1"`
+	Name      string `json:"name" example:"T-shirt"`
+	Requested uint   `json:"requested" example:"3"`
+	Available uint   `json:"available" example:"1"`
+}
+
+// InsufficientStockError reports every cart line that exceeds stock. It
+// matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	Lines []StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf(
+		"%s: %d line(s) exceed available stock",
+		ErrInsufficientStock.Error(), len(e.Lines),
+	)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/ginhelpers"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+const (
+	MsgAPIKeyRevoked = "API key revoked successfully"
+)
+
+type APIKeyHandler struct {
+	service services.APIKeyService
+}
+
+func NewAPIKeyHandler(service services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrScopeNotHeld):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *APIKeyHandler) HandleListAPIKeys(ctx *gin.Context) {
+	keys, err := h.service.ListAPIKeys(ctx.Request.Context())
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = keys[i].Response()
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *APIKeyHandler) HandleCreateAPIKey(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.CreateAPIKey](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := ginhelpers.GetContextValue[*jwt.Claims](ctx, "claims")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, plaintext, err := h.service.CreateAPIKey(
+		ctx.Request.Context(), userID, claims.Permissions, req,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(
+		http.StatusCreated,
+		models.CreatedAPIKey{APIKeyResponse: key.Response(), Key: plaintext},
+	)
+}
+
+func (h *APIKeyHandler) HandleRevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(ctx.Request.Context(), id); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgAPIKeyRevoked})
+}
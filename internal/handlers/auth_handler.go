@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -24,6 +25,29 @@ func NewAuthHandler(
 	return &AuthHandler{service: authService}
 }
 
+// clientInfo describes the device behind the request, for the session
+// list.
+func clientInfo(ctx *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
+
+func loginErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrInvalidCreds):
+		return http.StatusUnauthorized
+	case errors.Is(err, errs.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrTooManyLoginAttempts),
+		errors.Is(err, errs.ErrAccountLocked):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (h *AuthHandler) HandleRegister(ctx *gin.Context) {
 	req, err := ginhelpers.GetContextValue[*models.RegisterUser](ctx, "model")
 	if err != nil {
@@ -38,7 +62,9 @@ func (h *AuthHandler) HandleRegister(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.service.RegisterUser(req)
+	accessToken, refreshToken, err := h.service.RegisterUser(
+		ctx.Request.Context(), req, clientInfo(ctx),
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		if errors.Is(err, errs.ErrUserExists) {
@@ -49,20 +75,17 @@ func (h *AuthHandler) HandleRegister(ctx *gin.Context) {
 		return
 	}
 
-	if err := jwt.SetAuthCookies(
-		ctx.Writer, accessToken, refreshToken,
-	); err != nil {
-		_ = ctx.Error(err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// No tokens are issued while logins wait for a verified email.
+	if accessToken == "" {
+		ctx.JSON(
+			http.StatusCreated, gin.H{
+				"message": "check your email to verify the address",
+			},
+		)
 		return
 	}
 
-	ctx.JSON(
-		http.StatusCreated, gin.H{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-		},
-	)
+	h.respondWithTokens(ctx, http.StatusCreated, accessToken, refreshToken)
 }
 
 func (h *AuthHandler) HandleLogin(ctx *gin.Context) {
@@ -73,8 +96,48 @@ func (h *AuthHandler) HandleLogin(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.service.LoginUser(
-		req.Email, req.Password,
+	result, err := h.service.LoginUser(
+		ctx.Request.Context(), req.Email, req.Password, clientInfo(ctx),
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		var throttled *errs.LoginThrottledError
+		if errors.As(err, &throttled) {
+			ctx.Header(
+				"Retry-After",
+				strconv.FormatInt(throttled.RetryAfterSeconds(), 10),
+			)
+		}
+		ctx.JSON(loginErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Accounts with two-factor authentication finish at /login/mfa.
+	if result.MFAToken != "" {
+		ctx.JSON(
+			http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    result.MFAToken,
+			},
+		)
+		return
+	}
+
+	h.respondWithTokens(
+		ctx, http.StatusOK, result.AccessToken, result.RefreshToken,
+	)
+}
+
+func (h *AuthHandler) HandleLoginMFA(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.MFALogin](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accessToken, refreshToken, err := h.service.LoginMFA(
+		ctx.Request.Context(), req.MFAToken, req.Code, clientInfo(ctx),
 	)
 	if err != nil {
 		_ = ctx.Error(err)
@@ -82,38 +145,47 @@ func (h *AuthHandler) HandleLogin(ctx *gin.Context) {
 		return
 	}
 
-	if err := jwt.SetAuthCookies(
+	h.respondWithTokens(ctx, http.StatusOK, accessToken, refreshToken)
+}
+
+func (h *AuthHandler) respondWithTokens(
+	ctx *gin.Context, status int, accessToken, refreshToken string,
+) {
+	csrfToken, err := jwt.SetAuthCookies(
 		ctx.Writer, accessToken, refreshToken,
-	); err != nil {
+	)
+	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.JSON(
-		http.StatusOK, gin.H{
+		status, gin.H{
 			"access_token":  accessToken,
 			"refresh_token": refreshToken,
+			"csrf_token":    csrfToken,
 		},
 	)
 }
 
+// HandleLogout ends the session of the access token the request was
+// authenticated with.
 func (h *AuthHandler) HandleLogout(ctx *gin.Context) {
-	accessToken, err := ctx.Cookie("access_token")
+	accessToken, err := ginhelpers.GetContextValue[string](ctx, "tokenString")
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := ctx.Cookie("refresh_token")
-	if err != nil {
-		_ = ctx.Error(err)
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
+	// Only tokens issued before sessions existed need the refresh token,
+	// which browsers keep in a cookie.
+	refreshToken, _ := ctx.Cookie(jwt.RefreshTokenCookieName)
 
-	if err := h.service.LogoutUser(accessToken, refreshToken); err != nil {
+	if err := h.service.LogoutUser(
+		ctx.Request.Context(), accessToken, refreshToken,
+	); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -128,33 +200,252 @@ func (h *AuthHandler) HandleLogout(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "logout successfull"})
 }
 
+// HandleRefreshToken takes the refresh token from the request body or,
+// for browsers, the refresh token cookie, in which case the CSRF token of
+// its session must be sent as well.
 func (h *AuthHandler) HandleRefreshToken(ctx *gin.Context) {
-	refreshToken, err := ctx.Cookie("refresh_token")
+	refreshToken, err := refreshTokenOf(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, errs.ErrInvalidCSRFToken):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrTokenNotFound),
+			errors.Is(err, errs.ErrTokenParsingFailed):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	accessToken, newRefreshToken, err := h.service.RefreshTokens(refreshToken)
+	accessToken, newRefreshToken, err := h.service.RefreshTokens(
+		ctx.Request.Context(), refreshToken,
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := jwt.SetAuthCookies(
-		ctx.Writer, accessToken, newRefreshToken,
+	h.respondWithTokens(
+		ctx, http.StatusCreated, accessToken, newRefreshToken,
+	)
+}
+
+func refreshTokenOf(ctx *gin.Context) (string, error) {
+	if ctx.Request.ContentLength > 0 {
+		var req models.RefreshTokens
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return "", err
+		}
+		return req.RefreshToken, nil
+	}
+
+	refreshToken, err := ctx.Cookie(jwt.RefreshTokenCookieName)
+	if err != nil {
+		return "", errs.ErrTokenNotFound
+	}
+
+	claims, err := jwt.ParseJWT(refreshToken)
+	if err != nil {
+		return "", errs.ErrTokenParsingFailed
+	}
+	if err := jwt.VerifyCSRF(ctx.Request, claims.SessionID); err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+func (h *AuthHandler) HandleVerifyEmail(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.VerifyEmail](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.VerifyEmail(
+		ctx.Request.Context(), req.Token,
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrInvalidOneTimeToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+func (h *AuthHandler) HandleResendVerification(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.ResendVerification](
+		ctx, "model",
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResendVerification(
+		ctx.Request.Context(), req.Email,
 	); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// The same answer is given for unknown and verified addresses.
 	ctx.JSON(
-		http.StatusCreated, gin.H{
-			"access_token":  accessToken,
-			"refresh_token": newRefreshToken,
+		http.StatusAccepted, gin.H{
+			"message": "if the address needs verification, an email is on its way",
 		},
 	)
 }
+
+func (h *AuthHandler) HandleForgotPassword(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.ForgotPassword](
+		ctx, "model",
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ForgotPassword(
+		ctx.Request.Context(), req.Email,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The same answer is given for unknown addresses.
+	ctx.JSON(
+		http.StatusAccepted, gin.H{
+			"message": "if the address has an account, a reset link is on its way",
+		},
+	)
+}
+
+func (h *AuthHandler) HandleResetPassword(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.ResetPassword](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResetPassword(
+		ctx.Request.Context(), req.Token, req.Password,
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrInvalidOneTimeToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Every session was revoked, including the caller's, if any.
+	if err := jwt.DeleteAuthCookies(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "password has been reset"})
+}
+
+// HandleJWKS publishes the public keys access tokens can be verified with.
+// A key is listed from the moment it starts signing, so verifiers should
+// refetch the document when they meet a kid they do not know.
+func (h *AuthHandler) HandleJWKS(ctx *gin.Context) {
+	ctx.Header(
+		"Cache-Control",
+		"public, max-age="+strconv.Itoa(int(jwt.JWKSMaxAge.Seconds())),
+	)
+	ctx.JSON(http.StatusOK, jwt.PublicKeys())
+}
+
+func (h *AuthHandler) HandleUnlockAccount(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.UnlockAccount](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UnlockAccount(
+		ctx.Request.Context(), req.Token,
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrInvalidOneTimeToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func (h *AuthHandler) HandleGetLoginLockout(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	lockout, err := h.service.LoginLockout(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lockout)
+}
+
+func (h *AuthHandler) HandleUnlockUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	if err := h.service.UnlockUser(ctx.Request.Context(), userID); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
@@ -24,7 +24,7 @@ func getCart(ctx *gin.Context, cartService services.CartService) (
 	if err != nil {
 		return nil, err
 	}
-	cart, err := cartService.GetCartByUserID(userID)
+	cart, err := cartService.GetCartByUserID(ctx.Request.Context(), userID)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +77,7 @@ func (h *CartHandler) HandleAddItem(ctx *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.GetItemByID(itemID)
+	item, err := h.itemService.GetItemByID(ctx.Request.Context(), itemID)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -90,7 +90,9 @@ func (h *CartHandler) HandleAddItem(ctx *gin.Context) {
 		return
 	}
 
-	cartItem, err := h.cartService.AddItem(cart.ID, itemID)
+	cartItem, err := h.cartService.AddItem(
+		ctx.Request.Context(), cart.ID, itemID,
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -118,7 +120,7 @@ func (h *CartHandler) HandleUpdateItem(ctx *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.GetItemByID(itemID)
+	item, err := h.itemService.GetItemByID(ctx.Request.Context(), itemID)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -141,7 +143,7 @@ func (h *CartHandler) HandleUpdateItem(ctx *gin.Context) {
 	}
 
 	cartItem, err := h.cartService.UpdateItem(
-		cart.ID, itemID, updateItem.Quantity,
+		ctx.Request.Context(), cart.ID, itemID, updateItem.Quantity,
 	)
 	if err != nil {
 		_ = ctx.Error(err)
@@ -170,7 +172,7 @@ func (h *CartHandler) HandleDeleteItem(ctx *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.GetItemByID(itemID)
+	item, err := h.itemService.GetItemByID(ctx.Request.Context(), itemID)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -183,7 +185,9 @@ func (h *CartHandler) HandleDeleteItem(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.cartService.DeleteItem(cart.ID, itemID); err != nil {
+	if err := h.cartService.DeleteItem(
+		ctx.Request.Context(), cart.ID, itemID,
+	); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -200,7 +204,9 @@ func (h *CartHandler) HandleClearCart(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.cartService.ClearCart(cart.ID); err != nil {
+	if err := h.cartService.ClearCart(
+		ctx.Request.Context(), cart.ID,
+	); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/health"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// HandleLiveness answers as long as the process can serve requests; it
+// checks no dependency, so an outage of Postgres or Redis does not get
+// the API restarted.
+func (h *HealthHandler) HandleLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// HandleReadiness reports every dependency with its latency, answering
+// 503 when one is down or the server is shutting down. Why a check failed
+// is only logged.
+func (h *HealthHandler) HandleReadiness(ctx *gin.Context) {
+	report := h.checker.Run(ctx.Request.Context())
+	for name, result := range report.Checks {
+		if result.Error != "" {
+			logger.FromContext(ctx.Request.Context()).Warn(
+				"Readiness check failed", "check", name, "error", result.Error,
+			)
+		}
+	}
+	if !report.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -33,7 +35,7 @@ func (h *ItemHandler) HandleCreateItem(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.CreateItem(item); err != nil {
+	if err := h.service.CreateItem(ctx.Request.Context(), item); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,7 +55,7 @@ func (h *ItemHandler) HandleGetItemByID(ctx *gin.Context) {
 		return
 	}
 
-	item, err := h.service.GetItemByID(id)
+	item, err := h.service.GetItemByID(ctx.Request.Context(), id)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -63,15 +65,86 @@ func (h *ItemHandler) HandleGetItemByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, item)
 }
 
+func itemPageLink(ctx *gin.Context, set map[string]string) string {
+	query := ctx.Request.URL.Query()
+	for key, value := range set {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+
+	link := url.URL{Path: ctx.Request.URL.Path, RawQuery: query.Encode()}
+	return link.String()
+}
+
 func (h *ItemHandler) HandleGetAllItems(ctx *gin.Context) {
-	items, err := h.service.GetAllItems()
+	var opts models.ItemQueryOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := opts.Validate(); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.GetAllItems(ctx.Request.Context(), &opts)
 	if err != nil {
 		_ = ctx.Error(err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, errs.ErrInvalidCursor) ||
+			errors.Is(err, errs.ErrCursorMismatch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if page.HasMore {
+		if opts.Cursor != "" {
+			page.Links.Next = itemPageLink(
+				ctx, map[string]string{"cursor": page.NextCursor},
+			)
+		} else {
+			page.Links.Next = itemPageLink(
+				ctx, map[string]string{"page": strconv.Itoa(page.Page + 1)},
+			)
+		}
+	}
+	if page.Page > 1 {
+		page.Links.Prev = itemPageLink(
+			ctx, map[string]string{"page": strconv.Itoa(page.Page - 1)},
+		)
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (h *ItemHandler) HandleSearchItems(ctx *gin.Context) {
+	var opts models.ItemSearchOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.SearchItems(ctx.Request.Context(), &opts)
+	if err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrEmptySearchQuery) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, items)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (h *ItemHandler) HandleUpdateItem(ctx *gin.Context) {
@@ -94,7 +167,9 @@ func (h *ItemHandler) HandleUpdateItem(ctx *gin.Context) {
 		return
 	}
 
-	updatedItem, err := h.service.UpdateItem(id, updateItemDTO)
+	updatedItem, err := h.service.UpdateItem(
+		ctx.Request.Context(), id, updateItemDTO,
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -115,7 +190,7 @@ func (h *ItemHandler) HandleDeleteItem(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteItem(id); err != nil {
+	if err := h.service.DeleteItem(ctx.Request.Context(), id); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
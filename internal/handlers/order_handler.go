@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/ginhelpers"
+)
+
+type OrderHandler struct {
+	service services.OrderService
+}
+
+func NewOrderHandler(service services.OrderService) *OrderHandler {
+	return &OrderHandler{service: service}
+}
+
+func (h *OrderHandler) HandleCheckout(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.Checkout(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+
+		var stockErr *errs.InsufficientStockError
+		switch {
+		case errors.As(err, &stockErr):
+			ctx.JSON(
+				http.StatusConflict, gin.H{
+					"error": errs.ErrInsufficientStock.Error(),
+					"lines": stockErr.Lines,
+				},
+			)
+		case errors.Is(err, errs.ErrCartEmpty):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrCartNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrEmailNotVerified):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, order)
+}
+
+func (h *OrderHandler) HandleGetOrders(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, err := h.service.GetOrdersByUserID(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, orders)
+}
+
+func (h *OrderHandler) HandleGetOrderByID(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	idStr := ctx.Param("id")
+	orderID, err := strconv.Atoi(idStr)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	order, err := h.service.GetUserOrderByID(
+		ctx.Request.Context(), userID, orderID,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrOrderNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+func (h *OrderHandler) HandleCancelOrder(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	idStr := ctx.Param("id")
+	orderID, err := strconv.Atoi(idStr)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	order, err := h.service.CancelOrder(ctx.Request.Context(), userID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		switch {
+		case errors.Is(err, errs.ErrOrderNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrOrderNotCancellable):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+func (h *OrderHandler) HandleUpdateOrderStatus(ctx *gin.Context) {
+	adminID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	update, err := ginhelpers.GetContextValue[*models.UpdateOrderStatus](
+		ctx, "model",
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	idStr := ctx.Param("id")
+	orderID, err := strconv.Atoi(idStr)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	order, err := h.service.UpdateOrderStatus(
+		ctx.Request.Context(), adminID, orderID, update,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		switch {
+		case errors.Is(err, errs.ErrOrderNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrInvalidOrderTransition):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
+)
+
+const (
+	MsgWebhookProcessed = "webhook processed"
+
+	maxWebhookBodySize = 64 << 10
+)
+
+type PaymentHandler struct {
+	service   services.PaymentService
+	simulator payments.Simulator
+}
+
+// NewPaymentHandler creates the payment handler. simulator may be nil; it is
+// only set when the configured provider can emit its own webhooks.
+func NewPaymentHandler(
+	service services.PaymentService, simulator payments.Simulator,
+) *PaymentHandler {
+	return &PaymentHandler{service: service, simulator: simulator}
+}
+
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrOrderNotFound),
+		errors.Is(err, errs.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrOrderNotPayable),
+		errors.Is(err, errs.ErrOrderNotRefundable),
+		errors.Is(err, errs.ErrInvalidOrderTransition):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, errs.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrPaymentProvider):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *PaymentHandler) HandleCreatePayment(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	idStr := ctx.Param("id")
+	orderID, err := strconv.Atoi(idStr)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	payment, err := h.service.CreatePayment(
+		ctx.Request.Context(), userID, orderID,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, payment)
+}
+
+func (h *PaymentHandler) HandleWebhook(ctx *gin.Context) {
+	payload, err := io.ReadAll(
+		http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookBodySize),
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signature := ctx.GetHeader(payments.SignatureHeader)
+	if err := h.service.HandleWebhook(
+		ctx.Request.Context(), payload, signature,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgWebhookProcessed})
+}
+
+func (h *PaymentHandler) HandleRefundOrder(ctx *gin.Context) {
+	adminID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	idStr := ctx.Param("id")
+	orderID, err := strconv.Atoi(idStr)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	order, err := h.service.RefundOrder(ctx.Request.Context(), adminID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// HandleSimulatePayment lets developers complete a payment against the fake
+// provider. The generated webhook goes through the same verification path
+// as one delivered by a real gateway.
+func (h *PaymentHandler) HandleSimulatePayment(ctx *gin.Context) {
+	if h.simulator == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	var eventType payments.EventType
+	switch ctx.Param("outcome") {
+	case "succeed":
+		eventType = payments.EventPaymentAuthorized
+	case "fail":
+		eventType = payments.EventPaymentFailed
+	default:
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{"error": "outcome must be succeed or fail"},
+		)
+		return
+	}
+
+	payload, signature, err := h.simulator.Simulate(
+		ctx.Param("intent_id"), eventType,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.HandleWebhook(
+		ctx.Request.Context(), payload, signature,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgWebhookProcessed})
+}
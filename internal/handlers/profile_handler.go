@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -56,7 +57,7 @@ func (h *ProfileHandler) HandleGetProfile(ctx *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(ctx.Request.Context(), userID)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -97,7 +98,9 @@ func (h *ProfileHandler) HandleUpdateProfile(ctx *gin.Context) {
 		return
 	}
 
-	updatedUser, err := h.userService.UpdateUserByID(userID, updateUser)
+	updatedUser, err := h.userService.UpdateUserByID(
+		ctx.Request.Context(), userID, updateUser,
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -115,27 +118,73 @@ func (h *ProfileHandler) HandleUpdateProfile(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, userResponse)
 }
 
+// HandleDeleteProfile revokes every session of the user, not only the
+// current one, before deleting the account.
 func (h *ProfileHandler) HandleDeleteProfile(ctx *gin.Context) {
-	accessToken, err := ctx.Cookie("access_token")
+	userID, err := getUserIDFromContext(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := ctx.Cookie("refresh_token")
-	if err != nil {
+	if err := h.authService.RevokeAllSessions(
+		ctx.Request.Context(), userID,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.DeleteUserByID(
+		ctx.Request.Context(), userID,
+	); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.authService.LogoutUser(accessToken, refreshToken); err != nil {
+	if err := jwt.DeleteAuthCookies(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgProfileDeleted})
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrMFAAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrMFANotEnrolled),
+		errors.Is(err, errs.ErrMFANotEnabled),
+		errors.Is(err, errs.ErrInvalidMFACode):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *ProfileHandler) HandleEnrollMFA(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	enrollment, err := h.authService.EnrollMFA(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (h *ProfileHandler) HandleConfirmMFA(ctx *gin.Context) {
 	userID, err := getUserIDFromContext(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
@@ -143,17 +192,50 @@ func (h *ProfileHandler) HandleDeleteProfile(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUserByID(userID); err != nil {
+	req, err := ginhelpers.GetContextValue[*models.MFACode](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authService.ConfirmMFA(
+		ctx.Request.Context(), userID, req.Code,
+	)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *ProfileHandler) HandleDisableMFA(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := jwt.DeleteAuthCookies(ctx.Writer); err != nil {
+	req, err := ginhelpers.GetContextValue[*models.MFACode](ctx, "model")
+	if err != nil {
 		_ = ctx.Error(err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": MsgProfileDeleted})
+	if err := h.authService.DisableMFA(
+		ctx.Request.Context(), userID, req.Code,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		gin.H{"message": "two-factor authentication disabled"},
+	)
 }
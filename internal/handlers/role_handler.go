@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/ginhelpers"
+)
+
+const (
+	MsgRoleDeleted  = "role deleted successfully"
+	MsgRoleAssigned = "role assigned, it applies from the user's next token refresh"
+)
+
+type RoleHandler struct {
+	service services.RoleService
+}
+
+func NewRoleHandler(service services.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrRoleNotFound),
+		errors.Is(err, errs.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrRoleExists),
+		errors.Is(err, errs.ErrRoleInUse),
+		errors.Is(err, errs.ErrRoleBuiltin):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *RoleHandler) HandleListPermissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.Permissions)
+}
+
+func (h *RoleHandler) HandleListRoles(ctx *gin.Context) {
+	roles, err := h.service.ListRoles(ctx.Request.Context())
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.RoleResponse, len(roles))
+	for i := range roles {
+		response[i] = roles[i].Response()
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *RoleHandler) HandleGetRole(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	role, err := h.service.GetRole(ctx.Request.Context(), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role.Response())
+}
+
+func (h *RoleHandler) HandleCreateRole(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.CreateRole](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.CreateRole(ctx.Request.Context(), req)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role.Response())
+}
+
+func (h *RoleHandler) HandleUpdateRole(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.UpdateRole](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	role, err := h.service.UpdateRole(ctx.Request.Context(), id, req)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role.Response())
+}
+
+func (h *RoleHandler) HandleDeleteRole(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	if err := h.service.DeleteRole(ctx.Request.Context(), id); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgRoleDeleted})
+}
+
+func (h *RoleHandler) HandleAssignRole(ctx *gin.Context) {
+	req, err := ginhelpers.GetContextValue[*models.AssignRole](ctx, "model")
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	if err := h.service.AssignRole(
+		ctx.Request.Context(), userID, req.Role,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": MsgRoleAssigned})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+type SessionHandler struct {
+	service services.AuthService
+}
+
+func NewSessionHandler(authService services.AuthService) *SessionHandler {
+	return &SessionHandler{service: authService}
+}
+
+func (h *SessionHandler) HandleListSessions(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var currentID string
+	if claims, ok := ctx.MustGet("claims").(*jwt.Claims); ok {
+		currentID = claims.SessionID
+	}
+
+	response := make([]models.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = models.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			MFA:        session.MFA,
+			Current:    session.ID == currentID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *SessionHandler) HandleRevokeSession(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RevokeSession(
+		ctx.Request.Context(), userID, ctx.Param("id"),
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// HandleRevokeAllSessions logs the caller out everywhere, this device
+// included.
+func (h *SessionHandler) HandleRevokeAllSessions(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RevokeAllSessions(
+		ctx.Request.Context(), userID,
+	); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := jwt.DeleteAuthCookies(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
+
+func (h *SessionHandler) HandleRevokeUserSessions(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.JSON(
+			http.StatusBadRequest, gin.H{"error": errs.ErrInvalidID.Error()},
+		)
+		return
+	}
+
+	if err := h.service.RevokeAllSessions(
+		ctx.Request.Context(), userID,
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,11 +19,14 @@ const (
 )
 
 type UserHandler struct {
-	service services.UserService
+	service     services.UserService
+	authService services.AuthService
 }
 
-func NewUserHandler(service services.UserService) *UserHandler {
-	return &UserHandler{service: service}
+func NewUserHandler(
+	service services.UserService, authService services.AuthService,
+) *UserHandler {
+	return &UserHandler{service: service, authService: authService}
 }
 
 func (h *UserHandler) HandleGetUserByID(ctx *gin.Context) {
@@ -36,7 +40,7 @@ func (h *UserHandler) HandleGetUserByID(ctx *gin.Context) {
 		return
 	}
 
-	user, err := h.service.GetUserByID(id)
+	user, err := h.service.GetUserByID(ctx.Request.Context(), id)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -47,7 +51,7 @@ func (h *UserHandler) HandleGetUserByID(ctx *gin.Context) {
 }
 
 func (h *UserHandler) HandleGetAllUsers(ctx *gin.Context) {
-	users, err := h.service.GetAllUsers()
+	users, err := h.service.GetAllUsers(ctx.Request.Context())
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -83,7 +87,9 @@ func (h *UserHandler) HandleUpdateUserByID(ctx *gin.Context) {
 		return
 	}
 
-	updatedUser, err := h.service.UpdateUserByID(userID, updateUser)
+	updatedUser, err := h.service.UpdateUserByID(
+		ctx.Request.Context(), userID, updateUser,
+	)
 	if err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -112,7 +118,21 @@ func (h *UserHandler) HandleDeleteUser(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteUserByID(id); err != nil {
+	// Access tokens of the user's sessions would otherwise stay valid until
+	// they expire.
+	if err := h.authService.RevokeAllSessions(
+		ctx.Request.Context(), id,
+	); err != nil {
+		_ = ctx.Error(err)
+		if errors.Is(err, errs.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.service.DeleteUserByID(ctx.Request.Context(), id); err != nil {
 		_ = ctx.Error(err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+// setupCart creates an item with the given stock and a user with an empty
+// cart. Both are removed when the test ends.
+func setupCart(t *testing.T, stock uint) (*gorm.DB, *models.Cart, *models.Item) {
+	if err := godotenv.Load("../../.env"); err != nil {
+		t.Fatal("failed to load .env file:", err)
+	}
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN not set, skipping integration test")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(
+		t, db.AutoMigrate(
+			&models.Item{},
+			&models.User{},
+			&models.Cart{},
+			&models.CartItem{},
+			&models.StockReservation{},
+			&models.Order{},
+			&models.OrderItem{},
+			&models.OrderStatusHistory{},
+		),
+	)
+
+	suffix := time.Now().UnixNano()
+	item := &models.Item{
+		Name:  fmt.Sprintf("Concurrency item %d", suffix),
+		Price: 10,
+		Stock: stock,
+	}
+	require.NoError(t, db.Create(item).Error)
+
+	user := &models.User{
+		FirstName:   "Cart",
+		LastName:    "Tester",
+		Email:       fmt.Sprintf("cart-%d@example.com", suffix),
+		Password:    "password123",
+		PhoneNumber: "+70000000000",
+		Role:        models.RoleUser,
+	}
+	require.NoError(t, db.Create(user).Error)
+
+	t.Cleanup(
+		func() {
+			db.Delete(&models.User{}, user.ID)
+			db.Delete(&models.Item{}, item.ID)
+		},
+	)
+
+	var cart models.Cart
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&cart).Error)
+
+	return db, &cart, item
+}
+
+// hammerAdd calls Add for the same cart line from many goroutines at once
+// and returns how many calls succeeded and how many hit the stock limit.
+func hammerAdd(
+	t *testing.T, repo repositories.CartRepository, cartID, itemID, calls int,
+) (added int, rejected int) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		start = make(chan struct{})
+	)
+
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := repo.Add(context.Background(), cartID, itemID, time.Minute)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				added++
+			case errors.Is(err, errs.ErrInsufficientStock):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	return added, rejected
+}
+
+func TestCartConcurrentAdd_NoLostUpdates(t *testing.T) {
+	db, cart, item := setupCart(t, 100)
+	repo := repositories.NewCartRepository(db)
+
+	added, rejected := hammerAdd(t, repo, cart.ID, item.ID, 50)
+	assert.Equal(t, 50, added)
+	assert.Zero(t, rejected)
+
+	cartItem, err := repo.GetCartItem(context.Background(), cart.ID, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cartItem)
+	assert.Equal(t, uint(50), cartItem.Quantity)
+
+	var reservation models.StockReservation
+	require.NoError(
+		t, db.
+			Where("cart_id = ? AND item_id = ?", cart.ID, item.ID).
+			First(&reservation).Error,
+	)
+	assert.Equal(t, uint(50), reservation.Quantity)
+}
+
+func TestCartConcurrentAdd_StockLimit(t *testing.T) {
+	db, cart, item := setupCart(t, 20)
+	repo := repositories.NewCartRepository(db)
+
+	added, rejected := hammerAdd(t, repo, cart.ID, item.ID, 50)
+	assert.Equal(t, 20, added)
+	assert.Equal(t, 30, rejected)
+
+	cartItem, err := repo.GetCartItem(context.Background(), cart.ID, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cartItem)
+	assert.Equal(t, uint(20), cartItem.Quantity)
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/DaniilKalts/market-rest-api/internal/migrations"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := migrations.Embedded()
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for i, m := range all {
+		assert.NotEmpty(t, m.Up, "migration %d has no up", m.Version)
+		assert.NotEmpty(t, m.Down, "migration %d has no down", m.Version)
+		if i > 0 {
+			assert.Greater(t, m.Version, all[i-1].Version)
+		}
+	}
+}
+
+func TestLoadMigrations_MissingDown(t *testing.T) {
+	_, err := migrations.Load(
+		fstest.MapFS{
+			"0001_widgets.up.sql": {Data: []byte("CREATE TABLE widgets ();")},
+		},
+	)
+	assert.Error(t, err)
+}
+
+// TestMigrator_UpStatusDown runs its own migrations, numbered far above the
+// real ones, against the database from .env and reverts them afterwards.
+func TestMigrator_UpStatusDown(t *testing.T) {
+	if err := godotenv.Load("../../.env"); err != nil {
+		t.Fatal("failed to load .env file:", err)
+	}
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN not set, skipping integration test")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+
+	all, err := migrations.Load(
+		fstest.MapFS{
+			"900001_create_widgets.up.sql": {
+				Data: []byte("CREATE TABLE migrator_test_widgets (id int);"),
+			},
+			"900001_create_widgets.down.sql": {
+				Data: []byte("DROP TABLE migrator_test_widgets;"),
+			},
+			"900002_add_name.up.sql": {
+				Data: []byte(
+					"ALTER TABLE migrator_test_widgets ADD COLUMN name text;",
+				),
+			},
+			"900002_add_name.down.sql": {
+				Data: []byte(
+					"ALTER TABLE migrator_test_widgets DROP COLUMN name;",
+				),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	migrator := migrations.NewMigrator(db, all)
+	t.Cleanup(
+		func() {
+			_, _ = migrator.Down(ctx, len(all))
+		},
+	)
+
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.True(t, db.Migrator().HasColumn("migrator_test_widgets", "name"))
+
+	// Running up again is a no-op.
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, 900002, reverted[0].Version)
+	assert.False(t, db.Migrator().HasColumn("migrator_test_widgets", "name"))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	byVersion := make(map[int]migrations.Status)
+	for _, status := range statuses {
+		byVersion[status.Version] = status
+	}
+	assert.NotNil(t, byVersion[900001].AppliedAt)
+	assert.Nil(t, byVersion[900002].AppliedAt)
+}
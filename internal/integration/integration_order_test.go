@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+func TestOrderConcurrentCheckout_SingleOrder(t *testing.T) {
+	db, cart, item := setupCart(t, 10)
+	cartRepo := repositories.NewCartRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+
+	t.Cleanup(
+		func() {
+			db.Where("user_id = ?", cart.UserID).Delete(&models.Order{})
+		},
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := cartRepo.Add(
+			context.Background(), cart.ID, item.ID, time.Minute,
+		)
+		require.NoError(t, err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		start   = make(chan struct{})
+		created int
+		empty   int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := orderRepo.CreateFromCart(
+				context.Background(), cart.UserID,
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, errs.ErrCartEmpty):
+				empty++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 9, empty)
+
+	var stock models.Item
+	require.NoError(t, db.First(&stock, item.ID).Error)
+	assert.Equal(t, uint(7), stock.Stock)
+
+	var orders int64
+	require.NoError(
+		t, db.Model(&models.Order{}).
+			Where("user_id = ?", cart.UserID).
+			Count(&orders).Error,
+	)
+	assert.Equal(t, int64(1), orders)
+}
@@ -0,0 +1,37 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+func TestItemSearch_SnippetIsEscaped(t *testing.T) {
+	db, _, item := setupCart(t, 1)
+
+	term := fmt.Sprintf("escapetest%d", time.Now().UnixNano())
+	item.Description = term + ` <script>alert("x")</script> & more`
+	require.NoError(t, db.Save(item).Error)
+
+	results, err := repositories.NewItemRepository(db).Search(
+		context.Background(), &models.ItemSearchOptions{Query: term, Page: 1, Limit: 10},
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	snippet := results[0].Snippet
+	assert.Contains(t, snippet, "<mark>"+term+"</mark>")
+	assert.Contains(t, snippet, "&lt;script&gt;")
+	assert.Contains(t, snippet, "&amp;")
+	assert.NotContains(t, strings.ReplaceAll(
+		strings.ReplaceAll(snippet, "<mark>", ""), "</mark>", "",
+	), "<")
+}
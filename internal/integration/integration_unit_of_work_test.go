@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	db, cart, item := setupCart(t, 10)
+	ctx := context.Background()
+	uow := repositories.NewUnitOfWork(db)
+	failure := errors.New("abort")
+
+	err := uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if _, err := repos.Carts.Add(
+				ctx, cart.ID, item.ID, time.Minute,
+			); err != nil {
+				return err
+			}
+
+			item.Stock = 0
+			if err := repos.Items.Update(ctx, item); err != nil {
+				return err
+			}
+
+			return failure
+		},
+	)
+	assert.Equal(t, failure, err)
+
+	repo := repositories.NewCartRepository(db)
+	cartItem, err := repo.GetCartItem(ctx, cart.ID, item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, cartItem, "cart line must be rolled back")
+
+	var reservations int64
+	require.NoError(
+		t, db.
+			Model(&models.StockReservation{}).
+			Where("cart_id = ?", cart.ID).
+			Count(&reservations).Error,
+	)
+	assert.Zero(t, reservations, "reservation must be rolled back")
+
+	var stored models.Item
+	require.NoError(t, db.First(&stored, item.ID).Error)
+	assert.Equal(t, uint(10), stored.Stock, "stock must be rolled back")
+}
+
+func TestUnitOfWork_Commits(t *testing.T) {
+	db, cart, item := setupCart(t, 10)
+	ctx := context.Background()
+	uow := repositories.NewUnitOfWork(db)
+
+	err := uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			_, err := repos.Carts.Add(ctx, cart.ID, item.ID, time.Minute)
+			return err
+		},
+	)
+	require.NoError(t, err)
+
+	repo := repositories.NewCartRepository(db)
+	cartItem, err := repo.GetCartItem(ctx, cart.ID, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cartItem)
+	assert.Equal(t, uint(1), cartItem.Quantity)
+}
@@ -10,10 +10,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// JWTMiddleware authenticates with the bearer token of the Authorization
+// header or, for browsers, the access token cookie. Cookies are sent by
+// the browser on its own, so cookie-authenticated requests that change
+// state must also carry the CSRF token.
 func JWTMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if !strings.HasPrefix(authHeader, "Bearer ") {
+		if authenticatedByAPIKey(ctx) {
+			ctx.Next()
+			return
+		}
+
+		tokenString, fromCookie := accessToken(ctx)
+		if tokenString == "" {
 			ctx.JSON(
 				http.StatusUnauthorized,
 				gin.H{"error": errs.ErrAuthHeaderMissing.Error()},
@@ -22,8 +31,6 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
 		claims, err := jwt.ParseJWT(tokenString)
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -31,8 +38,32 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if fromCookie && !jwt.IsSafeMethod(ctx.Request.Method) {
+			if err := jwt.VerifyCSRF(ctx.Request, claims.SessionID); err != nil {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				ctx.Abort()
+				return
+			}
+		}
+
 		ctx.Set("claims", claims)
 		ctx.Set("tokenString", tokenString)
+		addLogAttrs(ctx, "user_id", claims.Subject)
 		ctx.Next()
 	}
 }
+
+// accessToken returns the token the request authenticates with and whether
+// it came from a cookie. The header wins when both are present.
+func accessToken(ctx *gin.Context) (string, bool) {
+	authHeader := ctx.GetHeader("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer "), false
+	}
+
+	if cookie, err := ctx.Cookie(jwt.AccessTokenCookieName); err == nil {
+		return cookie, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,112 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs request through handlers followed by a handler answering 200.
+func serve(
+	request *http.Request, handlers ...gin.HandlerFunc,
+) *httptest.ResponseRecorder {
+	router := gin.New()
+	handlers = append(
+		handlers, func(ctx *gin.Context) { ctx.Status(http.StatusOK) },
+	)
+	router.Handle(request.Method, request.URL.Path, handlers...)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func sessionToken(t *testing.T, sessionID string) string {
+	token, err := jwt.GenerateSessionJWT(
+		"1", 15, "user", nil, sessionID, false,
+	)
+	require.NoError(t, err)
+	return token
+}
+
+// cookieRequest authenticates with the access token cookie and sends csrf
+// as both the CSRF cookie and header when set.
+func cookieRequest(method, accessToken, csrf string) *http.Request {
+	r := httptest.NewRequest(method, "/api/cart", nil)
+	r.AddCookie(
+		&http.Cookie{Name: jwt.AccessTokenCookieName, Value: accessToken},
+	)
+	if csrf != "" {
+		r.AddCookie(&http.Cookie{Name: jwt.CSRFCookieName, Value: csrf})
+		r.Header.Set(jwt.CSRFHeaderName, csrf)
+	}
+	return r
+}
+
+func TestJWTMiddleware_CookieNeedsCSRF(t *testing.T) {
+	token := sessionToken(t, "session-1")
+
+	recorder := serve(
+		cookieRequest(http.MethodPost, token, ""), middlewares.JWTMiddleware(),
+	)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestJWTMiddleware_CookieWithCSRF(t *testing.T) {
+	token := sessionToken(t, "session-1")
+	csrf, err := jwt.NewCSRFToken("session-1")
+	require.NoError(t, err)
+
+	recorder := serve(
+		cookieRequest(http.MethodPost, token, csrf),
+		middlewares.JWTMiddleware(),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestJWTMiddleware_CSRFOfOtherSession(t *testing.T) {
+	token := sessionToken(t, "session-1")
+	csrf, err := jwt.NewCSRFToken("session-2")
+	require.NoError(t, err)
+
+	recorder := serve(
+		cookieRequest(http.MethodPost, token, csrf),
+		middlewares.JWTMiddleware(),
+	)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestJWTMiddleware_CookieSafeMethod(t *testing.T) {
+	token := sessionToken(t, "session-1")
+
+	recorder := serve(
+		cookieRequest(http.MethodGet, token, ""), middlewares.JWTMiddleware(),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestJWTMiddleware_BearerSkipsCSRF(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/cart", nil)
+	request.Header.Set("Authorization", "Bearer "+sessionToken(t, "session-1"))
+
+	recorder := serve(request, middlewares.JWTMiddleware())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestJWTMiddleware_Missing(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/cart", nil)
+
+	recorder := serve(request, middlewares.JWTMiddleware())
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
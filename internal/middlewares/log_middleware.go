@@ -1,34 +1,67 @@
 package middlewares
 
 import (
-	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
 	"time"
 
-	"github.com/DaniilKalts/market-rest-api/pkg/logger"
 	"github.com/gin-gonic/gin"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
 )
 
+// LoggerMiddleware writes one record per request with the request
+// context's logger, which carries the user and API key once the
+// authentication middlewares ran, at level ERROR for 5xx responses, WARN for requests
+// whose handlers reported errors and INFO otherwise.
 func LoggerMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		start := time.Now()
 		ctx.Next()
-		duration := time.Since(start)
-
-		message := fmt.Sprintf(
-			"Request Log:\n  %-8s: %s\n  %-8s: %s\n  %-8s: %d\n  %-8s: %s",
-			"Method", ctx.Request.Method,
-			"Path", ctx.Request.URL.Path,
-			"Status", ctx.Writer.Status(),
-			"Duration", duration.String(),
-		)
 
+		status := ctx.Writer.Status()
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", ctx.ClientIP(),
+			"bytes", ctx.Writer.Size(),
+		}
+
+		level := slog.LevelInfo
 		if len(ctx.Errors) > 0 {
-			for _, e := range ctx.Errors {
-				message += fmt.Sprintf("\n  %-8s: %s", "Error", e.Error())
-			}
-			logger.Error(message)
-		} else {
-			logger.Info(message)
+			level = slog.LevelWarn
+			attrs = append(attrs, "errors", ctx.Errors.Errors())
+		}
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
 		}
+
+		logger.FromContext(ctx.Request.Context()).Log(
+			ctx.Request.Context(), level, "request", attrs...,
+		)
+	}
+}
+
+// RecoveryMiddleware answers 500 to requests whose handler panicked and
+// logs the panic with its stack.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.FromContext(ctx.Request.Context()).Error(
+					"panic recovered",
+					"panic", recovered,
+					"stack", string(debug.Stack()),
+				)
+				ctx.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": http.StatusText(http.StatusInternalServerError)},
+				)
+			}
+		}()
+		ctx.Next()
 	}
 }
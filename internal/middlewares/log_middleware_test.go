@@ -0,0 +1,42 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+func TestLoggerMiddleware_UserLoggedOnce(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, logger.Setup(&out, logger.FormatJSON, "info"))
+	t.Cleanup(func() {
+		_ = logger.Setup(os.Stdout, logger.FormatText, "info")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+	request.Header.Set(
+		"Authorization", "Bearer "+sessionToken(t, "session-1"),
+	)
+
+	recorder := serve(
+		request,
+		middlewares.RequestIDMiddleware(),
+		middlewares.LoggerMiddleware(),
+		middlewares.JWTMiddleware(),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	line := strings.TrimSpace(out.String())
+	assert.Contains(t, line, `"msg":"request"`)
+	assert.Equal(t, 1, strings.Count(line, `"user_id"`))
+	assert.Equal(t, 1, strings.Count(line, `"request_id"`))
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/metrics"
+)
+
+// unmatchedRoute labels the requests that matched no route, so that
+// scanners probing random paths cannot create new series.
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware counts and times every request by its route template
+// and status.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveHTTPRequest(
+			ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start),
+		)
+	}
+}
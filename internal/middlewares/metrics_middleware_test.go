@@ -0,0 +1,52 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+	"github.com/DaniilKalts/market-rest-api/pkg/metrics"
+)
+
+func TestMetricsMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	metrics.Register()
+
+	router := gin.New()
+	router.Use(middlewares.MetricsMiddleware())
+	router.GET("/api/items/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{
+		"/api/items/1", "/api/items/2", "/wp-admin.php", "/.env",
+	} {
+		router.ServeHTTP(
+			httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, path, nil),
+		)
+	}
+
+	expected := `
+# HELP market_http_requests_total HTTP requests by method, route template and status.
+# TYPE market_http_requests_total counter
+market_http_requests_total{method="GET",route="/api/items/:id",status="200"} 2
+market_http_requests_total{method="GET",route="unmatched",status="404"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(
+		metrics.Registry, strings.NewReader(expected),
+		"market_http_requests_total",
+	))
+
+	count, err := testutil.GatherAndCount(
+		metrics.Registry, "market_http_request_duration_seconds",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// RequirePermission lets through tokens granting every one of permissions.
+// With requireMFA the session must also have passed two-factor
+// authentication.
+func RequirePermission(
+	requireMFA bool, permissions ...models.Permission,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claimsValue, exists := ctx.Get("claims")
+		if !exists {
+			ctx.JSON(
+				http.StatusUnauthorized,
+				gin.H{"error": errs.ErrClaimsNotFound.Error()},
+			)
+			ctx.Abort()
+			return
+		}
+
+		claims, ok := claimsValue.(*jwt.Claims)
+		if !ok {
+			ctx.JSON(
+				http.StatusUnauthorized,
+				gin.H{"error": errs.ErrInvalidClaims.Error()},
+			)
+			ctx.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(string(permission)) {
+				ctx.JSON(
+					http.StatusForbidden,
+					gin.H{
+						"error": errs.ErrPermissionDenied.Error() + ": " +
+							string(permission) + " required",
+					},
+				)
+				ctx.Abort()
+				return
+			}
+		}
+
+		if requireMFA && !claims.MFA {
+			ctx.JSON(
+				http.StatusForbidden,
+				gin.H{"error": errs.ErrMFARequired.Error()},
+			)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
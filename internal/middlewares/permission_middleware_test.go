@@ -0,0 +1,146 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// withClaims sets claims the way JWTMiddleware does.
+func withClaims(claims *jwt.Claims) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("claims", claims)
+		ctx.Next()
+	}
+}
+
+func adminRequest() *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/api/admin/items", nil)
+}
+
+func TestRequirePermission_NoClaims(t *testing.T) {
+	recorder := serve(
+		adminRequest(),
+		middlewares.RequirePermission(false, models.PermissionItemsWrite),
+	)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRequirePermission_InvalidClaims(t *testing.T) {
+	recorder := serve(
+		adminRequest(),
+		func(ctx *gin.Context) { ctx.Set("claims", "admin") },
+		middlewares.RequirePermission(false, models.PermissionItemsWrite),
+	)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRequirePermission_Granted(t *testing.T) {
+	claims := &jwt.Claims{
+		Permissions: []string{
+			string(models.PermissionItemsWrite),
+			string(models.PermissionUsersRead),
+		},
+	}
+
+	recorder := serve(
+		adminRequest(), withClaims(claims),
+		middlewares.RequirePermission(
+			false, models.PermissionItemsWrite, models.PermissionUsersRead,
+		),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestRequirePermission_MissingOne(t *testing.T) {
+	claims := &jwt.Claims{
+		Permissions: []string{string(models.PermissionItemsWrite)},
+	}
+
+	recorder := serve(
+		adminRequest(), withClaims(claims),
+		middlewares.RequirePermission(
+			false, models.PermissionItemsWrite, models.PermissionUsersRead,
+		),
+	)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "users:read required")
+}
+
+func TestRequirePermission_MFARequired(t *testing.T) {
+	claims := &jwt.Claims{
+		Permissions: []string{string(models.PermissionItemsWrite)},
+	}
+
+	recorder := serve(
+		adminRequest(), withClaims(claims),
+		middlewares.RequirePermission(true, models.PermissionItemsWrite),
+	)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), errs.ErrMFARequired.Error())
+
+	claims.MFA = true
+	recorder = serve(
+		adminRequest(), withClaims(claims),
+		middlewares.RequirePermission(true, models.PermissionItemsWrite),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// staticKeys authenticates every plaintext as key, or fails with err.
+type staticKeys struct {
+	key *models.APIKey
+	err error
+}
+
+func (s staticKeys) Authenticate(
+	context.Context, string,
+) (*models.APIKey, error) {
+	return s.key, s.err
+}
+
+func TestRequirePermission_APIKeyScopes(t *testing.T) {
+	key := &models.APIKey{Prefix: "mk_3f9a1c2e", CreatedBy: 1}
+	key.SetPermissions([]models.Permission{models.PermissionOrdersWrite})
+	keys := middlewares.APIKeyMiddleware(staticKeys{key: key})
+
+	request := adminRequest()
+	request.Header.Set(jwt.APIKeyHeaderName, "mk_3f9a1c2e_secret")
+	recorder := serve(
+		request, keys, middlewares.JWTMiddleware(),
+		middlewares.RequirePermission(true, models.PermissionOrdersWrite),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = adminRequest()
+	request.Header.Set(jwt.APIKeyHeaderName, "mk_3f9a1c2e_secret")
+	recorder = serve(
+		request, keys, middlewares.JWTMiddleware(),
+		middlewares.RequirePermission(true, models.PermissionItemsWrite),
+	)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestRequirePermission_InvalidAPIKey(t *testing.T) {
+	keys := middlewares.APIKeyMiddleware(
+		staticKeys{err: errs.ErrInvalidAPIKey},
+	)
+
+	request := adminRequest()
+	request.Header.Set(jwt.APIKeyHeaderName, "mk_bogus")
+	recorder := serve(
+		request, keys, middlewares.JWTMiddleware(),
+		middlewares.RequirePermission(false, models.PermissionOrdersWrite),
+	)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+// RateLimitKey is what a rate limit policy counts requests by.
+type RateLimitKey string
+
+// Requests that lack the identity a policy counts by fall back to the next
+// one: API key, then user, then client IP.
+const (
+	RateLimitByIP     RateLimitKey = "ip"
+	RateLimitByUser   RateLimitKey = "user"
+	RateLimitByAPIKey RateLimitKey = "api_key"
+)
+
+type RateLimitPolicy struct {
+	// Name keeps the counters of policies apart.
+	Name   string
+	Limit  int
+	Window time.Duration
+	By     RateLimitKey
+}
+
+// rateLimitSubject returns who the request is counted for under by.
+func rateLimitSubject(ctx *gin.Context, by RateLimitKey) string {
+	if by == RateLimitByAPIKey {
+		if key, exists := ctx.Get("apiKey"); exists {
+			if apiKey, ok := key.(*models.APIKey); ok {
+				return "api_key:" + apiKey.Prefix
+			}
+		}
+		by = RateLimitByUser
+	}
+
+	if by == RateLimitByUser {
+		if value, exists := ctx.Get("claims"); exists {
+			if claims, ok := value.(*jwt.Claims); ok && claims.Subject != "" {
+				return "user:" + claims.Subject
+			}
+		}
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// RateLimitMiddleware answers 429 once a caller exceeds the policy. Every
+// response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, and 429 responses Retry-After. Mount it after
+// the authentication middlewares for policies counting by user or API
+// key. Requests are let through when Redis cannot be reached.
+func RateLimitMiddleware(
+	limiter redis.RateLimiter, policy RateLimitPolicy,
+) gin.HandlerFunc {
+	policyHeader := strconv.Itoa(policy.Limit) + ";w=" +
+		seconds(policy.Window)
+
+	return func(ctx *gin.Context) {
+		result, err := limiter.Allow(
+			ctx.Request.Context(),
+			policy.Name+":"+rateLimitSubject(ctx, policy.By),
+			policy.Limit, policy.Window,
+		)
+		if err != nil {
+			logger.FromContext(ctx.Request.Context()).Warn(
+				"Rate limiting skipped", "policy", policy.Name, "error", err,
+			)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Policy", policyHeader)
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", seconds(result.ResetAfter))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", seconds(result.ResetAfter))
+			ctx.JSON(
+				http.StatusTooManyRequests,
+				gin.H{"error": errs.ErrRateLimited.Error()},
+			)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
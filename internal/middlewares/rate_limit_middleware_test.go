@@ -0,0 +1,134 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+func newRateLimiter(t *testing.T) (*miniredis.Miniredis, redis.RateLimiter) {
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return server, redis.NewRateLimiter(client)
+}
+
+// limitedRouter serves GET /api/items behind a policy of two requests a
+// minute counted by by, trusting no proxy.
+func limitedRouter(
+	t *testing.T, limiter redis.RateLimiter, by middlewares.RateLimitKey,
+	before ...gin.HandlerFunc,
+) *gin.Engine {
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(nil))
+
+	handlers := append(
+		before,
+		middlewares.RateLimitMiddleware(
+			limiter, middlewares.RateLimitPolicy{
+				Name: "public", Limit: 2, Window: time.Minute, By: by,
+			},
+		),
+		func(ctx *gin.Context) { ctx.Status(http.StatusOK) },
+	)
+	router.GET("/api/items", handlers...)
+	return router
+}
+
+func get(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	request.RemoteAddr = remoteAddr
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	server, limiter := newRateLimiter(t)
+	server.SetTime(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	router := limitedRouter(t, limiter, middlewares.RateLimitByIP)
+
+	for _, remaining := range []string{"1", "0"} {
+		recorder := get(router, "10.0.0.1:40000")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "2;w=60", recorder.Header().Get("RateLimit-Policy"))
+		assert.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		assert.Equal(
+			t, remaining, recorder.Header().Get("RateLimit-Remaining"),
+		)
+		assert.Equal(t, "60", recorder.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, recorder.Header().Get("Retry-After"))
+	}
+
+	recorder := get(router, "10.0.0.1:40000")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_IgnoresForwardedFor(t *testing.T) {
+	_, limiter := newRateLimiter(t)
+	router := limitedRouter(t, limiter, middlewares.RateLimitByIP)
+
+	for i, forwarded := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		request.RemoteAddr = "10.0.0.1:40000"
+		request.Header.Set("X-Forwarded-For", forwarded)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if i < 2 {
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		}
+	}
+}
+
+func TestRateLimitMiddleware_ByUser(t *testing.T) {
+	_, limiter := newRateLimiter(t)
+	var subject string
+	router := limitedRouter(
+		t, limiter, middlewares.RateLimitByUser,
+		func(ctx *gin.Context) {
+			claims := &jwt.Claims{}
+			claims.Subject = subject
+			ctx.Set("claims", claims)
+		},
+	)
+
+	// One user moving between addresses shares one budget, and another
+	// user on the same address has one of their own.
+	subject = "1"
+	assert.Equal(t, http.StatusOK, get(router, "10.0.0.1:40000").Code)
+	assert.Equal(t, http.StatusOK, get(router, "10.0.0.2:40000").Code)
+	assert.Equal(
+		t, http.StatusTooManyRequests, get(router, "10.0.0.3:40000").Code,
+	)
+
+	subject = "2"
+	assert.Equal(t, http.StatusOK, get(router, "10.0.0.1:40000").Code)
+}
+
+func TestRateLimitMiddleware_RedisDown(t *testing.T) {
+	server, limiter := newRateLimiter(t)
+	router := limitedRouter(t, limiter, middlewares.RateLimitByIP)
+	server.Close()
+
+	recorder := get(router, "10.0.0.1:40000")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+}
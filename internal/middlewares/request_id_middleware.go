@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+const RequestIDHeaderName = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs accepted from clients and proxies.
+const maxRequestIDLength = 128
+
+// validRequestID accepts the IDs of common proxies and tracing tools while
+// keeping log lines and headers free of anything a client could inject.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware keeps the X-Request-ID of the request, or generates
+// one, and echoes it in the response header and in the JSON body of error
+// responses. The request context gets a logger carrying the ID and the
+// route, which the authentication middlewares extend with the user. Mount
+// it before every other middleware.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(RequestIDHeaderName)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx.Set("requestID", requestID)
+		ctx.Header(RequestIDHeaderName, requestID)
+		ctx.Writer = &requestIDWriter{
+			ResponseWriter: ctx.Writer, requestID: requestID,
+		}
+
+		addLogAttrs(ctx, "request_id", requestID, "route", ctx.FullPath())
+		ctx.Next()
+	}
+}
+
+// addLogAttrs adds args to the logger of the request context.
+func addLogAttrs(ctx *gin.Context, args ...any) {
+	reqCtx := ctx.Request.Context()
+	ctx.Request = ctx.Request.WithContext(
+		logger.NewContext(reqCtx, logger.FromContext(reqCtx).With(args...)),
+	)
+}
+
+// requestIDWriter adds a request_id field to the JSON objects written as
+// the body of error responses.
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+	done      bool
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	if w.done || w.Status() < 400 || len(b) == 0 || b[0] != '{' ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(b)
+	}
+	w.done = true
+
+	id, _ := json.Marshal(w.requestID)
+	body := append([]byte(`{"request_id":`), id...)
+	if rest := bytes.TrimSpace(b[1:]); len(rest) > 0 && rest[0] != '}' {
+		body = append(body, ',')
+	}
+	body = append(body, b[1:]...)
+
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *requestIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,106 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
+)
+
+func requestWithID(id string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	if id != "" {
+		r.Header.Set(middlewares.RequestIDHeaderName, id)
+	}
+	return r
+}
+
+func respond(status int, body any) gin.HandlerFunc {
+	return func(ctx *gin.Context) { ctx.AbortWithStatusJSON(status, body) }
+}
+
+func TestRequestIDMiddleware_KeepsValidID(t *testing.T) {
+	recorder := serve(
+		requestWithID("trace-1.a:b_c"), middlewares.RequestIDMiddleware(),
+	)
+	assert.Equal(
+		t, "trace-1.a:b_c",
+		recorder.Header().Get(middlewares.RequestIDHeaderName),
+	)
+}
+
+func TestRequestIDMiddleware_ReplacesInvalidID(t *testing.T) {
+	for _, id := range []string{
+		"", "id with spaces", "id\r\nX-Injected: 1", strings.Repeat("a", 129),
+	} {
+		recorder := serve(requestWithID(id), middlewares.RequestIDMiddleware())
+
+		got := recorder.Header().Get(middlewares.RequestIDHeaderName)
+		assert.Len(t, got, 32, "id %q", id)
+		assert.NotEqual(t, id, got)
+	}
+}
+
+func TestRequestIDMiddleware_ErrorBody(t *testing.T) {
+	recorder := serve(
+		requestWithID("req-1"), middlewares.RequestIDMiddleware(),
+		respond(http.StatusNotFound, gin.H{"error": "item not found"}),
+	)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(
+		t, `{"request_id":"req-1","error":"item not found"}`,
+		recorder.Body.String(),
+	)
+}
+
+func TestRequestIDMiddleware_EmptyErrorBody(t *testing.T) {
+	recorder := serve(
+		requestWithID("req-1"), middlewares.RequestIDMiddleware(),
+		respond(http.StatusBadRequest, gin.H{}),
+	)
+	assert.JSONEq(t, `{"request_id":"req-1"}`, recorder.Body.String())
+}
+
+func TestRequestIDMiddleware_LeavesOtherBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		body    string
+	}{
+		{
+			name:    "success",
+			handler: respond(http.StatusOK, gin.H{"name": "Phone"}),
+			body:    `{"name":"Phone"}`,
+		},
+		{
+			name: "array",
+			handler: respond(
+				http.StatusUnprocessableEntity, []string{"invalid"},
+			),
+			body: `["invalid"]`,
+		},
+		{
+			name: "plain text",
+			handler: func(ctx *gin.Context) {
+				ctx.String(http.StatusInternalServerError, "{oops")
+				ctx.Abort()
+			},
+			body: `{oops`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(
+				requestWithID("req-1"), middlewares.RequestIDMiddleware(),
+				tt.handler,
+			)
+			assert.Equal(t, tt.body, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware puts a deadline on the request context. Repositories and
+// the token store run their queries with this context, so the work is
+// cancelled when the deadline passes or the client goes away.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 {
+			ctx.Next()
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
@@ -17,6 +17,11 @@ var _ = jwt.Claims{}
 
 func TokenStoreMiddleware(tokenStore redis.TokenStore) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if authenticatedByAPIKey(ctx) {
+			ctx.Next()
+			return
+		}
+
 		claimsVal, exists := ctx.Get("claims")
 		if !exists {
 			ctx.JSON(
@@ -67,7 +72,9 @@ func TokenStoreMiddleware(tokenStore redis.TokenStore) gin.HandlerFunc {
 			return
 		}
 
-		valid, err := tokenStore.ValidateJWToken(userID, tokenString)
+		valid, err := tokenStore.ValidateJWToken(
+			ctx.Request.Context(), userID, tokenString,
+		)
 		if err != nil || !valid {
 			ctx.JSON(
 				http.StatusUnauthorized,
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/tracing"
+)
+
+// TracingMiddleware starts the server span of the request, continuing the
+// trace of the W3C traceparent header when there is one, and adds its
+// trace ID to the request logger. Mount it after RequestIDMiddleware.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := otel.GetTextMapPropagator().Extract(
+			ctx.Request.Context(),
+			propagation.HeaderCarrier(ctx.Request.Header),
+		)
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		reqCtx, span := tracing.Start(
+			reqCtx, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", ctx.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", ctx.Request.URL.Path),
+				attribute.String("client.address", ctx.ClientIP()),
+				attribute.String("request.id", ctx.GetString("requestID")),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			addLogAttrs(ctx, "trace_id", spanContext.TraceID().String())
+		}
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if value, exists := ctx.Get("claims"); exists {
+			if claims, ok := value.(*jwt.Claims); ok {
+				span.SetAttributes(attribute.String("user.id", claims.Subject))
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Dir is where `migrate create` writes new files, relative to the
+// repository root. They are compiled into the binary from there.
+const Dir = "internal/migrations/sql"
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Embedded returns the migrations compiled into the binary.
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads NNNN_name.up.sql / NNNN_name.down.sql pairs from the root of
+// fsys and returns them ordered by version. Every version needs both files.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf(
+				"migration %d has two names: %s and %s",
+				version, m.Name, match[2],
+			)
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf(
+				"migration %04d_%s needs both an up and a down file",
+				m.Version, m.Name,
+			)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(
+		migrations, func(i, j int) bool {
+			return migrations[i].Version < migrations[j].Version
+		},
+	)
+
+	return migrations, nil
+}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes an empty up/down pair to dir, numbered one past the highest
+// version already there, and returns the paths of the new files.
+func Create(dir, name string) (string, string, error) {
+	name = nonWord.ReplaceAllString(strings.ToLower(name), "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return "", "", errors.New("migration name needs letters or digits")
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	version := 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down := base+".up.sql", base+".down.sql"
+	templates := map[string]string{
+		up:   "-- Write the schema change here.\n",
+		down: "-- Revert everything " + filepath.Base(up) + " does.\n",
+	}
+	for path, content := range templates {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return "", "", err
+		}
+	}
+
+	return up, down, nil
+}
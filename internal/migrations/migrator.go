@@ -0,0 +1,208 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockID keys the advisory lock that keeps two processes from applying the
+// same migration at once.
+const lockID = 7_240_519_316
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+func ensureTable(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Exec(
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint PRIMARY KEY,
+			name       varchar(255) NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`,
+	).Error
+}
+
+func loadApplied(
+	ctx context.Context, db *gorm.DB,
+) (map[int]appliedMigration, error) {
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var rows []appliedMigration
+	err := db.WithContext(ctx).Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every known migration with the time it was applied, nil for
+// pending ones. Versions recorded in the database but unknown to this build
+// are listed too, with an empty Up and Down.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := loadApplied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(
+			statuses, Status{
+				Migration: Migration{Version: row.Version, Name: row.Name},
+				AppliedAt: &appliedAt,
+			},
+		)
+	}
+	sort.Slice(
+		statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		},
+	)
+
+	return statuses, nil
+}
+
+// Pending returns the known migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := loadApplied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the ones it applied. It stops at the first
+// failure, leaving the failed migration unapplied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	for _, migration := range m.migrations {
+		ran, err := m.run(ctx, migration, true)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, migration)
+		}
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and
+// returns the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		ran, err := m.run(ctx, m.migrations[i], false)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, m.migrations[i])
+		}
+	}
+
+	return done, nil
+}
+
+// run applies or reverts one migration together with its schema_migrations
+// row. It reports false when another process got there first.
+func (m *Migrator) run(
+	ctx context.Context, migration Migration, up bool,
+) (bool, error) {
+	var ran bool
+	err := m.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Exec(
+				"SELECT pg_advisory_xact_lock(?)", lockID,
+			).Error; err != nil {
+				return err
+			}
+			applied, err := loadApplied(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := applied[migration.Version]; ok == up {
+				return nil
+			}
+
+			if !up {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				ran = true
+				return tx.Delete(
+					&appliedMigration{}, "version = ?", migration.Version,
+				).Error
+			}
+
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			ran = true
+			return tx.Create(
+				&appliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				},
+			).Error
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"migration %04d_%s: %w", migration.Version, migration.Name, err,
+		)
+	}
+
+	return ran, nil
+}
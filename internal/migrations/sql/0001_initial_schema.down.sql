@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS order_status_histories;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS items;
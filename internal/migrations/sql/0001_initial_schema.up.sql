@@ -0,0 +1,129 @@
+-- Baseline schema. Every statement is guarded with IF NOT EXISTS so that
+-- databases previously created by AutoMigrate adopt it without changes.
+
+CREATE TABLE IF NOT EXISTS items (
+    id          bigserial PRIMARY KEY,
+    name        varchar(100) NOT NULL,
+    description varchar(255),
+    price       bigint NOT NULL,
+    stock       bigint NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_name ON items (name);
+
+CREATE TABLE IF NOT EXISTS users (
+    id           bigserial PRIMARY KEY,
+    first_name   varchar(30) NOT NULL,
+    last_name    varchar(30) NOT NULL,
+    email        varchar(100) NOT NULL,
+    password     varchar(255) NOT NULL,
+    phone_number varchar(12) NOT NULL,
+    role         varchar(10) NOT NULL DEFAULT 'user',
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS carts (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_users_cart FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_id    bigint NOT NULL,
+    item_id    bigint NOT NULL,
+    quantity   bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (cart_id, item_id),
+    CONSTRAINT fk_carts_items FOREIGN KEY (cart_id)
+        REFERENCES carts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_cart_items_item FOREIGN KEY (item_id)
+        REFERENCES items (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    status       varchar(20) NOT NULL DEFAULT 'pending',
+    total        bigint NOT NULL,
+    paid_at      timestamptz,
+    shipped_at   timestamptz,
+    delivered_at timestamptz,
+    cancelled_at timestamptz,
+    refunded_at  timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id         bigserial PRIMARY KEY,
+    order_id   bigint NOT NULL,
+    item_id    bigint,
+    name       varchar(100) NOT NULL,
+    price      bigint NOT NULL,
+    quantity   bigint NOT NULL,
+    created_at timestamptz,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id)
+        REFERENCES orders (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_order_items_item FOREIGN KEY (item_id)
+        REFERENCES items (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_item_id ON order_items (item_id);
+
+CREATE TABLE IF NOT EXISTS order_status_histories (
+    id          bigserial PRIMARY KEY,
+    order_id    bigint NOT NULL,
+    from_status varchar(20),
+    to_status   varchar(20) NOT NULL,
+    changed_by  bigint,
+    note        varchar(255),
+    created_at  timestamptz,
+    CONSTRAINT fk_orders_history FOREIGN KEY (order_id)
+        REFERENCES orders (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_order_status_histories_order_id
+    ON order_status_histories (order_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id             bigserial PRIMARY KEY,
+    order_id       bigint NOT NULL,
+    provider       varchar(30) NOT NULL,
+    intent_id      varchar(100) NOT NULL,
+    amount         bigint NOT NULL,
+    currency       varchar(3) NOT NULL,
+    status         varchar(20) NOT NULL DEFAULT 'pending',
+    failure_reason varchar(255),
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    CONSTRAINT fk_payments_order FOREIGN KEY (order_id)
+        REFERENCES orders (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_intent_id
+    ON payments (intent_id);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    cart_id    bigint NOT NULL,
+    item_id    bigint NOT NULL,
+    quantity   bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (cart_id, item_id),
+    CONSTRAINT fk_stock_reservations_cart FOREIGN KEY (cart_id)
+        REFERENCES carts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_stock_reservations_item FOREIGN KEY (item_id)
+        REFERENCES items (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_item_id
+    ON stock_reservations (item_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at
+    ON stock_reservations (expires_at);
@@ -0,0 +1,28 @@
+-- Full-text and typo tolerant item search. pg_trgm needs privileges the
+-- database user may lack; without it the repository simply skips trigram
+-- matching, so a failure here is reported as a notice only.
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_items_search_vector
+    ON items USING GIN (search_vector);
+
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_trgm unavailable: %', SQLERRM;
+END
+$$;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS idx_items_name_trgm
+            ON items USING GIN (name gin_trgm_ops);
+    END IF;
+END
+$$;
@@ -0,0 +1,21 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+-- Accounts created before verification existed are trusted as they are.
+UPDATE users SET email_verified = true, email_verified_at = now();
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    purpose    varchar(30) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_user_tokens_user FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash
+    ON user_tokens (token_hash);
@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS mfa_enabled boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS mfa_secret varchar(64);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_mfa_recovery_codes_user FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id
+    ON mfa_recovery_codes (user_id);
@@ -0,0 +1,8 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+
+-- Only the two original roles fit the old column.
+UPDATE users SET role = 'user' WHERE role NOT IN ('admin', 'user');
+ALTER TABLE users ALTER COLUMN role TYPE varchar(10);
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id          bigserial PRIMARY KEY,
+    name        varchar(50) NOT NULL,
+    description varchar(255) NOT NULL DEFAULT '',
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id    bigint NOT NULL,
+    permission varchar(50) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    CONSTRAINT fk_roles_permissions FOREIGN KEY (role_id)
+        REFERENCES roles (id) ON DELETE CASCADE
+);
+
+-- admin is granted every permission by the application, so it needs no
+-- rows in role_permissions.
+INSERT INTO roles (name, description, created_at, updated_at) VALUES
+    ('admin', 'Full access', now(), now()),
+    ('user', 'Customer account', now(), now()),
+    ('catalog-manager', 'Manages the item catalog', now(), now()),
+    ('support', 'Looks up users and signs them out', now(), now()),
+    ('order-fulfilment', 'Moves orders through fulfilment and refunds them',
+        now(), now())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, grants.permission
+FROM (VALUES
+    ('catalog-manager', 'items:write'),
+    ('support', 'users:read'),
+    ('support', 'sessions:revoke'),
+    ('order-fulfilment', 'orders:write'),
+    ('order-fulfilment', 'orders:refund')
+) AS grants (role, permission)
+JOIN roles ON roles.name = grants.role
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ALTER COLUMN role TYPE varchar(50);
+ALTER TABLE users
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (role)
+        REFERENCES roles (name) ON UPDATE CASCADE;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           bigserial PRIMARY KEY,
+    name         varchar(100) NOT NULL,
+    prefix       varchar(16) NOT NULL,
+    key_hash     varchar(64) NOT NULL,
+    created_by   bigint NOT NULL,
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz,
+    CONSTRAINT fk_api_keys_created_by FOREIGN KEY (created_by)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TABLE IF NOT EXISTS api_key_scopes (
+    api_key_id bigint NOT NULL,
+    permission varchar(50) NOT NULL,
+    PRIMARY KEY (api_key_id, permission),
+    CONSTRAINT fk_api_keys_scopes FOREIGN KEY (api_key_id)
+        REFERENCES api_keys (id) ON DELETE CASCADE
+);
@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	models "github.com/DaniilKalts/market-rest-api/internal/models"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // CartRepository is an autogenerated mock type for the CartRepository type
@@ -12,9 +16,9 @@ type CartRepository struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: cartID, itemID
-func (_m *CartRepository) Add(cartID int, itemID int) (*models.CartItem, error) {
-	ret := _m.Called(cartID, itemID)
+// Add provides a mock function with given fields: ctx, cartID, itemID, hold
+func (_m *CartRepository) Add(ctx context.Context, cartID int, itemID int, hold time.Duration) (*models.CartItem, error) {
+	ret := _m.Called(ctx, cartID, itemID, hold)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Add")
@@ -22,19 +26,19 @@ func (_m *CartRepository) Add(cartID int, itemID int) (*models.CartItem, error)
 
 	var r0 *models.CartItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*models.CartItem, error)); ok {
-		return rf(cartID, itemID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, time.Duration) (*models.CartItem, error)); ok {
+		return rf(ctx, cartID, itemID, hold)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *models.CartItem); ok {
-		r0 = rf(cartID, itemID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, time.Duration) *models.CartItem); ok {
+		r0 = rf(ctx, cartID, itemID, hold)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.CartItem)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(cartID, itemID)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, time.Duration) error); ok {
+		r1 = rf(ctx, cartID, itemID, hold)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,17 +46,17 @@ func (_m *CartRepository) Add(cartID int, itemID int) (*models.CartItem, error)
 	return r0, r1
 }
 
-// Clear provides a mock function with given fields: cartID
-func (_m *CartRepository) Clear(cartID int) error {
-	ret := _m.Called(cartID)
+// Clear provides a mock function with given fields: ctx, cartID
+func (_m *CartRepository) Clear(ctx context.Context, cartID int) error {
+	ret := _m.Called(ctx, cartID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Clear")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(cartID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, cartID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,17 +64,45 @@ func (_m *CartRepository) Clear(cartID int) error {
 	return r0
 }
 
-// Delete provides a mock function with given fields: cartID, itemID
-func (_m *CartRepository) Delete(cartID int, itemID int) error {
-	ret := _m.Called(cartID, itemID)
+// CountWithItems provides a mock function with given fields: ctx
+func (_m *CartRepository) CountWithItems(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountWithItems")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, cartID, itemID
+func (_m *CartRepository) Delete(ctx context.Context, cartID int, itemID int) error {
+	ret := _m.Called(ctx, cartID, itemID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, int) error); ok {
-		r0 = rf(cartID, itemID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, cartID, itemID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -78,9 +110,9 @@ func (_m *CartRepository) Delete(cartID int, itemID int) error {
 	return r0
 }
 
-// GetByUserID provides a mock function with given fields: userID
-func (_m *CartRepository) GetByUserID(userID int) (*models.Cart, error) {
-	ret := _m.Called(userID)
+// GetByUserID provides a mock function with given fields: ctx, userID
+func (_m *CartRepository) GetByUserID(ctx context.Context, userID int) (*models.Cart, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByUserID")
@@ -88,19 +120,19 @@ func (_m *CartRepository) GetByUserID(userID int) (*models.Cart, error) {
 
 	var r0 *models.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*models.Cart, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Cart, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int) *models.Cart); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Cart); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Cart)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,9 +140,9 @@ func (_m *CartRepository) GetByUserID(userID int) (*models.Cart, error) {
 	return r0, r1
 }
 
-// GetCartItem provides a mock function with given fields: cartID, itemID
-func (_m *CartRepository) GetCartItem(cartID int, itemID int) (*models.CartItem, error) {
-	ret := _m.Called(cartID, itemID)
+// GetCartItem provides a mock function with given fields: ctx, cartID, itemID
+func (_m *CartRepository) GetCartItem(ctx context.Context, cartID int, itemID int) (*models.CartItem, error) {
+	ret := _m.Called(ctx, cartID, itemID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCartItem")
@@ -118,19 +150,19 @@ func (_m *CartRepository) GetCartItem(cartID int, itemID int) (*models.CartItem,
 
 	var r0 *models.CartItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*models.CartItem, error)); ok {
-		return rf(cartID, itemID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*models.CartItem, error)); ok {
+		return rf(ctx, cartID, itemID)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *models.CartItem); ok {
-		r0 = rf(cartID, itemID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *models.CartItem); ok {
+		r0 = rf(ctx, cartID, itemID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.CartItem)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(cartID, itemID)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, cartID, itemID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,9 +170,9 @@ func (_m *CartRepository) GetCartItem(cartID int, itemID int) (*models.CartItem,
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: cartID, itemID, quantity
-func (_m *CartRepository) Update(cartID int, itemID int, quantity uint) (*models.CartItem, error) {
-	ret := _m.Called(cartID, itemID, quantity)
+// Update provides a mock function with given fields: ctx, cartID, itemID, quantity, hold
+func (_m *CartRepository) Update(ctx context.Context, cartID int, itemID int, quantity uint, hold time.Duration) (*models.CartItem, error) {
+	ret := _m.Called(ctx, cartID, itemID, quantity, hold)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
@@ -148,19 +180,19 @@ func (_m *CartRepository) Update(cartID int, itemID int, quantity uint) (*models
 
 	var r0 *models.CartItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int, uint) (*models.CartItem, error)); ok {
-		return rf(cartID, itemID, quantity)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, uint, time.Duration) (*models.CartItem, error)); ok {
+		return rf(ctx, cartID, itemID, quantity, hold)
 	}
-	if rf, ok := ret.Get(0).(func(int, int, uint) *models.CartItem); ok {
-		r0 = rf(cartID, itemID, quantity)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, uint, time.Duration) *models.CartItem); ok {
+		r0 = rf(ctx, cartID, itemID, quantity, hold)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.CartItem)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int, uint) error); ok {
-		r1 = rf(cartID, itemID, quantity)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, uint, time.Duration) error); ok {
+		r1 = rf(ctx, cartID, itemID, quantity, hold)
 	} else {
 		r1 = ret.Error(1)
 	}
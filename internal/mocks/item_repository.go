@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	models "github.com/DaniilKalts/market-rest-api/internal/models"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,17 +14,45 @@ type ItemRepository struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: item
-func (_m *ItemRepository) Create(item *models.Item) error {
-	ret := _m.Called(item)
+// CountOutOfStock provides a mock function with given fields: ctx
+func (_m *ItemRepository) CountOutOfStock(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountOutOfStock")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, item
+func (_m *ItemRepository) Create(ctx context.Context, item *models.Item) error {
+	ret := _m.Called(ctx, item)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Item) error); ok {
-		r0 = rf(item)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Item) error); ok {
+		r0 = rf(ctx, item)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,17 +60,17 @@ func (_m *ItemRepository) Create(item *models.Item) error {
 	return r0
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *ItemRepository) Delete(id int) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *ItemRepository) Delete(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -48,39 +78,46 @@ func (_m *ItemRepository) Delete(id int) error {
 	return r0
 }
 
-// GetAll provides a mock function with no fields
-func (_m *ItemRepository) GetAll() ([]models.Item, error) {
-	ret := _m.Called()
+// GetAll provides a mock function with given fields: ctx, opts
+func (_m *ItemRepository) GetAll(ctx context.Context, opts *models.ItemQueryOptions) ([]models.Item, int64, error) {
+	ret := _m.Called(ctx, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
 	}
 
 	var r0 []models.Item
-	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.Item, error)); ok {
-		return rf()
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ItemQueryOptions) ([]models.Item, int64, error)); ok {
+		return rf(ctx, opts)
 	}
-	if rf, ok := ret.Get(0).(func() []models.Item); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ItemQueryOptions) []models.Item); ok {
+		r0 = rf(ctx, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Item)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, *models.ItemQueryOptions) int64); ok {
+		r1 = rf(ctx, opts)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int64)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, *models.ItemQueryOptions) error); ok {
+		r2 = rf(ctx, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *ItemRepository) GetByID(id int) (*models.Item, error) {
-	ret := _m.Called(id)
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *ItemRepository) GetByID(ctx context.Context, id int) (*models.Item, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByID")
@@ -88,19 +125,49 @@ func (_m *ItemRepository) GetByID(id int) (*models.Item, error) {
 
 	var r0 *models.Item
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*models.Item, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Item, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int) *models.Item); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Item); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Item)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, opts
+func (_m *ItemRepository) Search(ctx context.Context, opts *models.ItemSearchOptions) ([]models.ItemSearchResult, error) {
+	ret := _m.Called(ctx, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []models.ItemSearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ItemSearchOptions) ([]models.ItemSearchResult, error)); ok {
+		return rf(ctx, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ItemSearchOptions) []models.ItemSearchResult); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ItemSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.ItemSearchOptions) error); ok {
+		r1 = rf(ctx, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,17 +175,17 @@ func (_m *ItemRepository) GetByID(id int) (*models.Item, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: item
-func (_m *ItemRepository) Update(item *models.Item) error {
-	ret := _m.Called(item)
+// Update provides a mock function with given fields: ctx, item
+func (_m *ItemRepository) Update(ctx context.Context, item *models.Item) error {
+	ret := _m.Called(ctx, item)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Item) error); ok {
-		r0 = rf(item)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Item) error); ok {
+		r0 = rf(ctx, item)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,82 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MFARecoveryCodeRepository is an autogenerated mock type for the MFARecoveryCodeRepository type
+type MFARecoveryCodeRepository struct {
+	mock.Mock
+}
+
+// Consume provides a mock function with given fields: ctx, userID, codeHash
+func (_m *MFARecoveryCodeRepository) Consume(ctx context.Context, userID int, codeHash string) error {
+	ret := _m.Called(ctx, userID, codeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Consume")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, codeHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUserID provides a mock function with given fields: ctx, userID
+func (_m *MFARecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteByUserID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Replace provides a mock function with given fields: ctx, userID, codeHashes
+func (_m *MFARecoveryCodeRepository) Replace(ctx context.Context, userID int, codeHashes []string) error {
+	ret := _m.Called(ctx, userID, codeHashes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replace")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) error); ok {
+		r0 = rf(ctx, userID, codeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMFARecoveryCodeRepository creates a new instance of MFARecoveryCodeRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMFARecoveryCodeRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MFARecoveryCodeRepository {
+	mock := &MFARecoveryCodeRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
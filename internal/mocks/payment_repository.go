@@ -0,0 +1,125 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	models "github.com/DaniilKalts/market-rest-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PaymentRepository is an autogenerated mock type for the PaymentRepository type
+type PaymentRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, payment
+func (_m *PaymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	ret := _m.Called(ctx, payment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Payment) error); ok {
+		r0 = rf(ctx, payment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByIntentIDForUpdate provides a mock function with given fields: ctx, intentID
+func (_m *PaymentRepository) GetByIntentIDForUpdate(ctx context.Context, intentID string) (*models.Payment, error) {
+	ret := _m.Called(ctx, intentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByIntentIDForUpdate")
+	}
+
+	var r0 *models.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Payment, error)); ok {
+		return rf(ctx, intentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Payment); ok {
+		r0 = rf(ctx, intentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, intentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSucceededByOrderID provides a mock function with given fields: ctx, orderID
+func (_m *PaymentRepository) GetSucceededByOrderID(ctx context.Context, orderID int) (*models.Payment, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSucceededByOrderID")
+	}
+
+	var r0 *models.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Payment, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Payment); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, payment
+func (_m *PaymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	ret := _m.Called(ctx, payment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Payment) error); ok {
+		r0 = rf(ctx, payment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewPaymentRepository creates a new instance of PaymentRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPaymentRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PaymentRepository {
+	mock := &PaymentRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,35 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	audit "github.com/DaniilKalts/market-rest-api/pkg/audit"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Recorder is an autogenerated mock type for the Recorder type
+type Recorder struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: ctx, event
+func (_m *Recorder) Record(ctx context.Context, event audit.Event) {
+	_m.Called(ctx, event)
+}
+
+// NewRecorder creates a new instance of Recorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRecorder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Recorder {
+	mock := &Recorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -2,24 +2,47 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	redis "github.com/DaniilKalts/market-rest-api/pkg/redis"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // TokenStore is an autogenerated mock type for the TokenStore type
 type TokenStore struct {
 	mock.Mock
 }
 
-// DeleteJWToken provides a mock function with given fields: userID, token
-func (_m *TokenStore) DeleteJWToken(userID int, token string) error {
-	ret := _m.Called(userID, token)
+// DeleteAllSessions provides a mock function with given fields: ctx, userID
+func (_m *TokenStore) DeleteAllSessions(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAllSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteJWToken provides a mock function with given fields: ctx, userID, token
+func (_m *TokenStore) DeleteJWToken(ctx context.Context, userID int, token string) error {
+	ret := _m.Called(ctx, userID, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteJWToken")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, string) error); ok {
-		r0 = rf(userID, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -27,17 +50,17 @@ func (_m *TokenStore) DeleteJWToken(userID int, token string) error {
 	return r0
 }
 
-// DeleteJWTokens provides a mock function with given fields: userID, accessToken, refreshToken
-func (_m *TokenStore) DeleteJWTokens(userID int, accessToken string, refreshToken string) error {
-	ret := _m.Called(userID, accessToken, refreshToken)
+// DeleteJWTokens provides a mock function with given fields: ctx, userID, accessToken, refreshToken
+func (_m *TokenStore) DeleteJWTokens(ctx context.Context, userID int, accessToken string, refreshToken string) error {
+	ret := _m.Called(ctx, userID, accessToken, refreshToken)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteJWTokens")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, string, string) error); ok {
-		r0 = rf(userID, accessToken, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, userID, accessToken, refreshToken)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -45,17 +68,139 @@ func (_m *TokenStore) DeleteJWTokens(userID int, accessToken string, refreshToke
 	return r0
 }
 
-// SaveJWToken provides a mock function with given fields: userID, token
-func (_m *TokenStore) SaveJWToken(userID int, token string) error {
-	ret := _m.Called(userID, token)
+// DeleteSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *TokenStore) DeleteSession(ctx context.Context, userID int, sessionID string) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IsRotatedJWToken provides a mock function with given fields: ctx, userID, token
+func (_m *TokenStore) IsRotatedJWToken(ctx context.Context, userID int, token string) (bool, error) {
+	ret := _m.Called(ctx, userID, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsRotatedJWToken")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (bool, error)); ok {
+		return rf(ctx, userID, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) bool); ok {
+		r0 = rf(ctx, userID, token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSessions provides a mock function with given fields: ctx, userID
+func (_m *TokenStore) ListSessions(ctx context.Context, userID int) ([]redis.Session, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSessions")
+	}
+
+	var r0 []redis.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]redis.Session, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []redis.Session); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]redis.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *TokenStore) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateSession provides a mock function with given fields: ctx, session, refreshToken, newAccessToken, newRefreshToken
+func (_m *TokenStore) RotateSession(ctx context.Context, session *redis.Session, refreshToken string, newAccessToken string, newRefreshToken string) (bool, error) {
+	ret := _m.Called(ctx, session, refreshToken, newAccessToken, newRefreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateSession")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *redis.Session, string, string, string) (bool, error)); ok {
+		return rf(ctx, session, refreshToken, newAccessToken, newRefreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *redis.Session, string, string, string) bool); ok {
+		r0 = rf(ctx, session, refreshToken, newAccessToken, newRefreshToken)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *redis.Session, string, string, string) error); ok {
+		r1 = rf(ctx, session, refreshToken, newAccessToken, newRefreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveJWToken provides a mock function with given fields: ctx, userID, token
+func (_m *TokenStore) SaveJWToken(ctx context.Context, userID int, token string) error {
+	ret := _m.Called(ctx, userID, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveJWToken")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, string) error); ok {
-		r0 = rf(userID, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -63,17 +208,17 @@ func (_m *TokenStore) SaveJWToken(userID int, token string) error {
 	return r0
 }
 
-// SaveJWTokens provides a mock function with given fields: userID, accessToken, refreshToken
-func (_m *TokenStore) SaveJWTokens(userID int, accessToken string, refreshToken string) error {
-	ret := _m.Called(userID, accessToken, refreshToken)
+// SaveSession provides a mock function with given fields: ctx, session, accessToken, refreshToken
+func (_m *TokenStore) SaveSession(ctx context.Context, session *redis.Session, accessToken string, refreshToken string) error {
+	ret := _m.Called(ctx, session, accessToken, refreshToken)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveJWTokens")
+		panic("no return value specified for SaveSession")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, string, string) error); ok {
-		r0 = rf(userID, accessToken, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, *redis.Session, string, string) error); ok {
+		r0 = rf(ctx, session, accessToken, refreshToken)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -81,9 +226,9 @@ func (_m *TokenStore) SaveJWTokens(userID int, accessToken string, refreshToken
 	return r0
 }
 
-// ValidateJWToken provides a mock function with given fields: userID, token
-func (_m *TokenStore) ValidateJWToken(userID int, token string) (bool, error) {
-	ret := _m.Called(userID, token)
+// ValidateJWToken provides a mock function with given fields: ctx, userID, token
+func (_m *TokenStore) ValidateJWToken(ctx context.Context, userID int, token string) (bool, error) {
+	ret := _m.Called(ctx, userID, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateJWToken")
@@ -91,17 +236,17 @@ func (_m *TokenStore) ValidateJWToken(userID int, token string) (bool, error) {
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, string) (bool, error)); ok {
-		return rf(userID, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (bool, error)); ok {
+		return rf(ctx, userID, token)
 	}
-	if rf, ok := ret.Get(0).(func(int, string) bool); ok {
-		r0 = rf(userID, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) bool); ok {
+		r0 = rf(ctx, userID, token)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(int, string) error); ok {
-		r1 = rf(userID, token)
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, token)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,47 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	repositories "github.com/DaniilKalts/market-rest-api/internal/repositories"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UnitOfWork is an autogenerated mock type for the UnitOfWork type
+type UnitOfWork struct {
+	mock.Mock
+}
+
+// Do provides a mock function with given fields: ctx, fn
+func (_m *UnitOfWork) Do(ctx context.Context, fn func(repositories.Repositories) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Do")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(repositories.Repositories) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewUnitOfWork creates a new instance of UnitOfWork. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUnitOfWork(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UnitOfWork {
+	mock := &UnitOfWork{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	models "github.com/DaniilKalts/market-rest-api/internal/models"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,17 +14,17 @@ type UserRepository struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: user
-func (_m *UserRepository) Create(user *models.User) error {
-	ret := _m.Called(user)
+// Create provides a mock function with given fields: ctx, user
+func (_m *UserRepository) Create(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.User) error); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
+		r0 = rf(ctx, user)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,17 +32,17 @@ func (_m *UserRepository) Create(user *models.User) error {
 	return r0
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *UserRepository) Delete(id int) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Delete(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -48,9 +50,9 @@ func (_m *UserRepository) Delete(id int) error {
 	return r0
 }
 
-// GetAll provides a mock function with no fields
-func (_m *UserRepository) GetAll() ([]models.User, error) {
-	ret := _m.Called()
+// GetAll provides a mock function with given fields: ctx
+func (_m *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
@@ -58,19 +60,19 @@ func (_m *UserRepository) GetAll() ([]models.User, error) {
 
 	var r0 []models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.User, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.User, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []models.User); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []models.User); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -78,9 +80,9 @@ func (_m *UserRepository) GetAll() ([]models.User, error) {
 	return r0, r1
 }
 
-// GetByEmail provides a mock function with given fields: email
-func (_m *UserRepository) GetByEmail(email string) (*models.User, error) {
-	ret := _m.Called(email)
+// GetByEmail provides a mock function with given fields: ctx, email
+func (_m *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByEmail")
@@ -88,19 +90,19 @@ func (_m *UserRepository) GetByEmail(email string) (*models.User, error) {
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*models.User, error)); ok {
-		return rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(string) *models.User); ok {
-		r0 = rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(email)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,9 +110,9 @@ func (_m *UserRepository) GetByEmail(email string) (*models.User, error) {
 	return r0, r1
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *UserRepository) GetByID(id int) (*models.User, error) {
-	ret := _m.Called(id)
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByID")
@@ -118,19 +120,19 @@ func (_m *UserRepository) GetByID(id int) (*models.User, error) {
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*models.User, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.User, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int) *models.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,9 +140,27 @@ func (_m *UserRepository) GetByID(id int) (*models.User, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: user
-func (_m *UserRepository) Update(user *models.User) (*models.User, error) {
-	ret := _m.Called(user)
+// MarkEmailVerified provides a mock function with given fields: ctx, id
+func (_m *UserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkEmailVerified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, user
+func (_m *UserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
@@ -148,19 +168,19 @@ func (_m *UserRepository) Update(user *models.User) (*models.User, error) {
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*models.User) (*models.User, error)); ok {
-		return rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) (*models.User, error)); ok {
+		return rf(ctx, user)
 	}
-	if rf, ok := ret.Get(0).(func(*models.User) *models.User); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) *models.User); ok {
+		r0 = rf(ctx, user)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*models.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.User) error); ok {
+		r1 = rf(ctx, user)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -168,6 +188,42 @@ func (_m *UserRepository) Update(user *models.User) (*models.User, error) {
 	return r0, r1
 }
 
+// UpdateMFA provides a mock function with given fields: ctx, id, enabled, secret
+func (_m *UserRepository) UpdateMFA(ctx context.Context, id int, enabled bool, secret string) error {
+	ret := _m.Called(ctx, id, enabled, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, bool, string) error); ok {
+		r0 = rf(ctx, id, enabled, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: ctx, id, role
+func (_m *UserRepository) UpdateRole(ctx context.Context, id int, role models.Role) error {
+	ret := _m.Called(ctx, id, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.Role) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserRepository creates a new instance of UserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepository(t interface {
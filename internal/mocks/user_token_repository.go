@@ -0,0 +1,95 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	models "github.com/DaniilKalts/market-rest-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UserTokenRepository is an autogenerated mock type for the UserTokenRepository type
+type UserTokenRepository struct {
+	mock.Mock
+}
+
+// Consume provides a mock function with given fields: ctx, purpose, tokenHash
+func (_m *UserTokenRepository) Consume(ctx context.Context, purpose models.TokenPurpose, tokenHash string) (*models.UserToken, error) {
+	ret := _m.Called(ctx, purpose, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Consume")
+	}
+
+	var r0 *models.UserToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.TokenPurpose, string) (*models.UserToken, error)); ok {
+		return rf(ctx, purpose, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.TokenPurpose, string) *models.UserToken); ok {
+		r0 = rf(ctx, purpose, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.TokenPurpose, string) error); ok {
+		r1 = rf(ctx, purpose, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, token
+func (_m *UserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.UserToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByUserID provides a mock function with given fields: ctx, userID, purpose
+func (_m *UserTokenRepository) DeleteByUserID(ctx context.Context, userID int, purpose models.TokenPurpose) error {
+	ret := _m.Called(ctx, userID, purpose)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteByUserID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TokenPurpose) error); ok {
+		r0 = rf(ctx, userID, purpose)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewUserTokenRepository creates a new instance of UserTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserTokenRepository {
+	mock := &UserTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
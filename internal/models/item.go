@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Item struct {
 	ID          int       `json:"id" gorm:"primaryKey" example:"1"`
@@ -8,13 +11,113 @@ type Item struct {
 	Description string    `json:"description" gorm:"type:varchar(255)" example:"A premium quality T-shirt featuring an exclusive IITU logo design, crafted from soft, breathable fabric for both style and everyday comfort."`
 	Price       uint      `json:"price" gorm:"not null" binding:"required,gte=10,lte=100" example:"30"`
 	Stock       uint      `json:"stock" gorm:"not null" binding:"required" example:"20"`
+	Reserved    *uint     `json:"reserved,omitempty" gorm:"-" binding:"-" example:"3"`
+	Available   *uint     `json:"available,omitempty" gorm:"-" binding:"-" example:"17"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
 }
 
+// SetReserved records how many units are held in carts and derives how many
+// can still be added to a cart.
+func (i *Item) SetReserved(reserved uint) {
+	available := uint(0)
+	if i.Stock > reserved {
+		available = i.Stock - reserved
+	}
+	i.Reserved = &reserved
+	i.Available = &available
+}
+
 type UpdateItem struct {
 	Name        *string `json:"name" binding:"omitempty,min=5,max=40" example:"T-shirt"`
 	Description *string `json:"description" binding:"omitempty" example:"A premium quality T-shirt featuring an exclusive IITU logo design."`
 	Price       *uint   `json:"price" binding:"omitempty,gte=10,lte=100" example:"30"`
 	Stock       *uint   `json:"stock" binding:"omitempty" example:"20"`
 }
+
+type ItemSortField string
+
+const (
+	ItemSortName      ItemSortField = "name"
+	ItemSortPrice     ItemSortField = "price"
+	ItemSortStock     ItemSortField = "stock"
+	ItemSortCreatedAt ItemSortField = "created_at"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+const (
+	DefaultItemLimit = 20
+	MaxItemLimit     = 100
+)
+
+type ItemQueryOptions struct {
+	Page     int           `form:"page" binding:"omitempty,min=1" example:"1"`
+	Limit    int           `form:"limit" binding:"omitempty,min=1,max=100" example:"20"`
+	Cursor   string        `form:"cursor" example:"eyJ2IjoiMzAiLCJpZCI6MTJ9"`
+	MinPrice *uint         `form:"min_price" example:"10"`
+	MaxPrice *uint         `form:"max_price" example:"50"`
+	InStock  *bool         `form:"in_stock" example:"true"`
+	SortBy   ItemSortField `form:"sort_by" binding:"omitempty,oneof=name price stock created_at" example:"price"`
+	Order    SortOrder     `form:"order" binding:"omitempty,oneof=asc desc" example:"asc"`
+
+	// After is the decoded Cursor; it is filled in by the service layer.
+	After *ItemCursor `form:"-" json:"-"`
+}
+
+func (q *ItemQueryOptions) Validate() error {
+	if q.Cursor != "" && q.Page > 1 {
+		return errors.New("page and cursor cannot be used together")
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return errors.New("min_price cannot be greater than max_price")
+	}
+	return nil
+}
+
+// ItemCursor is the position after the last item of a page. It records the
+// sort it was issued for, since Value means nothing under another one.
+type ItemCursor struct {
+	SortBy ItemSortField `json:"s"`
+	Order  SortOrder     `json:"o"`
+	Value  string        `json:"v"`
+	ID     int           `json:"id"`
+}
+
+type ItemPageLinks struct {
+	Next string `json:"next,omitempty" example:"/api/items?limit=20&page=2"`
+	Prev string `json:"prev,omitempty" example:""`
+}
+
+type ItemPage struct {
+	Items      []Item        `json:"items"`
+	Total      int64         `json:"total" example:"42"`
+	Page       int           `json:"page,omitempty" example:"1"`
+	Limit      int           `json:"limit" example:"20"`
+	HasMore    bool          `json:"has_more" example:"true"`
+	NextCursor string        `json:"next_cursor,omitempty" example:"eyJ2IjoiMzAiLCJpZCI6MTJ9"`
+	Links      ItemPageLinks `json:"links"`
+}
+
+type ItemSearchOptions struct {
+	Query string `form:"q" binding:"required,max=100" example:"t-shirt"`
+	Page  int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100" example:"20"`
+}
+
+type ItemSearchResult struct {
+	Item    `gorm:"embedded"`
+	Rank    float64 `json:"rank" gorm:"column:rank" example:"0.42"`
+	Snippet string  `json:"snippet" gorm:"column:snippet" example:"A premium quality <mark>T-shirt</mark> featuring an exclusive IITU logo design"`
+}
+
+type ItemSearchPage struct {
+	Results []ItemSearchResult `json:"results"`
+	Page    int                `json:"page" example:"1"`
+	Limit   int                `json:"limit" example:"20"`
+}
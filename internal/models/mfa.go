@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// MFARecoveryCode is a single-use code that stands in for the
+// authenticator. Only the hash of the code is stored.
+type MFARecoveryCode struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"not null;index"`
+	User      *User      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID;references:ID;"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/Market%20REST%20API:martin@gmail.com?algorithm=SHA1&digits=6&issuer=Market+REST+API&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// MFACode carries a code from the authenticator or, where noted, a
+// recovery code.
+type MFACode struct {
+	Code string `json:"code" binding:"required" example:"492039"`
+}
+
+type MFALogin struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"q3T0c2Zr8m1H4yJx9bVnWl2oPe7uAaKdRiSgLfCzXsE.mJ0k3QpX8rZy1vN5cT7bW2aL4eG9hF6dS0uI3oP1qR8"`
+	Code     string `json:"code" binding:"required" example:"492039"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFailed    OrderStatus = "failed"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {
+		OrderStatusPaid, OrderStatusFailed, OrderStatusCancelled,
+	},
+	// The payment was declined; the customer may pay again or cancel.
+	OrderStatusFailed:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// IsPaymentStatus reports whether status is only reached through a
+// payment or refund, never set by hand.
+func IsPaymentStatus(status OrderStatus) bool {
+	switch status {
+	case OrderStatusPaid, OrderStatusFailed, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether an order may move from one status to
+// another. Cancelled and refunded orders are final.
+func CanTransition(from, to OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Order struct {
+	ID          int                  `json:"id" gorm:"primaryKey" example:"1"`
+	UserID      int                  `json:"user_id" gorm:"not null;index" example:"1"`
+	Status      OrderStatus          `json:"status" gorm:"type:varchar(20);not null;default:'pending'" example:"pending"`
+	Total       uint                 `json:"total" gorm:"not null" example:"60"`
+	Items       []OrderItem          `json:"items" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	History     []OrderStatusHistory `json:"history,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	PaidAt      *time.Time           `json:"paid_at,omitempty" example:"2025-02-25T12:40:00Z"`
+	ShippedAt   *time.Time           `json:"shipped_at,omitempty" example:"2025-02-26T09:00:00Z"`
+	DeliveredAt *time.Time           `json:"delivered_at,omitempty" example:"2025-02-28T15:30:00Z"`
+	CancelledAt *time.Time           `json:"cancelled_at,omitempty" example:"2025-02-25T13:00:00Z"`
+	RefundedAt  *time.Time           `json:"refunded_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	CreatedAt   time.Time            `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt   time.Time            `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
+}
+
+// SetStatus moves the order to status and stamps the matching timestamp.
+func (o *Order) SetStatus(status OrderStatus, at time.Time) {
+	o.Status = status
+	switch status {
+	case OrderStatusPaid:
+		o.PaidAt = &at
+	case OrderStatusShipped:
+		o.ShippedAt = &at
+	case OrderStatusDelivered:
+		o.DeliveredAt = &at
+	case OrderStatusCancelled:
+		o.CancelledAt = &at
+	case OrderStatusRefunded:
+		o.RefundedAt = &at
+	}
+}
+
+// OrderItem snapshots the name and price of an item at checkout time, so
+// later catalog changes or deletions do not rewrite past orders.
+type OrderItem struct {
+	ID        int       `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID   int       `json:"order_id" gorm:"not null;index" example:"1"`
+	ItemID    *int      `json:"item_id" gorm:"index" example:"1"`
+	Item      *Item     `json:"-" gorm:"foreignKey:ItemID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null" example:"T-shirt"`
+	Price     uint      `json:"price" gorm:"not null" example:"30"`
+	Quantity  uint      `json:"quantity" gorm:"not null" example:"2"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+}
+
+// OrderStatusHistory records every status change of an order. ChangedBy is
+// empty for changes made by the system, e.g. payment webhooks.
+type OrderStatusHistory struct {
+	ID         int         `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID    int         `json:"order_id" gorm:"not null;index" example:"1"`
+	FromStatus OrderStatus `json:"from_status" gorm:"type:varchar(20)" example:"pending"`
+	ToStatus   OrderStatus `json:"to_status" gorm:"type:varchar(20);not null" example:"paid"`
+	ChangedBy  *int        `json:"changed_by" example:"1"`
+	Note       string      `json:"note,omitempty" gorm:"type:varchar(255)" example:"paid by card"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+}
+
+// UpdateOrderStatus is a manual status change by staff. Paid, failed and
+// refunded are left to the payment flow, which records the payment and
+// moves the money.
+type UpdateOrderStatus struct {
+	Status OrderStatus `json:"status" binding:"required,oneof=shipped delivered cancelled" example:"shipped"`
+	Note   string      `json:"note" binding:"omitempty,max=255" example:"handed over to courier"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	PaymentStatusRefunded  PaymentStatus = "refunded"
+)
+
+type Payment struct {
+	ID            int           `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID       int           `json:"order_id" gorm:"not null;index" example:"1"`
+	Order         *Order        `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:OrderID;references:ID;"`
+	Provider      string        `json:"provider" gorm:"type:varchar(30);not null" example:"fake"`
+	IntentID      string        `json:"intent_id" gorm:"type:varchar(100);uniqueIndex;not null" example:"fake_pi_1_5f2b9c0e4d1a7e3b8c6d9a01"`
+	ClientSecret  string        `json:"client_secret,omitempty" gorm:"-" example:"fake_secret_0c8e1b7d2f4a6c9e3b5d7f10"`
+	Amount        uint          `json:"amount" gorm:"not null" example:"60"`
+	Currency      string        `json:"currency" gorm:"type:varchar(3);not null" example:"KZT"`
+	Status        PaymentStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'" example:"pending"`
+	FailureReason string        `json:"failure_reason,omitempty" gorm:"type:varchar(255)" example:"card_declined"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt     time.Time     `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
+}
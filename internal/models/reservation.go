@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// StockReservation holds Quantity units of an item for a cart until
+// ExpiresAt. Expired rows are ignored when computing availability and are
+// purged in the background.
+type StockReservation struct {
+	CartID    int       `json:"cart_id" gorm:"primaryKey;not null" example:"1"`
+	Cart      *Cart     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:CartID;references:ID;"`
+	ItemID    int       `json:"item_id" gorm:"primaryKey;not null;index" example:"1"`
+	Item      *Item     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ItemID;references:ID;"`
+	Quantity  uint      `json:"quantity" gorm:"not null" example:"2"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index" example:"2025-02-25T12:52:32Z"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Permission names one action guarded by RequirePermission, in the form
+// resource:action.
+type Permission string
+
+const (
+	PermissionItemsWrite     Permission = "items:write"
+	PermissionUsersRead      Permission = "users:read"
+	PermissionUsersWrite     Permission = "users:write"
+	PermissionSessionsRevoke Permission = "sessions:revoke"
+	PermissionOrdersWrite    Permission = "orders:write"
+	PermissionOrdersRefund   Permission = "orders:refund"
+	PermissionRolesManage    Permission = "roles:manage"
+	PermissionAPIKeysManage  Permission = "api-keys:manage"
+)
+
+// Permissions lists every permission a role can be granted.
+var Permissions = []Permission{
+	PermissionItemsWrite,
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionSessionsRevoke,
+	PermissionOrdersWrite,
+	PermissionOrdersRefund,
+	PermissionRolesManage,
+	PermissionAPIKeysManage,
+}
+
+func (p Permission) Valid() bool {
+	for _, known := range Permissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{1,49}$`)
+
+// IsBuiltin reports whether the role comes with the application: admin
+// always holds every permission and neither it nor user can be deleted.
+func (r Role) IsBuiltin() bool {
+	return r == RoleAdmin || r == RoleUser
+}
+
+// RoleDefinition is a role users can be assigned together with the
+// permissions it grants.
+type RoleDefinition struct {
+	ID          int              `json:"id" gorm:"primaryKey" example:"3"`
+	Name        Role             `json:"name" gorm:"type:varchar(50);uniqueIndex;not null" example:"catalog-manager"`
+	Description string           `json:"description" gorm:"type:varchar(255);not null;default:''" example:"Manages the item catalog"`
+	Permissions []RolePermission `json:"-" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
+}
+
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+type RolePermission struct {
+	RoleID     int        `gorm:"primaryKey"`
+	Permission Permission `gorm:"type:varchar(50);primaryKey"`
+}
+
+// Grants returns the permissions the role grants. Admins are granted
+// every permission, including ones added after the role was stored.
+func (r *RoleDefinition) Grants() []Permission {
+	if r.Name == RoleAdmin {
+		return Permissions
+	}
+
+	permissions := make([]Permission, len(r.Permissions))
+	for i, p := range r.Permissions {
+		permissions[i] = p.Permission
+	}
+	return permissions
+}
+
+// SetGrants replaces the permissions the role grants.
+func (r *RoleDefinition) SetGrants(permissions []Permission) {
+	r.Permissions = make([]RolePermission, 0, len(permissions))
+
+	seen := make(map[Permission]bool, len(permissions))
+	for _, p := range permissions {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		r.Permissions = append(
+			r.Permissions, RolePermission{RoleID: r.ID, Permission: p},
+		)
+	}
+}
+
+type RoleResponse struct {
+	ID          int          `json:"id" example:"3"`
+	Name        Role         `json:"name" example:"catalog-manager"`
+	Description string       `json:"description" example:"Manages the item catalog"`
+	Permissions []Permission `json:"permissions" example:"items:write"`
+	Builtin     bool         `json:"builtin" example:"false"`
+	CreatedAt   time.Time    `json:"created_at" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt   time.Time    `json:"updated_at" example:"2025-02-25T12:37:32Z"`
+}
+
+func (r *RoleDefinition) Response() RoleResponse {
+	return RoleResponse{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Grants(),
+		Builtin:     r.Name.IsBuiltin(),
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+func validatePermissions(permissions []Permission) error {
+	for _, p := range permissions {
+		if !p.Valid() {
+			return errors.New("unknown permission " + string(p))
+		}
+	}
+	return nil
+}
+
+type CreateRole struct {
+	Name        Role         `json:"name" binding:"required" example:"catalog-manager"`
+	Description string       `json:"description" binding:"max=255" example:"Manages the item catalog"`
+	Permissions []Permission `json:"permissions" example:"items:write"`
+}
+
+func (r *CreateRole) Validate() error {
+	if !roleNameRegex.MatchString(string(r.Name)) {
+		return errors.New(
+			"role name must be 2-50 lowercase letters, digits or hyphens",
+		)
+	}
+	return validatePermissions(r.Permissions)
+}
+
+// UpdateRole changes the fields that are set; Permissions replaces the
+// whole set.
+type UpdateRole struct {
+	Description *string      `json:"description" binding:"omitempty,max=255" example:"Manages items and prices"`
+	Permissions []Permission `json:"permissions" example:"items:write"`
+}
+
+func (r *UpdateRole) Validate() error {
+	return validatePermissions(r.Permissions)
+}
+
+type AssignRole struct {
+	Role Role `json:"role" binding:"required" example:"support"`
+}
@@ -27,16 +27,20 @@ const (
 )
 
 type User struct {
-	ID          int       `json:"id" gorm:"primaryKey" example:"1"`
-	FirstName   string    `json:"first_name" gorm:"type:varchar(30);not null" binding:"required,min=2,max=30" example:"Martin"`
-	LastName    string    `json:"last_name" gorm:"type:varchar(30);not null" binding:"required,min=2,max=30" example:"Kalts"`
-	Email       string    `json:"email" gorm:"type:varchar(100);uniqueIndex;not null" binding:"required,email" example:"martin@gmail.com"`
-	Password    string    `json:"password" gorm:"type:varchar(255);not null" binding:"required,min=8" example:"$2a$10$EKq8Yv9Y1WnrDFEdiMYCSOaz/oq2I9l9ngJyH/eBRM3lIbcJRLS02"`
-	PhoneNumber string    `json:"phone_number" gorm:"type:varchar(12);not null" binding:"required" example:"+77007473472"`
-	Role        Role      `json:"role" gorm:"type:varchar(10);not null;default:'user'" binding:"required,oneof=admin user" example:"user"`
-	Cart        *Cart     `json:"cart" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
+	ID              int        `json:"id" gorm:"primaryKey" example:"1"`
+	FirstName       string     `json:"first_name" gorm:"type:varchar(30);not null" binding:"required,min=2,max=30" example:"Martin"`
+	LastName        string     `json:"last_name" gorm:"type:varchar(30);not null" binding:"required,min=2,max=30" example:"Kalts"`
+	Email           string     `json:"email" gorm:"type:varchar(100);uniqueIndex;not null" binding:"required,email" example:"martin@gmail.com"`
+	Password        string     `json:"password" gorm:"type:varchar(255);not null" binding:"required,min=8" example:"$2a$10$EKq8Yv9Y1WnrDFEdiMYCSOaz/oq2I9l9ngJyH/eBRM3lIbcJRLS02"`
+	PhoneNumber     string     `json:"phone_number" gorm:"type:varchar(12);not null" binding:"required" example:"+77007473472"`
+	Role            Role       `json:"role" gorm:"type:varchar(50);not null;default:'user'" binding:"required" example:"user"`
+	EmailVerified   bool       `json:"email_verified" gorm:"not null;default:false" binding:"-" example:"true"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" binding:"-" example:"2025-02-25T12:40:00Z"`
+	MFAEnabled      bool       `json:"mfa_enabled" gorm:"not null;default:false" binding:"-" example:"false"`
+	MFASecret       string     `json:"-" gorm:"type:varchar(64)" binding:"-"`
+	Cart            *Cart      `json:"cart" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime" example:"2025-02-25T12:37:32Z"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime" example:"2025-02-25T12:37:32Z"`
 }
 
 type UserResponse struct {
@@ -77,11 +81,30 @@ func (r *RegisterUser) Validate() error {
 	return ValidatePhoneNumber(r.PhoneNumber)
 }
 
+// ClientInfo describes the device a session is opened from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// LoginLockout is the failed login state of an account.
+type LoginLockout struct {
+	Failures          int64 `json:"failures" example:"4"`
+	Locked            bool  `json:"locked" example:"false"`
+	RetryAfterSeconds int64 `json:"retry_after_seconds" example:"8"`
+}
+
 type LoginUser struct {
 	Email    string `json:"email" binding:"required,email" example:"martin@gmail.com"`
 	Password string `json:"password" binding:"required,min=8" example:"12341234"`
 }
 
+// RefreshTokens carries the refresh token of clients that do not keep it
+// in a cookie.
+type RefreshTokens struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type UpdateUser struct {
 	FirstName       *string `json:"first_name" binding:"omitempty,min=2,max=30" example:"Martin"`
 	LastName        *string `json:"last_name" binding:"omitempty,min=2,max=30" example:"Kalts"`
@@ -104,3 +127,14 @@ func (u *UpdateUser) Validate() error {
 	}
 	return nil
 }
+
+type SessionResponse struct {
+	ID         string    `json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4)"`
+	IP         string    `json:"ip" example:"203.0.113.7"`
+	MFA        bool      `json:"mfa" example:"false"`
+	Current    bool      `json:"current" example:"true"`
+	CreatedAt  time.Time `json:"created_at" example:"2025-02-25T12:37:32Z"`
+	LastUsedAt time.Time `json:"last_used_at" example:"2025-02-25T13:02:11Z"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2025-02-26T12:37:32Z"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeMFAChallenge      TokenPurpose = "mfa_challenge"
+	TokenPurposeAccountUnlock     TokenPurpose = "account_unlock"
+)
+
+// UserToken is a single-use token mailed to a user. Only the hash of the
+// token is stored.
+type UserToken struct {
+	ID        int          `json:"id" gorm:"primaryKey"`
+	UserID    int          `json:"user_id" gorm:"not null;index"`
+	User      *User        `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID;references:ID;"`
+	Purpose   TokenPurpose `json:"purpose" gorm:"type:varchar(30);not null"`
+	TokenHash string       `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time    `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time   `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required" example:"q3T0c2Zr8m1H4yJx9bVnWl2oPe7uAaKdRiSgLfCzXsE.mJ0k3QpX8rZy1vN5cT7bW2aL4eG9hF6dS0uI3oP1qR8"`
+}
+
+type ResendVerification struct {
+	Email string `json:"email" binding:"required,email" example:"martin@gmail.com"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email" example:"martin@gmail.com"`
+}
+
+type UnlockAccount struct {
+	Token string `json:"token" binding:"required" example:"q3T0c2Zr8m1H4yJx9bVnWl2oPe7uAaKdRiSgLfCzXsE.mJ0k3QpX8rZy1vN5cT7bW2aL4eG9hF6dS0uI3oP1qR8"`
+}
+
+type ResetPassword struct {
+	Token           string `json:"token" binding:"required" example:"q3T0c2Zr8m1H4yJx9bVnWl2oPe7uAaKdRiSgLfCzXsE.mJ0k3QpX8rZy1vN5cT7bW2aL4eG9hF6dS0uI3oP1qR8"`
+	Password        string `json:"password" binding:"required,min=8" example:"43214321"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,min=8" example:"43214321"`
+}
+
+func (r *ResetPassword) Validate() error {
+	if r.Password != r.ConfirmPassword {
+		return errors.New("passwords do not match")
+	}
+	return nil
+}
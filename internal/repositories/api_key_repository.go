@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAll(ctx context.Context) ([]models.APIKey, error)
+	// Revoke marks an unrevoked key as revoked at the given time.
+	Revoke(ctx context.Context, id int, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(
+	ctx context.Context, key *models.APIKey,
+) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(
+	ctx context.Context, hash string,
+) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := r.db.WithContext(ctx).
+		Preload("Scopes").
+		Where("key_hash = ?", hash).
+		First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetAll(
+	ctx context.Context,
+) ([]models.APIKey, error) {
+	var keys []models.APIKey
+
+	if err := r.db.WithContext(ctx).
+		Preload("Scopes").
+		Order("id").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(
+	ctx context.Context, id int, at time.Time,
+) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(
+	ctx context.Context, id int, at time.Time,
+) error {
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
@@ -1,21 +1,34 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"time"
+
 	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/DaniilKalts/market-rest-api/internal/models"
 )
 
 type CartRepository interface {
-	Add(cartID int, itemID int) (*models.CartItem, error)
-	GetCartItem(cartID int, itemID int) (*models.CartItem, error)
-	GetByUserID(userID int) (*models.Cart, error)
-	Update(cartID int, itemID int, quantity uint) (*models.CartItem, error)
-	Delete(cartID int, itemID int) error
-	Clear(cartID int) error
+	Add(
+		ctx context.Context, cartID int, itemID int, hold time.Duration,
+	) (*models.CartItem, error)
+	GetCartItem(
+		ctx context.Context, cartID int, itemID int,
+	) (*models.CartItem, error)
+	GetByUserID(ctx context.Context, userID int) (*models.Cart, error)
+	Update(
+		ctx context.Context,
+		cartID int, itemID int, quantity uint, hold time.Duration,
+	) (*models.CartItem, error)
+	Delete(ctx context.Context, cartID int, itemID int) error
+	Clear(ctx context.Context, cartID int) error
+	// CountWithItems counts the carts holding at least one item.
+	CountWithItems(ctx context.Context) (int64, error)
 }
 
 type cartRepository struct {
@@ -26,57 +39,104 @@ func NewCartRepository(db *gorm.DB) CartRepository {
 	return &cartRepository{db: db}
 }
 
-func (r *cartRepository) Add(
-	cartID int, itemID int,
-) (*models.CartItem, error) {
-	var cartItem models.CartItem
-
-	err := r.db.
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		First(&cartItem).Error
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		cartItem = models.CartItem{
-			CartID:   cartID,
-			ItemID:   itemID,
-			Quantity: 1,
-		}
-
-		if err := r.db.Create(&cartItem).Error; err != nil {
-			return nil, err
-		}
-		if err := r.db.
-			Preload("Item").
-			Where("cart_id = ? AND item_id = ?", cartID, itemID).
-			First(&cartItem).Error; err != nil {
-			return nil, err
+// lockCart locks the cart row until the transaction ends. Everything that
+// changes the lines of a cart takes this lock before any item lock, so a
+// checkout never misses or wipes a line added while it runs.
+func lockCart(tx *gorm.DB, cartID int) error {
+	var cart models.Cart
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&cart, cartID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.ErrCartNotFound
 		}
-
-		return &cartItem, nil
-	} else if err != nil {
-		return nil, err
+		return err
 	}
 
-	cartItem.Quantity++
-	if err := r.db.Save(&cartItem).Error; err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	if err := r.db.
-		Preload("Item").
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		First(&cartItem).Error; err != nil {
+// changeQuantity runs change and re-reserves stock for the resulting line
+// quantity in one transaction. The cart and then the item row are locked
+// first, so concurrent changes to the same item are serialized and the
+// stock check always sees the committed quantities; a shortage rolls the
+// change back.
+func (r *cartRepository) changeQuantity(
+	ctx context.Context, cartID int, itemID int, hold time.Duration,
+	change func(tx *gorm.DB) error,
+) (*models.CartItem, error) {
+	var cartItem models.CartItem
+
+	err := r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := lockCart(tx, cartID); err != nil {
+				return err
+			}
+
+			item, err := lockItem(tx, itemID)
+			if err != nil {
+				return err
+			}
+
+			if err := change(tx); err != nil {
+				return err
+			}
+
+			if err := tx.
+				Preload("Item").
+				Where("cart_id = ? AND item_id = ?", cartID, itemID).
+				First(&cartItem).Error; err != nil {
+				return err
+			}
+
+			return reserveStock(tx, cartID, item, cartItem.Quantity, hold)
+		},
+	)
+	if err != nil {
 		return nil, err
 	}
 
 	return &cartItem, nil
 }
 
-func (r *cartRepository) GetCartItem(cartID int, itemID int) (
-	*models.CartItem, error,
-) {
+// Add puts one more unit of the item into the cart. The increment is done by
+// the database, so concurrent requests cannot overwrite each other.
+func (r *cartRepository) Add(
+	ctx context.Context, cartID int, itemID int, hold time.Duration,
+) (*models.CartItem, error) {
+	return r.changeQuantity(
+		ctx, cartID, itemID, hold, func(tx *gorm.DB) error {
+			cartItem := models.CartItem{
+				CartID:   cartID,
+				ItemID:   itemID,
+				Quantity: 1,
+			}
+			return tx.
+				Clauses(
+					clause.OnConflict{
+						Columns: []clause.Column{
+							{Name: "cart_id"}, {Name: "item_id"},
+						},
+						DoUpdates: clause.Assignments(
+							map[string]interface{}{
+								"quantity": gorm.Expr(
+									"cart_items.quantity + 1",
+								),
+								"updated_at": time.Now(),
+							},
+						),
+					},
+				).
+				Create(&cartItem).Error
+		},
+	)
+}
+
+func (r *cartRepository) GetCartItem(
+	ctx context.Context, cartID int, itemID int,
+) (*models.CartItem, error) {
 	var cartItem models.CartItem
-	err := r.db.
+	err := r.db.WithContext(ctx).
 		Preload("Item").
 		Where("cart_id = ? AND item_id = ?", cartID, itemID).
 		First(&cartItem).Error
@@ -90,10 +150,12 @@ func (r *cartRepository) GetCartItem(cartID int, itemID int) (
 	return &cartItem, nil
 }
 
-func (r *cartRepository) GetByUserID(userID int) (*models.Cart, error) {
+func (r *cartRepository) GetByUserID(
+	ctx context.Context, userID int,
+) (*models.Cart, error) {
 	var cart models.Cart
 
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
 		Preload("Items.Item").
 		First(&cart).
 		Error
@@ -108,48 +170,76 @@ func (r *cartRepository) GetByUserID(userID int) (*models.Cart, error) {
 }
 
 func (r *cartRepository) Update(
+	ctx context.Context,
 	cartID int,
 	itemID int,
 	quantity uint,
+	hold time.Duration,
 ) (*models.CartItem, error) {
-	var cartItem models.CartItem
-
-	if err := r.db.
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		First(&cartItem).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errs.ErrItemNotFound
-		}
-		return nil, err
-	}
-
-	cartItem.Quantity = quantity
-	if err := r.db.Save(&cartItem).Error; err != nil {
-		return nil, err
-	}
-
-	if err := r.db.
-		Preload("Item").
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		First(&cartItem).Error; err != nil {
-		return nil, err
-	}
-
-	return &cartItem, nil
+	return r.changeQuantity(
+		ctx, cartID, itemID, hold, func(tx *gorm.DB) error {
+			result := tx.
+				Model(&models.CartItem{}).
+				Where("cart_id = ? AND item_id = ?", cartID, itemID).
+				Update("quantity", quantity)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errs.ErrItemNotFound
+			}
+			return nil
+		},
+	)
 }
 
+// Delete removes the line from the cart together with its reservation.
 func (r *cartRepository) Delete(
-	cartID int, itemID int,
+	ctx context.Context, cartID int, itemID int,
 ) error {
-	return r.db.
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		Delete(&models.CartItem{}).
-		Error
+	return r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := lockCart(tx, cartID); err != nil {
+				return err
+			}
+
+			if err := tx.
+				Where("cart_id = ? AND item_id = ?", cartID, itemID).
+				Delete(&models.CartItem{}).Error; err != nil {
+				return err
+			}
+
+			return releaseStock(tx, cartID, itemID)
+		},
+	)
+}
+
+// Clear empties the cart and releases all of its reservations.
+func (r *cartRepository) Clear(ctx context.Context, cartID int) error {
+	return r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := lockCart(tx, cartID); err != nil {
+				return err
+			}
+
+			if err := tx.
+				Where("cart_id = ?", cartID).
+				Delete(&models.CartItem{}).Error; err != nil {
+				return err
+			}
+
+			return releaseStock(tx, cartID)
+		},
+	)
 }
 
-func (r *cartRepository) Clear(cartID int) error {
-	return r.db.
-		Where("cart_id = ?", cartID).
-		Delete(&models.CartItem{}).
+func (r *cartRepository) CountWithItems(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&models.CartItem{}).
+		Distinct("cart_id").
+		Count(&count).
 		Error
+
+	return count, err
 }
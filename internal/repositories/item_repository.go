@@ -1,7 +1,14 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"gorm.io/gorm"
 
@@ -11,29 +18,43 @@ import (
 )
 
 type ItemRepository interface {
-	Create(item *models.Item) error
-	GetByID(id int) (*models.Item, error)
-	GetAll() ([]models.Item, error)
-	Update(item *models.Item) error
-	Delete(id int) error
+	Create(ctx context.Context, item *models.Item) error
+	GetByID(ctx context.Context, id int) (*models.Item, error)
+	GetAll(
+		ctx context.Context, opts *models.ItemQueryOptions,
+	) ([]models.Item, int64, error)
+	Search(
+		ctx context.Context, opts *models.ItemSearchOptions,
+	) ([]models.ItemSearchResult, error)
+	Update(ctx context.Context, item *models.Item) error
+	Delete(ctx context.Context, id int) error
+	// CountOutOfStock counts the items whose stock is zero.
+	CountOutOfStock(ctx context.Context) (int64, error)
 }
 
 type itemRepository struct {
 	db *gorm.DB
+
+	searchMu       sync.Mutex
+	searchDetected bool
+	hasFullText    bool
+	hasTrigram     bool
 }
 
 func NewItemRepository(db *gorm.DB) ItemRepository {
 	return &itemRepository{db: db}
 }
 
-func (r *itemRepository) Create(item *models.Item) error {
-	return r.db.Create(item).Error
+func (r *itemRepository) Create(ctx context.Context, item *models.Item) error {
+	return r.db.WithContext(ctx).Create(item).Error
 }
 
-func (r *itemRepository) GetByID(id int) (*models.Item, error) {
+func (r *itemRepository) GetByID(
+	ctx context.Context, id int,
+) (*models.Item, error) {
 	var item models.Item
 
-	err := r.db.First(&item, id).Error
+	err := r.db.WithContext(ctx).First(&item, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errs.ErrItemNotFound
@@ -41,26 +62,297 @@ func (r *itemRepository) GetByID(id int) (*models.Item, error) {
 		return nil, err
 	}
 
+	held, err := heldByOtherCarts(
+		r.db.WithContext(ctx), 0, []int{item.ID}, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	item.SetReserved(held[item.ID])
+
 	return &item, nil
 }
 
-func (r *itemRepository) GetAll() ([]models.Item, error) {
+var itemSortColumns = map[models.ItemSortField]string{
+	models.ItemSortName:      "name",
+	models.ItemSortPrice:     "price",
+	models.ItemSortStock:     "stock",
+	models.ItemSortCreatedAt: "created_at",
+}
+
+func parseItemCursorValue(
+	field models.ItemSortField, raw string,
+) (interface{}, error) {
+	switch field {
+	case models.ItemSortName:
+		return raw, nil
+	case models.ItemSortPrice, models.ItemSortStock:
+		return strconv.ParseUint(raw, 10, 64)
+	case models.ItemSortCreatedAt:
+		return time.Parse(time.RFC3339Nano, raw)
+	default:
+		return nil, errs.ErrInvalidCursor
+	}
+}
+
+func (r *itemRepository) GetAll(
+	ctx context.Context, opts *models.ItemQueryOptions,
+) ([]models.Item, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Item{})
+
+	if opts.MinPrice != nil {
+		query = query.Where("price >= ?", *opts.MinPrice)
+	}
+	if opts.MaxPrice != nil {
+		query = query.Where("price <= ?", *opts.MaxPrice)
+	}
+	if opts.InStock != nil {
+		if *opts.InStock {
+			query = query.Where("stock > 0")
+		} else {
+			query = query.Where("stock = 0")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := itemSortColumns[opts.SortBy]
+	if !ok {
+		column = itemSortColumns[models.ItemSortCreatedAt]
+	}
+	direction, comparator := "ASC", ">"
+	if opts.Order == models.SortDesc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		value, err := parseItemCursorValue(opts.SortBy, opts.After.Value)
+		if err != nil {
+			return nil, 0, errs.ErrInvalidCursor
+		}
+		query = query.Where(
+			"("+column+", id) "+comparator+" (?, ?)", value, opts.After.ID,
+		)
+	} else if opts.Page > 1 {
+		query = query.Offset((opts.Page - 1) * opts.Limit)
+	}
+
 	var items []models.Item
 
-	err := r.db.Find(&items).Error
+	err := query.
+		Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(opts.Limit).
+		Find(&items).Error
 	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// detectSearchSupport checks whether migrate managed to create the
+// generated search column and the pg_trgm extension. Search falls back to
+// ILIKE matching when the column is missing. The answer is kept once both
+// queries succeeded; a failed check is retried by the next search.
+func (r *itemRepository) detectSearchSupport(ctx context.Context) error {
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.searchDetected {
+		return nil
+	}
+
+	var hasFullText, hasTrigram bool
+	if err := r.db.WithContext(ctx).Raw(
+		`SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'items' AND column_name = 'search_vector'
+		)`,
+	).Scan(&hasFullText).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Raw(
+		`SELECT EXISTS (
+			SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm'
+		)`,
+	).Scan(&hasTrigram).Error; err != nil {
+		return err
+	}
+
+	r.hasFullText, r.hasTrigram = hasFullText, hasTrigram
+	r.searchDetected = true
+	return nil
+}
+
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(
+		strings.ToLower(query), func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+		},
+	)
+}
+
+func (r *itemRepository) Search(
+	ctx context.Context, opts *models.ItemSearchOptions,
+) ([]models.ItemSearchResult, error) {
+	terms := searchTerms(opts.Query)
+	if len(terms) == 0 {
+		return []models.ItemSearchResult{}, nil
+	}
+
+	if err := r.detectSearchSupport(ctx); err != nil {
 		return nil, err
 	}
+	if !r.hasFullText {
+		return r.searchILike(ctx, terms, opts)
+	}
 
-	return items, nil
+	// Every term is matched as a prefix so that "t-sh" already finds
+	// "T-shirt" while the user is typing.
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	tsQuery := strings.Join(prefixes, " & ")
+
+	rank := "ts_rank(items.search_vector, q)"
+	match := "items.search_vector @@ q"
+	if r.hasTrigram {
+		rank += " + word_similarity(@raw, items.name)"
+		match += " OR @raw <% items.name"
+	}
+
+	// The text is HTML-escaped before it is highlighted, so the snippet
+	// carries no markup but the <mark> tags. The parser reads the entities
+	// as single tokens, which are never highlighted.
+	sql := `SELECT items.*, ` + rank + ` AS rank,
+			ts_headline(
+				'simple', ` + escapeHTMLSQL(
+		"items.name || ': ' || coalesce(items.description, '')",
+	) + `, q,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=10'
+			) AS snippet
+		FROM items, to_tsquery('simple', @query) q
+		WHERE ` + match + `
+		ORDER BY rank DESC, items.id
+		LIMIT @limit OFFSET @offset`
+
+	var results []models.ItemSearchResult
+
+	err := r.db.WithContext(ctx).Raw(
+		sql, map[string]interface{}{
+			"query":  tsQuery,
+			"raw":    opts.Query,
+			"limit":  opts.Limit,
+			"offset": (opts.Page - 1) * opts.Limit,
+		},
+	).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
-func (r *itemRepository) Update(item *models.Item) error {
-	return r.db.Save(item).Error
+// escapeHTMLSQL wraps the SQL text expression expr so that it escapes the
+// same characters as html.EscapeString.
+func escapeHTMLSQL(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'),` +
+		` '''', '&#39;')`
 }
 
-func (r *itemRepository) Delete(id int) error {
-	result := r.db.Delete(&models.Item{}, id)
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *itemRepository) searchILike(
+	ctx context.Context, terms []string, opts *models.ItemSearchOptions,
+) ([]models.ItemSearchResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Item{})
+	for _, term := range terms {
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		query = query.Where(
+			"(name ILIKE ? OR description ILIKE ?)", pattern, pattern,
+		)
+	}
+
+	var items []models.Item
+
+	err := query.
+		Order(
+			gorm.Expr(
+				"CASE WHEN name ILIKE ? THEN 0 ELSE 1 END",
+				likeEscaper.Replace(terms[0])+"%",
+			),
+		).
+		Order("name").
+		Order("id").
+		Limit(opts.Limit).
+		Offset((opts.Page - 1) * opts.Limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.ItemSearchResult, len(items))
+	for i, item := range items {
+		results[i] = models.ItemSearchResult{
+			Item:    item,
+			Snippet: highlight(item.Name+": "+item.Description, terms),
+		}
+	}
+
+	return results, nil
+}
+
+// highlight mirrors the ts_headline output for the ILIKE fallback by
+// HTML-escaping text and wrapping every case-insensitive occurrence of the
+// terms in <mark> tags.
+func highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	marked := make([]bool, len(lower))
+	for _, term := range terms {
+		for start := 0; ; {
+			idx := strings.Index(lower[start:], term)
+			if idx < 0 {
+				break
+			}
+			for i := start + idx; i < start+idx+len(term); i++ {
+				marked[i] = true
+			}
+			start += idx + len(term)
+		}
+	}
+
+	// strings.ToLower can change byte lengths for some runes, in which case
+	// the offsets no longer line up and the text is returned unchanged.
+	if len(lower) != len(text) {
+		return html.EscapeString(text)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+
+	return b.String()
+}
+
+func (r *itemRepository) Update(ctx context.Context, item *models.Item) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
+
+func (r *itemRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&models.Item{}, id)
 
 	if result.Error != nil {
 		return result.Error
@@ -71,3 +363,14 @@ func (r *itemRepository) Delete(id int) error {
 
 	return nil
 }
+
+func (r *itemRepository) CountOutOfStock(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&models.Item{}).
+		Where("stock = 0").
+		Count(&count).
+		Error
+
+	return count, err
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type MFARecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID int, codeHashes []string) error
+	Consume(ctx context.Context, userID int, codeHash string) error
+	DeleteByUserID(ctx context.Context, userID int) error
+}
+
+type mfaRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARecoveryCodeRepository(db *gorm.DB) MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepository{db: db}
+}
+
+// Replace swaps every recovery code of the user for the given ones.
+func (r *mfaRecoveryCodeRepository) Replace(
+	ctx context.Context, userID int, codeHashes []string,
+) error {
+	return r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.
+				Where("user_id = ?", userID).
+				Delete(&models.MFARecoveryCode{}).Error; err != nil {
+				return err
+			}
+
+			codes := make([]models.MFARecoveryCode, len(codeHashes))
+			for i, hash := range codeHashes {
+				codes[i] = models.MFARecoveryCode{
+					UserID:   userID,
+					CodeHash: hash,
+				}
+			}
+			return tx.Create(&codes).Error
+		},
+	)
+}
+
+// Consume marks an unused code of the user as used in one statement, so a
+// code cannot be redeemed twice by concurrent requests.
+func (r *mfaRecoveryCodeRepository) Consume(
+	ctx context.Context, userID int, codeHash string,
+) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.MFARecoveryCode{}).
+		Where(
+			"user_id = ? AND code_hash = ? AND used_at IS NULL",
+			userID, codeHash,
+		).
+		Update("used_at", time.Now())
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (r *mfaRecoveryCodeRepository) DeleteByUserID(
+	ctx context.Context, userID int,
+) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&models.MFARecoveryCode{}).Error
+}
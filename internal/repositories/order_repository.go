@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type OrderRepository interface {
+	CreateFromCart(ctx context.Context, userID int) (*models.Order, error)
+	GetByID(ctx context.Context, id int) (*models.Order, error)
+	GetByUserID(ctx context.Context, userID int) ([]models.Order, error)
+	UpdateStatus(
+		ctx context.Context,
+		orderID int, status models.OrderStatus, changedBy *int, note string,
+	) (*models.Order, error)
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+// CreateFromCart turns the user's cart into an order in one transaction:
+// the cart row is locked, so concurrent checkouts and cart changes wait and
+// then see the emptied cart; the referenced items are locked, stock not
+// reserved by other carts is checked and decremented, the order with its
+// snapshot lines is created and the cart together with its reservations is
+// emptied.
+func (r *orderRepository) CreateFromCart(
+	ctx context.Context, userID int,
+) (*models.Order, error) {
+	var order models.Order
+
+	err := r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			var cart models.Cart
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_id = ?", userID).
+				First(&cart).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errs.ErrCartNotFound
+				}
+				return err
+			}
+
+			var cartItems []models.CartItem
+			if err := tx.
+				Where("cart_id = ?", cart.ID).
+				Order("item_id").
+				Find(&cartItems).Error; err != nil {
+				return err
+			}
+			if len(cartItems) == 0 {
+				return errs.ErrCartEmpty
+			}
+
+			itemIDs := make([]int, len(cartItems))
+			for i, cartItem := range cartItems {
+				itemIDs[i] = cartItem.ItemID
+			}
+
+			// Rows are locked in id order so concurrent checkouts sharing
+			// items cannot deadlock each other.
+			var items []models.Item
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", itemIDs).
+				Order("id").
+				Find(&items).Error; err != nil {
+				return err
+			}
+
+			itemsByID := make(map[int]models.Item, len(items))
+			for _, item := range items {
+				itemsByID[item.ID] = item
+			}
+
+			// Units held by other carts are not for sale; this cart's own
+			// reservations are converted into the stock decrement below.
+			held, err := heldByOtherCarts(tx, cart.ID, itemIDs, time.Now())
+			if err != nil {
+				return err
+			}
+			for itemID, quantity := range held {
+				item, ok := itemsByID[itemID]
+				if !ok {
+					continue
+				}
+				if item.Stock > quantity {
+					item.Stock -= quantity
+				} else {
+					item.Stock = 0
+				}
+				itemsByID[itemID] = item
+			}
+
+			var shortages []errs.StockShortage
+			order = models.Order{
+				UserID: userID,
+				Status: models.OrderStatusPending,
+				History: []models.OrderStatusHistory{
+					{
+						ToStatus:  models.OrderStatusPending,
+						ChangedBy: &userID,
+					},
+				},
+			}
+			for _, cartItem := range cartItems {
+				item, ok := itemsByID[cartItem.ItemID]
+				if !ok || cartItem.Quantity > item.Stock {
+					shortages = append(
+						shortages, errs.StockShortage{
+							ItemID:    cartItem.ItemID,
+							Name:      item.Name,
+							Requested: cartItem.Quantity,
+							Available: item.Stock,
+						},
+					)
+					continue
+				}
+
+				itemID := item.ID
+				order.Total += item.Price * cartItem.Quantity
+				order.Items = append(
+					order.Items, models.OrderItem{
+						ItemID:   &itemID,
+						Name:     item.Name,
+						Price:    item.Price,
+						Quantity: cartItem.Quantity,
+					},
+				)
+			}
+			if len(shortages) > 0 {
+				return &errs.InsufficientStockError{Lines: shortages}
+			}
+
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+
+			for _, orderItem := range order.Items {
+				if err := tx.
+					Model(&models.Item{}).
+					Where("id = ?", *orderItem.ItemID).
+					UpdateColumn(
+						"stock", gorm.Expr("stock - ?", orderItem.Quantity),
+					).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := releaseStock(tx, cart.ID); err != nil {
+				return err
+			}
+
+			return tx.
+				Where("cart_id = ?", cart.ID).
+				Delete(&models.CartItem{}).
+				Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (r *orderRepository) GetByID(
+	ctx context.Context, id int,
+) (*models.Order, error) {
+	var order models.Order
+
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at, id")
+		}).
+		First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (r *orderRepository) GetByUserID(
+	ctx context.Context, userID int,
+) ([]models.Order, error) {
+	var orders []models.Order
+
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// UpdateStatus moves an order to a new status if the transition is legal,
+// records it in the history table and returns the stock of cancelled orders
+// to their items. The order row is locked for the whole transaction.
+func (r *orderRepository) UpdateStatus(
+	ctx context.Context,
+	orderID int, status models.OrderStatus, changedBy *int, note string,
+) (*models.Order, error) {
+	err := r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			var order models.Order
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Preload("Items").
+				First(&order, orderID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errs.ErrOrderNotFound
+				}
+				return err
+			}
+
+			from := order.Status
+			if !models.CanTransition(from, status) {
+				return errs.ErrInvalidOrderTransition
+			}
+
+			order.SetStatus(status, time.Now())
+			if err := tx.
+				Model(&order).
+				Select(
+					"Status", "PaidAt", "ShippedAt", "DeliveredAt",
+					"CancelledAt", "RefundedAt",
+				).
+				Updates(&order).Error; err != nil {
+				return err
+			}
+
+			history := models.OrderStatusHistory{
+				OrderID:    order.ID,
+				FromStatus: from,
+				ToStatus:   status,
+				ChangedBy:  changedBy,
+				Note:       note,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+
+			if status != models.OrderStatusCancelled {
+				return nil
+			}
+			for _, orderItem := range order.Items {
+				// Items deleted from the catalog have nothing to restore.
+				if orderItem.ItemID == nil {
+					continue
+				}
+				if err := tx.
+					Model(&models.Item{}).
+					Where("id = ?", *orderItem.ItemID).
+					UpdateColumn(
+						"stock", gorm.Expr("stock + ?", orderItem.Quantity),
+					).Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, orderID)
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	// GetByIntentIDForUpdate locks the payment row until the transaction
+	// ends, so call it inside a UnitOfWork.
+	GetByIntentIDForUpdate(
+		ctx context.Context, intentID string,
+	) (*models.Payment, error)
+	GetSucceededByOrderID(
+		ctx context.Context, orderID int,
+	) (*models.Payment, error)
+	Update(ctx context.Context, payment *models.Payment) error
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(
+	ctx context.Context, payment *models.Payment,
+) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetByIntentIDForUpdate(
+	ctx context.Context, intentID string,
+) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("intent_id = ?", intentID).
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetSucceededByOrderID(
+	ctx context.Context, orderID int,
+) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := r.db.WithContext(ctx).
+		Where(
+			"order_id = ? AND status = ?",
+			orderID, models.PaymentStatusSucceeded,
+		).
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) Update(
+	ctx context.Context, payment *models.Payment,
+) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+// ReservationRepository maintains the stock reservations table. Cart lines
+// reserve and release stock in the transaction that changes them, through
+// reserveStock and releaseStock, so a reservation never outlives or
+// precedes its line; this repository only purges the holds that expired.
+type ReservationRepository interface {
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+func (r *reservationRepository) DeleteExpired(
+	ctx context.Context,
+) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&models.StockReservation{})
+
+	return result.RowsAffected, result.Error
+}
+
+// lockItem loads an item with a row lock that is held until tx ends. Every
+// change to reservations of the item happens under this lock.
+func lockItem(tx *gorm.DB, itemID int) (*models.Item, error) {
+	var item models.Item
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// heldByOtherCarts sums the active reservations of each of itemIDs held by
+// carts other than cartID. Pass 0 to include every cart. Items nobody holds
+// are missing from the result.
+func heldByOtherCarts(
+	db *gorm.DB, cartID int, itemIDs []int, now time.Time,
+) (map[int]uint, error) {
+	var rows []struct {
+		ItemID   int
+		Quantity uint
+	}
+	if err := db.
+		Model(&models.StockReservation{}).
+		Select("item_id, SUM(quantity) AS quantity").
+		Where(
+			"item_id IN ? AND cart_id <> ? AND expires_at > ?",
+			itemIDs, cartID, now,
+		).
+		Group("item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	held := make(map[int]uint, len(rows))
+	for _, row := range rows {
+		held[row.ItemID] = row.Quantity
+	}
+
+	return held, nil
+}
+
+// reserveStock sets the cart's hold on a locked item to quantity and pushes
+// its expiry ttl into the future, failing with an InsufficientStockError
+// when other carts already hold the units.
+func reserveStock(
+	tx *gorm.DB, cartID int, item *models.Item, quantity uint,
+	ttl time.Duration,
+) error {
+	now := time.Now()
+	held, err := heldByOtherCarts(tx, cartID, []int{item.ID}, now)
+	if err != nil {
+		return err
+	}
+	reserved := held[item.ID]
+
+	available := uint(0)
+	if item.Stock > reserved {
+		available = item.Stock - reserved
+	}
+	if quantity > available {
+		return &errs.InsufficientStockError{
+			Lines: []errs.StockShortage{
+				{
+					ItemID:    item.ID,
+					Name:      item.Name,
+					Requested: quantity,
+					Available: available,
+				},
+			},
+		}
+	}
+
+	reservation := models.StockReservation{
+		CartID:    cartID,
+		ItemID:    item.ID,
+		Quantity:  quantity,
+		ExpiresAt: now.Add(ttl),
+	}
+	return tx.
+		Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "cart_id"}, {Name: "item_id"}},
+				DoUpdates: clause.AssignmentColumns(
+					[]string{"quantity", "expires_at", "updated_at"},
+				),
+			},
+		).
+		Create(&reservation).Error
+}
+
+// releaseStock drops the reservations of cartID for itemIDs, or for every
+// item of the cart when none are given.
+func releaseStock(tx *gorm.DB, cartID int, itemIDs ...int) error {
+	query := tx.Where("cart_id = ?", cartID)
+	if len(itemIDs) > 0 {
+		query = query.Where("item_id IN ?", itemIDs)
+	}
+
+	return query.Delete(&models.StockReservation{}).Error
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.RoleDefinition) error
+	GetByID(ctx context.Context, id int) (*models.RoleDefinition, error)
+	GetByName(
+		ctx context.Context, name models.Role,
+	) (*models.RoleDefinition, error)
+	GetAll(ctx context.Context) ([]models.RoleDefinition, error)
+	// Update saves the description of the role and replaces its
+	// permissions.
+	Update(ctx context.Context, role *models.RoleDefinition) error
+	Delete(ctx context.Context, id int) error
+	CountUsers(ctx context.Context, name models.Role) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) Create(
+	ctx context.Context, role *models.RoleDefinition,
+) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
+func (r *roleRepository) GetByID(
+	ctx context.Context, id int,
+) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+
+	err := r.db.WithContext(ctx).Preload("Permissions").First(&role, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *roleRepository) GetByName(
+	ctx context.Context, name models.Role,
+) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+
+	err := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Where("name = ?", name).
+		First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *roleRepository) GetAll(
+	ctx context.Context,
+) ([]models.RoleDefinition, error) {
+	var roles []models.RoleDefinition
+
+	if err := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Order("id").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepository) Update(
+	ctx context.Context, role *models.RoleDefinition,
+) error {
+	return r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Model(role).
+				Update("description", role.Description).Error; err != nil {
+				return err
+			}
+
+			if err := tx.
+				Where("role_id = ?", role.ID).
+				Delete(&models.RolePermission{}).Error; err != nil {
+				return err
+			}
+			if len(role.Permissions) == 0 {
+				return nil
+			}
+
+			for i := range role.Permissions {
+				role.Permissions[i].RoleID = role.ID
+			}
+			return tx.Create(&role.Permissions).Error
+		},
+	)
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id int) error {
+	res := r.db.WithContext(ctx).Delete(&models.RoleDefinition{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrRoleNotFound
+	}
+	return nil
+}
+
+func (r *roleRepository) CountUsers(
+	ctx context.Context, name models.Role,
+) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("role = ?", name).
+		Count(&count).Error
+
+	return count, err
+}
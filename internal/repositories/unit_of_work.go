@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories bundles one instance of every repository, all bound to the
+// same database handle.
+type Repositories struct {
+	Items         ItemRepository
+	Users         UserRepository
+	Carts         CartRepository
+	Orders        OrderRepository
+	Payments      PaymentRepository
+	Reservations  ReservationRepository
+	UserTokens    UserTokenRepository
+	RecoveryCodes MFARecoveryCodeRepository
+}
+
+func newRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Items:         NewItemRepository(db),
+		Users:         NewUserRepository(db),
+		Carts:         NewCartRepository(db),
+		Orders:        NewOrderRepository(db),
+		Payments:      NewPaymentRepository(db),
+		Reservations:  NewReservationRepository(db),
+		UserTokens:    NewUserTokenRepository(db),
+		RecoveryCodes: NewMFARecoveryCodeRepository(db),
+	}
+}
+
+// UnitOfWork lets services group calls to several repositories into one
+// database transaction.
+type UnitOfWork interface {
+	// Do runs fn inside a transaction and hands it repositories bound to
+	// that transaction. The transaction is rolled back when fn returns an
+	// error or panics and committed otherwise. Repositories that open their
+	// own transactions nest into it as savepoints.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(
+	ctx context.Context, fn func(repos Repositories) error,
+) error {
+	return u.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			return fn(newRepositories(tx))
+		},
+	)
+}
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -11,12 +13,15 @@ import (
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id int) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetAll() ([]models.User, error)
-	Update(user *models.User) (*models.User, error)
-	Delete(id int) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetAll(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, user *models.User) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, id int) error
+	UpdateMFA(ctx context.Context, id int, enabled bool, secret string) error
+	UpdateRole(ctx context.Context, id int, role models.Role) error
+	Delete(ctx context.Context, id int) error
 }
 
 type userRepository struct {
@@ -27,14 +32,18 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) GetByID(id int) (*models.User, error) {
+func (r *userRepository) GetByID(
+	ctx context.Context, id int,
+) (*models.User, error) {
 	var user models.User
 
-	err := r.db.Preload("Cart.Items.Item").First(&user, id).Error
+	err := r.db.WithContext(ctx).
+		Preload("Cart.Items.Item").
+		First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errs.ErrUserNotFound
@@ -49,10 +58,12 @@ func (r *userRepository) GetByID(id int) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(
+	ctx context.Context, email string,
+) (*models.User, error) {
 	var user models.User
 
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errs.ErrUserNotFound
@@ -63,18 +74,22 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) GetAll() ([]models.User, error) {
+func (r *userRepository) GetAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
 
-	if err := r.db.Preload("Cart.Items.Item").Find(&users).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Preload("Cart.Items.Item").
+		Find(&users).Error; err != nil {
 		return nil, err
 	}
 
 	return users, nil
 }
 
-func (r *userRepository) Update(user *models.User) (*models.User, error) {
-	err := r.db.Save(user).Error
+func (r *userRepository) Update(
+	ctx context.Context, user *models.User,
+) (*models.User, error) {
+	err := r.db.WithContext(ctx).Save(user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -82,8 +97,71 @@ func (r *userRepository) Update(user *models.User) (*models.User, error) {
 	return user, nil
 }
 
-func (r *userRepository) Delete(id int) error {
-	res := r.db.Delete(&models.User{}, id)
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(
+			map[string]interface{}{
+				"email_verified":    true,
+				"email_verified_at": time.Now(),
+			},
+		)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateMFA sets the two-factor state of a user. An empty secret clears it.
+func (r *userRepository) UpdateMFA(
+	ctx context.Context, id int, enabled bool, secret string,
+) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(
+			map[string]interface{}{
+				"mfa_enabled": enabled,
+				"mfa_secret":  secret,
+			},
+		)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdateRole(
+	ctx context.Context, id int, role models.Role,
+) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Update("role", role)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	res := r.db.WithContext(ctx).Delete(&models.User{}, id)
 
 	if res.Error != nil {
 		return res.Error
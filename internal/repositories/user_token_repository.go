@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+)
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	Consume(
+		ctx context.Context, purpose models.TokenPurpose, tokenHash string,
+	) (*models.UserToken, error)
+	DeleteByUserID(
+		ctx context.Context, userID int, purpose models.TokenPurpose,
+	) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(
+	ctx context.Context, token *models.UserToken,
+) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// Consume marks an unused, unexpired token as used and returns it. The
+// check and the update are one statement, so a token cannot be redeemed
+// twice by concurrent requests.
+func (r *userTokenRepository) Consume(
+	ctx context.Context, purpose models.TokenPurpose, tokenHash string,
+) (*models.UserToken, error) {
+	var tokens []models.UserToken
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where(
+			"token_hash = ? AND purpose = ? AND used_at IS NULL "+
+				"AND expires_at > ?",
+			tokenHash, purpose, now,
+		).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || len(tokens) == 0 {
+		return nil, errs.ErrInvalidOneTimeToken
+	}
+
+	return &tokens[0], nil
+}
+
+func (r *userTokenRepository) DeleteByUserID(
+	ctx context.Context, userID int, purpose models.TokenPurpose,
+) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		Delete(&models.UserToken{}).Error
+}
@@ -6,9 +6,10 @@ import (
 
 	"github.com/DaniilKalts/market-rest-api/internal/config"
 	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/tracing"
 )
 
-func initDB() *gorm.DB {
+func InitDB() *gorm.DB {
 	dsn := config.Config.Postgres.DSN
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -16,5 +17,11 @@ func initDB() *gorm.DB {
 		logger.Fatal("Failed to connect to database: " + err.Error())
 	}
 
+	if tracingEnabled() {
+		if err := db.Use(tracing.GormPlugin{}); err != nil {
+			logger.Fatal("Failed to trace the database: " + err.Error())
+		}
+	}
+
 	return db
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/DaniilKalts/market-rest-api/internal/handlers"
 	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
 )
 
 func initHandlers(
@@ -10,18 +11,36 @@ func initHandlers(
 	userService services.UserService,
 	authService services.AuthService,
 	cartService services.CartService,
+	orderService services.OrderService,
+	paymentService services.PaymentService,
+	roleService services.RoleService,
+	apiKeyService services.APIKeyService,
+	paymentProvider payments.PaymentProvider,
 ) (
 	*handlers.ItemHandler,
 	*handlers.UserHandler,
 	*handlers.AuthHandler,
 	*handlers.ProfileHandler,
 	*handlers.CartHandler,
+	*handlers.OrderHandler,
+	*handlers.PaymentHandler,
+	*handlers.SessionHandler,
+	*handlers.RoleHandler,
+	*handlers.APIKeyHandler,
 ) {
 	itemHandler := handlers.NewItemHandler(itemService)
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, authService)
 	authHandler := handlers.NewAuthHandler(authService)
 	profileHandler := handlers.NewProfileHandler(userService, authService)
 	cartHandler := handlers.NewCartHandler(itemService, cartService)
+	orderHandler := handlers.NewOrderHandler(orderService)
 
-	return itemHandler, userHandler, authHandler, profileHandler, cartHandler
+	simulator, _ := paymentProvider.(payments.Simulator)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, simulator)
+
+	sessionHandler := handlers.NewSessionHandler(authService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	return itemHandler, userHandler, authHandler, profileHandler, cartHandler, orderHandler, paymentHandler, sessionHandler, roleHandler, apiKeyHandler
 }
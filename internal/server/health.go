@@ -0,0 +1,25 @@
+package server
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/health"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+// initHealth returns the checker behind /readyz, pinging the Postgres pool
+// of db and the Redis client of tokenStore.
+func initHealth(db *gorm.DB, tokenStore redis.TokenStore) *health.Checker {
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Fatal("Failed to get the database pool: " + err.Error())
+	}
+
+	checker := health.NewChecker(config.Config.Health.CheckTimeout)
+	checker.Add("postgres", sqlDB.PingContext)
+	checker.Add("redis", tokenStore.Ping)
+
+	return checker
+}
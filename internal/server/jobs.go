@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// backgroundJobs runs the periodic jobs of the server until stop is
+// called.
+type backgroundJobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newBackgroundJobs() *backgroundJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundJobs{ctx: ctx, cancel: cancel}
+}
+
+// every runs job each interval in its own goroutine. The context passed to
+// job is cancelled by stop.
+func (j *backgroundJobs) every(
+	interval time.Duration, job func(ctx context.Context),
+) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+				job(j.ctx)
+			}
+		}
+	}()
+}
+
+// stop cancels the running jobs and waits until they returned.
+func (j *backgroundJobs) stop() {
+	j.cancel()
+	j.wg.Wait()
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"os"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/mailer"
+)
+
+func initMailer() mailer.Mailer {
+	cfg := config.Config.Mail
+
+	switch cfg.Driver {
+	case "stdout":
+		return mailer.NewWriterMailer(os.Stdout, cfg.From)
+	case "file":
+		m, err := mailer.NewFileMailer(cfg.FilePath, cfg.From)
+		if err != nil {
+			logger.Fatal("Failed to open the mail file: " + err.Error())
+		}
+		return m
+	case "smtp":
+		return mailer.NewSMTPMailer(
+			cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword,
+			cfg.From,
+		)
+	default:
+		logger.Fatal("Unsupported mail driver: " + cfg.Driver)
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/metrics"
+)
+
+// gaugeTimeout bounds the queries run for the business gauges on every
+// scrape.
+const gaugeTimeout = 5 * time.Second
+
+// initMetrics times the queries of db and registers the metrics served by
+// startMetricsServer: HTTP, query and Redis latencies, the connection pool of db and
+// the business gauges.
+func initMetrics(
+	db *gorm.DB,
+	itemRepo repositories.ItemRepository,
+	cartRepo repositories.CartRepository,
+) {
+	if !config.Config.Metrics.Enabled {
+		return
+	}
+
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		logger.Fatal("Failed to instrument the database: " + err.Error())
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Fatal("Failed to get the database pool: " + err.Error())
+	}
+
+	metrics.Register(
+		collectors.NewDBStatsCollector(sqlDB, "postgres"),
+		metrics.NewGauge(
+			"items_out_of_stock", "Items whose stock is zero.",
+			countGauge("items_out_of_stock", itemRepo.CountOutOfStock),
+		),
+		metrics.NewGauge(
+			"carts_with_items", "Carts holding at least one item.",
+			countGauge("carts_with_items", cartRepo.CountWithItems),
+		),
+	)
+}
+
+// startMetricsServer serves /metrics on METRICS_ADDR, a listener of its
+// own, so that the metrics are not exposed on the API port. It returns nil
+// when metrics are disabled.
+func startMetricsServer() *http.Server {
+	if !config.Config.Metrics.Enabled {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	srv := &http.Server{
+		Addr:              config.Config.Metrics.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Metrics are served on " + srv.Addr + "/metrics")
+		if err := srv.ListenAndServe(); err != nil &&
+			!errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Failed to serve metrics: " + err.Error())
+		}
+	}()
+
+	return srv
+}
+
+// countGauge reads a gauge from count, reporting NaN when the query fails.
+func countGauge(
+	name string, count func(context.Context) (int64, error),
+) func() float64 {
+	return func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), gaugeTimeout)
+		defer cancel()
+
+		n, err := count(ctx)
+		if err != nil {
+			logger.Logger().Warn(
+				"Failed to read a gauge", "gauge", name, "error", err,
+			)
+			return math.NaN()
+		}
+		return float64(n)
+	}
+}
@@ -1,39 +1,61 @@
 package server
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/internal/migrations"
 	"github.com/DaniilKalts/market-rest-api/internal/models"
 	"github.com/DaniilKalts/market-rest-api/pkg/logger"
 )
 
-func migrate(db *gorm.DB) {
-	modelsToMigrate := []interface{}{
-		&models.Item{},
-		&models.User{},
-		&models.Cart{},
-		&models.CartItem{},
+// checkSchema stops the server when the database is missing migrations
+// compiled into this binary. They are applied with `migrate up`.
+func checkSchema(db *gorm.DB) {
+	all, err := migrations.Embedded()
+	if err != nil {
+		logger.Fatal("Failed to load migrations: " + err.Error())
 	}
 
-	if err := db.AutoMigrate(modelsToMigrate...); err != nil {
-		logger.Error("Failed to auto migrate models: " + err.Error())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pending, err := migrations.NewMigrator(db, all).Pending(ctx)
+	if err != nil {
+		logger.Fatal("Failed to check the database schema: " + err.Error())
 	}
+	if len(pending) > 0 {
+		logger.Fatal(
+			fmt.Sprintf(
+				"Database schema is behind by %d migration(s), "+
+					"run `market-rest-api migrate up` first",
+				len(pending),
+			),
+		)
+	}
+}
 
+func seedAdmin(db *gorm.DB) {
 	var admin models.User
 
 	err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			now := time.Now()
 			admin := models.User{
-				FirstName:   config.Config.Admin.FirstName,
-				LastName:    config.Config.Admin.LastName,
-				Email:       config.Config.Admin.Email,
-				Password:    config.Config.Admin.Password,
-				PhoneNumber: config.Config.Admin.PhoneNumber,
-				Role:        models.RoleAdmin,
+				FirstName:       config.Config.Admin.FirstName,
+				LastName:        config.Config.Admin.LastName,
+				Email:           config.Config.Admin.Email,
+				Password:        config.Config.Admin.Password,
+				PhoneNumber:     config.Config.Admin.PhoneNumber,
+				Role:            models.RoleAdmin,
+				EmailVerified:   true,
+				EmailVerifiedAt: &now,
 			}
 			if err := db.Create(&admin).Error; err != nil {
 				logger.Error("Failed to create admin user: " + err.Error())
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
+)
+
+func initPaymentProvider() payments.PaymentProvider {
+	switch config.Config.Payment.Provider {
+	case "fake":
+		logger.Warn("Using the fake payment provider, do not use it in production")
+		return payments.NewFakeProvider(config.Config.Payment.WebhookSecret)
+	default:
+		logger.Fatal(
+			"Unsupported payment provider: " + config.Config.Payment.Provider,
+		)
+		return nil
+	}
+}
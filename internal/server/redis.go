@@ -1,12 +1,39 @@
 package server
 
 import (
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/metrics"
 	"github.com/DaniilKalts/market-rest-api/pkg/redis"
 )
 
-func initRedis() redis.TokenStore {
+// initRedis also returns the client the stores share, for SetupServer to
+// close on shutdown.
+func initRedis() (
+	*goredis.Client, redis.TokenStore, redis.LoginGuard, redis.RateLimiter,
+) {
 	redisClient := redis.NewClient()
+	if config.Config.Metrics.Enabled {
+		redisClient.AddHook(metrics.RedisHook{})
+	}
 	tokenStore := redis.NewTokenStore(redisClient)
+	if tracingEnabled() {
+		tokenStore = redis.NewTracedTokenStore(tokenStore)
+	}
+	loginGuard := redis.NewLoginGuard(
+		redisClient, redis.LoginGuardOptions{
+			FailureWindow:   config.Config.Login.FailureWindow,
+			BackoffAfter:    int64(config.Config.Login.BackoffAfter),
+			IPBackoffAfter:  int64(config.Config.Login.IPBackoffAfter),
+			BackoffBase:     config.Config.Login.BackoffBase,
+			BackoffMax:      config.Config.Login.BackoffMax,
+			LockoutAfter:    int64(config.Config.Login.LockoutAfter),
+			LockoutDuration: config.Config.Login.LockoutDuration,
+		},
+	)
+
+	rateLimiter := redis.NewRateLimiter(redisClient)
 
-	return tokenStore
+	return redisClient, tokenStore, loginGuard, rateLimiter
 }
@@ -9,10 +9,22 @@ func initRepositories(db *gorm.DB) (
 	repositories.ItemRepository,
 	repositories.UserRepository,
 	repositories.CartRepository,
+	repositories.OrderRepository,
+	repositories.PaymentRepository,
+	repositories.ReservationRepository,
+	repositories.RoleRepository,
+	repositories.APIKeyRepository,
+	repositories.UnitOfWork,
 ) {
 	itemRepo := repositories.NewItemRepository(db)
 	userRepo := repositories.NewUserRepository(db)
 	cartRepo := repositories.NewCartRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+	reservationRepo := repositories.NewReservationRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	unitOfWork := repositories.NewUnitOfWork(db)
 
-	return itemRepo, userRepo, cartRepo
+	return itemRepo, userRepo, cartRepo, orderRepo, paymentRepo, reservationRepo, roleRepo, apiKeyRepo, unitOfWork
 }
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+// startReservationJanitor periodically deletes expired stock reservations.
+// Expired rows never count against availability, so this only keeps the
+// table small.
+func startReservationJanitor(
+	jobs *backgroundJobs, repo repositories.ReservationRepository,
+) {
+	interval := config.Config.Cart.ReservationTTL
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+
+	jobs.every(interval, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		deleted, err := repo.DeleteExpired(ctx)
+		cancel()
+		if err != nil {
+			logger.Error("Failed to purge expired reservations: " + err.Error())
+			return
+		}
+		if deleted > 0 {
+			logger.Info(
+				"Purged " + strconv.FormatInt(deleted, 10) +
+					" expired stock reservation(s)",
+			)
+		}
+	})
+}
@@ -6,9 +6,12 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/DaniilKalts/market-rest-api/internal/config"
 	"github.com/DaniilKalts/market-rest-api/internal/handlers"
 	"github.com/DaniilKalts/market-rest-api/internal/middlewares"
 	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
 )
 
 func setupRouter(
@@ -17,15 +20,82 @@ func setupRouter(
 	authHandler *handlers.AuthHandler,
 	profileHandler *handlers.ProfileHandler,
 	cartHandler *handlers.CartHandler,
+	orderHandler *handlers.OrderHandler,
+	paymentHandler *handlers.PaymentHandler,
+	sessionHandler *handlers.SessionHandler,
+	roleHandler *handlers.RoleHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	healthHandler *handlers.HealthHandler,
+	apiKeys middlewares.APIKeyAuthenticator,
+	tokenStore redis.TokenStore,
+	rateLimiter redis.RateLimiter,
 ) *gin.Engine {
-	router := gin.Default()
-	tokenStore := initRedis()
-	router.Use(middlewares.LoggerMiddleware())
+	router := gin.New()
+	// Without trusted proxies every client could pick its own address,
+	// and so a fresh rate limit and login backoff, through X-Forwarded-For.
+	if err := router.SetTrustedProxies(
+		config.Config.Server.TrustedProxies,
+	); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES: " + err.Error())
+	}
+	requireAdminMFA := config.Config.Auth.AdminRequireMFA
+	requirePermission := func(
+		permissions ...models.Permission,
+	) gin.HandlerFunc {
+		return middlewares.RequirePermission(requireAdminMFA, permissions...)
+	}
+	rateLimit := func(
+		name string, limit config.RateLimit, by middlewares.RateLimitKey,
+	) gin.HandlerFunc {
+		if !config.Config.RateLimit.Enabled {
+			return func(ctx *gin.Context) { ctx.Next() }
+		}
+		return middlewares.RateLimitMiddleware(
+			rateLimiter, middlewares.RateLimitPolicy{
+				Name:   name,
+				Limit:  limit.Limit,
+				Window: limit.Window,
+				By:     by,
+			},
+		)
+	}
+	publicRateLimit := rateLimit(
+		"public", config.Config.RateLimit.Public, middlewares.RateLimitByIP,
+	)
+	authRateLimit := rateLimit(
+		"auth", config.Config.RateLimit.Auth, middlewares.RateLimitByIP,
+	)
+	userRateLimit := rateLimit(
+		"user", config.Config.RateLimit.User, middlewares.RateLimitByUser,
+	)
+	adminRateLimit := rateLimit(
+		"admin", config.Config.RateLimit.Admin, middlewares.RateLimitByAPIKey,
+	)
+	router.Use(
+		middlewares.RequestIDMiddleware(),
+		middlewares.TracingMiddleware(),
+		middlewares.LoggerMiddleware(),
+	)
+	if config.Config.Metrics.Enabled {
+		router.Use(middlewares.MetricsMiddleware())
+	}
+	router.Use(
+		middlewares.RecoveryMiddleware(),
+		middlewares.TimeoutMiddleware(config.Config.Server.RequestTimeout),
+	)
+
+	router.GET("/healthz", healthHandler.HandleLiveness)
+	router.GET("/readyz", healthHandler.HandleReadiness)
 
 	api := router.Group("/api")
 
 	itemPublicRoutes := api.Group("/items")
+	itemPublicRoutes.Use(publicRateLimit)
 	{
+		itemPublicRoutes.GET(
+			"/search",
+			itemHandler.HandleSearchItems,
+		)
 		itemPublicRoutes.GET(
 			"/:id",
 			itemHandler.HandleGetItemByID,
@@ -38,57 +108,70 @@ func setupRouter(
 
 	itemPrivateRoutes := api.Group("/items")
 	itemPrivateRoutes.Use(
+		middlewares.APIKeyMiddleware(apiKeys),
 		middlewares.JWTMiddleware(),
 		middlewares.TokenStoreMiddleware(tokenStore),
+		adminRateLimit,
 	)
 	{
 		itemPrivateRoutes.POST(
 			"",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionItemsWrite),
 			middlewares.BindBodyMiddleware(&models.Item{}),
 			itemHandler.HandleCreateItem,
 		)
 		itemPrivateRoutes.PUT(
 			"/:id",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionItemsWrite),
 			middlewares.BindBodyMiddleware(&models.UpdateItem{}),
 			itemHandler.HandleUpdateItem,
 		)
 		itemPrivateRoutes.DELETE(
 			"/:id",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionItemsWrite),
 			itemHandler.HandleDeleteItem,
 		)
 	}
 
 	userRoutes := api.Group("/users")
-	userRoutes.Use(
-		middlewares.JWTMiddleware(),
-		middlewares.TokenStoreMiddleware(tokenStore),
-	)
 	{
-		userRoutes.GET(
+		// API keys are accepted here but not on /me, which acts on the
+		// key's creator.
+		userAdminRoutes := userRoutes.Group("")
+		userAdminRoutes.Use(
+			middlewares.APIKeyMiddleware(apiKeys),
+			middlewares.JWTMiddleware(),
+			middlewares.TokenStoreMiddleware(tokenStore),
+			adminRateLimit,
+		)
+		userAdminRoutes.GET(
 			"/:id",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionUsersRead),
 			userHandler.HandleGetUserByID,
 		)
-		userRoutes.GET(
+		userAdminRoutes.GET(
 			"",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionUsersRead),
 			userHandler.HandleGetAllUsers,
 		)
-		userRoutes.PUT(
+		userAdminRoutes.PUT(
 			"/:id",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionUsersWrite),
 			middlewares.BindBodyMiddleware(&models.UpdateUser{}),
 			userHandler.HandleUpdateUserByID,
 		)
-		userRoutes.DELETE(
+		userAdminRoutes.DELETE(
 			"/:id",
-			middlewares.AdminMiddleware(),
+			requirePermission(models.PermissionUsersWrite),
 			userHandler.HandleDeleteUser,
 		)
+
 		profileRoutes := userRoutes.Group("/me")
+		profileRoutes.Use(
+			middlewares.JWTMiddleware(),
+			middlewares.TokenStoreMiddleware(tokenStore),
+			userRateLimit,
+		)
 		{
 			profileRoutes.GET(
 				"",
@@ -103,10 +186,37 @@ func setupRouter(
 				"",
 				profileHandler.HandleDeleteProfile,
 			)
+			profileRoutes.GET(
+				"/sessions",
+				sessionHandler.HandleListSessions,
+			)
+			profileRoutes.DELETE(
+				"/sessions",
+				sessionHandler.HandleRevokeAllSessions,
+			)
+			profileRoutes.DELETE(
+				"/sessions/:id",
+				sessionHandler.HandleRevokeSession,
+			)
+			profileRoutes.POST(
+				"/mfa/enroll",
+				profileHandler.HandleEnrollMFA,
+			)
+			profileRoutes.POST(
+				"/mfa/confirm",
+				middlewares.BindBodyMiddleware(&models.MFACode{}),
+				profileHandler.HandleConfirmMFA,
+			)
+			profileRoutes.POST(
+				"/mfa/disable",
+				middlewares.BindBodyMiddleware(&models.MFACode{}),
+				profileHandler.HandleDisableMFA,
+			)
 		}
 	}
 
 	authRoutes := api.Group("/auth")
+	authRoutes.Use(authRateLimit)
 	{
 		authRoutes.POST(
 			"/register",
@@ -118,19 +228,52 @@ func setupRouter(
 			middlewares.BindBodyMiddleware(&models.LoginUser{}),
 			authHandler.HandleLogin,
 		)
+		authRoutes.POST(
+			"/login/mfa",
+			middlewares.BindBodyMiddleware(&models.MFALogin{}),
+			authHandler.HandleLoginMFA,
+		)
 		authRoutes.POST(
 			"/logout",
+			middlewares.JWTMiddleware(),
 			authHandler.HandleLogout,
 		)
 		authRoutes.POST(
 			"/refresh",
 			authHandler.HandleRefreshToken,
 		)
+		authRoutes.POST(
+			"/verify-email",
+			middlewares.BindBodyMiddleware(&models.VerifyEmail{}),
+			authHandler.HandleVerifyEmail,
+		)
+		authRoutes.POST(
+			"/verify-email/resend",
+			middlewares.BindBodyMiddleware(&models.ResendVerification{}),
+			authHandler.HandleResendVerification,
+		)
+		authRoutes.POST(
+			"/password/forgot",
+			middlewares.BindBodyMiddleware(&models.ForgotPassword{}),
+			authHandler.HandleForgotPassword,
+		)
+		authRoutes.POST(
+			"/password/reset",
+			middlewares.BindBodyMiddleware(&models.ResetPassword{}),
+			authHandler.HandleResetPassword,
+		)
+		authRoutes.POST(
+			"/unlock",
+			middlewares.BindBodyMiddleware(&models.UnlockAccount{}),
+			authHandler.HandleUnlockAccount,
+		)
 	}
 
 	cartRoutes := api.Group("/cart")
 	cartRoutes.Use(
 		middlewares.JWTMiddleware(),
+		middlewares.TokenStoreMiddleware(tokenStore),
+		userRateLimit,
 	)
 	{
 		cartRoutes.GET(
@@ -154,8 +297,147 @@ func setupRouter(
 			"/items",
 			cartHandler.HandleClearCart,
 		)
+		cartRoutes.POST(
+			"/checkout",
+			orderHandler.HandleCheckout,
+		)
+	}
+
+	orderRoutes := api.Group("/orders")
+	orderRoutes.Use(
+		middlewares.JWTMiddleware(),
+		middlewares.TokenStoreMiddleware(tokenStore),
+		userRateLimit,
+	)
+	{
+		orderRoutes.GET(
+			"",
+			orderHandler.HandleGetOrders,
+		)
+		orderRoutes.GET(
+			"/:id",
+			orderHandler.HandleGetOrderByID,
+		)
+		orderRoutes.POST(
+			"/:id/cancel",
+			orderHandler.HandleCancelOrder,
+		)
+		orderRoutes.POST(
+			"/:id/pay",
+			paymentHandler.HandleCreatePayment,
+		)
+	}
+
+	paymentRoutes := api.Group("/payments")
+	{
+		paymentRoutes.POST(
+			"/webhook",
+			paymentHandler.HandleWebhook,
+		)
 	}
 
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(
+		middlewares.APIKeyMiddleware(apiKeys),
+		middlewares.JWTMiddleware(),
+		middlewares.TokenStoreMiddleware(tokenStore),
+		adminRateLimit,
+	)
+	{
+		adminRoutes.PATCH(
+			"/orders/:id/status",
+			requirePermission(models.PermissionOrdersWrite),
+			middlewares.BindBodyMiddleware(&models.UpdateOrderStatus{}),
+			orderHandler.HandleUpdateOrderStatus,
+		)
+		adminRoutes.POST(
+			"/orders/:id/refund",
+			requirePermission(models.PermissionOrdersRefund),
+			paymentHandler.HandleRefundOrder,
+		)
+		// Marks payments paid without any money moving, so it is only
+		// served when explicitly enabled for development.
+		if config.Config.Payment.SimulateEnabled {
+			adminRoutes.POST(
+				"/payments/fake/:intent_id/:outcome",
+				requirePermission(models.PermissionOrdersWrite),
+				paymentHandler.HandleSimulatePayment,
+			)
+		}
+		adminRoutes.DELETE(
+			"/users/:id/sessions",
+			requirePermission(models.PermissionSessionsRevoke),
+			sessionHandler.HandleRevokeUserSessions,
+		)
+		adminRoutes.GET(
+			"/users/:id/lockout",
+			requirePermission(models.PermissionUsersRead),
+			authHandler.HandleGetLoginLockout,
+		)
+		adminRoutes.DELETE(
+			"/users/:id/lockout",
+			requirePermission(models.PermissionUsersWrite),
+			authHandler.HandleUnlockUser,
+		)
+		adminRoutes.PUT(
+			"/users/:id/role",
+			requirePermission(models.PermissionRolesManage),
+			middlewares.BindBodyMiddleware(&models.AssignRole{}),
+			roleHandler.HandleAssignRole,
+		)
+
+		roleRoutes := adminRoutes.Group("")
+		roleRoutes.Use(requirePermission(models.PermissionRolesManage))
+		{
+			roleRoutes.GET(
+				"/permissions",
+				roleHandler.HandleListPermissions,
+			)
+			roleRoutes.GET(
+				"/roles",
+				roleHandler.HandleListRoles,
+			)
+			roleRoutes.GET(
+				"/roles/:id",
+				roleHandler.HandleGetRole,
+			)
+			roleRoutes.POST(
+				"/roles",
+				middlewares.BindBodyMiddleware(&models.CreateRole{}),
+				roleHandler.HandleCreateRole,
+			)
+			roleRoutes.PATCH(
+				"/roles/:id",
+				middlewares.BindBodyMiddleware(&models.UpdateRole{}),
+				roleHandler.HandleUpdateRole,
+			)
+			roleRoutes.DELETE(
+				"/roles/:id",
+				roleHandler.HandleDeleteRole,
+			)
+		}
+
+		apiKeyRoutes := adminRoutes.Group("/api-keys")
+		apiKeyRoutes.Use(requirePermission(models.PermissionAPIKeysManage))
+		{
+			apiKeyRoutes.GET(
+				"",
+				apiKeyHandler.HandleListAPIKeys,
+			)
+			apiKeyRoutes.POST(
+				"",
+				middlewares.BindBodyMiddleware(&models.CreateAPIKey{}),
+				apiKeyHandler.HandleCreateAPIKey,
+			)
+			apiKeyRoutes.DELETE(
+				"/:id",
+				apiKeyHandler.HandleRevokeAPIKey,
+			)
+		}
+	}
+
+	router.GET("/.well-known/jwks.json", authHandler.HandleJWKS)
+
 	router.Static("/api/docs", "./docs")
 	router.GET(
 		"/api/swagger/*any",
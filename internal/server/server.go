@@ -1,30 +1,61 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/internal/handlers"
+	"github.com/DaniilKalts/market-rest-api/pkg/health"
 )
 
-func SetupServer() *http.Server {
-	db := initDB()
-	migrate(db)
+// SetupServer also returns the readiness checker, for main to mark the
+// server as shutting down, and a function for main to call once the server
+// stopped, which stops the background jobs and the metrics listener and
+// closes the database pool and the Redis client.
+func SetupServer() (*http.Server, *health.Checker, func() error) {
+	jobs := newBackgroundJobs()
 
-	tokenStore := initRedis()
+	db := InitDB()
+	checkSchema(db)
+	seedAdmin(db)
+	initSigningKeys(jobs)
 
-	itemRepository, userRepository, cartRepository := initRepositories(db)
-	itemService, userService, authService, cartService := initServices(
+	redisClient, tokenStore, loginGuard, rateLimiter := initRedis()
+	paymentProvider := initPaymentProvider()
+	mailer := initMailer()
+
+	itemRepository, userRepository, cartRepository, orderRepository, paymentRepository, reservationRepository, roleRepository, apiKeyRepository, unitOfWork := initRepositories(db)
+	startReservationJanitor(jobs, reservationRepository)
+	initMetrics(db, itemRepository, cartRepository)
+	metricsServer := startMetricsServer()
+	healthChecker := initHealth(db, tokenStore)
+
+	itemService, userService, authService, cartService, orderService, paymentService, roleService, apiKeyService := initServices(
 		itemRepository,
 		userRepository,
 		cartRepository,
+		orderRepository,
+		paymentRepository,
+		roleRepository,
+		apiKeyRepository,
+		unitOfWork,
 		tokenStore,
+		loginGuard,
+		paymentProvider,
+		mailer,
 	)
-	itemHandler, userHandler, authHandler, profileHandler, cartHandler := initHandlers(
+	itemHandler, userHandler, authHandler, profileHandler, cartHandler, orderHandler, paymentHandler, sessionHandler, roleHandler, apiKeyHandler := initHandlers(
 		itemService,
 		userService,
 		authService,
 		cartService,
+		orderService,
+		paymentService,
+		roleService,
+		apiKeyService,
+		paymentProvider,
 	)
 
 	router := setupRouter(
@@ -33,6 +64,15 @@ func SetupServer() *http.Server {
 		authHandler,
 		profileHandler,
 		cartHandler,
+		orderHandler,
+		paymentHandler,
+		sessionHandler,
+		roleHandler,
+		apiKeyHandler,
+		handlers.NewHealthHandler(healthChecker),
+		apiKeyService,
+		tokenStore,
+		rateLimiter,
 	)
 
 	srv := &http.Server{
@@ -41,5 +81,20 @@ func SetupServer() *http.Server {
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	return srv
+	closeServer := func() error {
+		jobs.stop()
+
+		var metricsErr error
+		if metricsServer != nil {
+			metricsErr = metricsServer.Close()
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			return errors.Join(metricsErr, err, redisClient.Close())
+		}
+		return errors.Join(metricsErr, sqlDB.Close(), redisClient.Close())
+	}
+
+	return srv, healthChecker, closeServer
 }
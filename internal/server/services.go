@@ -1,8 +1,12 @@
 package server
 
 import (
+	"github.com/DaniilKalts/market-rest-api/internal/config"
 	"github.com/DaniilKalts/market-rest-api/internal/repositories"
 	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/audit"
+	"github.com/DaniilKalts/market-rest-api/pkg/mailer"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
 	"github.com/DaniilKalts/market-rest-api/pkg/redis"
 )
 
@@ -10,17 +14,82 @@ func initServices(
 	itemRepo repositories.ItemRepository,
 	userRepo repositories.UserRepository,
 	cartRepo repositories.CartRepository,
+	orderRepo repositories.OrderRepository,
+	paymentRepo repositories.PaymentRepository,
+	roleRepo repositories.RoleRepository,
+	apiKeyRepo repositories.APIKeyRepository,
+	unitOfWork repositories.UnitOfWork,
 	tokenStore redis.TokenStore,
+	loginGuard redis.LoginGuard,
+	paymentProvider payments.PaymentProvider,
+	mailer mailer.Mailer,
 ) (
 	services.ItemService,
 	services.UserService,
 	services.AuthService,
 	services.CartService,
+	services.OrderService,
+	services.PaymentService,
+	services.RoleService,
+	services.APIKeyService,
 ) {
 	itemService := services.NewItemService(itemRepo)
+	if tracingEnabled() {
+		// Wrapped first so the calls of the cart service to it are traced
+		// too.
+		itemService = services.NewTracedItemService(itemService)
+	}
 	userService := services.NewUserService(userRepo)
-	authService := services.NewAuthService(userRepo, tokenStore)
-	cartService := services.NewCartService(cartRepo, itemService)
+	authService := services.NewAuthService(
+		userRepo,
+		roleRepo,
+		unitOfWork,
+		tokenStore,
+		loginGuard,
+		mailer,
+		audit.NewLogRecorder(),
+		services.AuthOptions{
+			RequireVerifiedLogin: config.Config.Auth.RequireVerifiedEmail ==
+				config.RequireVerifiedLogin,
+			VerificationTTL:  config.Config.Auth.VerificationTTL,
+			VerificationURL:  config.Config.Auth.VerificationURL,
+			PasswordResetTTL: config.Config.Auth.PasswordResetTTL,
+			PasswordResetURL: config.Config.Auth.PasswordResetURL,
+			MFAIssuer:        config.Config.Auth.MFAIssuer,
+			MFAChallengeTTL:  config.Config.Auth.MFAChallengeTTL,
+			UnlockTTL:        config.Config.Login.LockoutDuration,
+			UnlockURL:        config.Config.Login.UnlockURL,
+		},
+	)
+	cartService := services.NewCartService(
+		cartRepo, itemService, config.Config.Cart.ReservationTTL,
+	)
+	orderService := services.NewOrderService(
+		orderRepo,
+		userRepo,
+		config.Config.Auth.RequireVerifiedEmail ==
+			config.RequireVerifiedCheckout,
+	)
+	paymentService := services.NewPaymentService(
+		paymentRepo,
+		orderRepo,
+		unitOfWork,
+		paymentProvider,
+		config.Config.Payment.Currency,
+	)
 
-	return itemService, userService, authService, cartService
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, roleRepo)
+
+	if tracingEnabled() {
+		userService = services.NewTracedUserService(userService)
+		authService = services.NewTracedAuthService(authService)
+		cartService = services.NewTracedCartService(cartService)
+		orderService = services.NewTracedOrderService(orderService)
+		paymentService = services.NewTracedPaymentService(paymentService)
+		roleService = services.NewTracedRoleService(roleService)
+		apiKeyService = services.NewTracedAPIKeyService(apiKeyService)
+	}
+
+	return itemService, userService, authService, cartService, orderService, paymentService, roleService, apiKeyService
 }
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+// keyRotationCheckInterval is how often the key ring looks for a due
+// rotation and for keys added by other instances.
+const keyRotationCheckInterval = time.Hour
+
+func initSigningKeys(jobs *backgroundJobs) {
+	cfg := config.Config.JWT
+	if cfg.Algorithm == config.JWTAlgorithmHS256 {
+		return
+	}
+
+	ring, err := jwt.NewKeyRing(
+		cfg.KeysDir, cfg.Algorithm, cfg.KeyRotation, cfg.KeyRetention,
+	)
+	if err != nil {
+		logger.Fatal("Failed to load JWT signing keys: " + err.Error())
+	}
+	jwt.UseKeyRing(ring, cfg.HS256MigrationUntil)
+
+	jobs.every(keyRotationCheckInterval, func(context.Context) {
+		if err := ring.Rotate(); err != nil {
+			logger.Error("Failed to rotate JWT signing keys: " + err.Error())
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"os"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/tracing"
+)
+
+// InitTracing sets up the trace exporter chosen in the config. Call the
+// returned function on shutdown to flush the spans still buffered.
+func InitTracing() func(context.Context) error {
+	shutdown, err := tracing.Setup(
+		context.Background(), tracing.Options{
+			Exporter:     config.Config.Tracing.Exporter,
+			ServiceName:  config.Config.Tracing.ServiceName,
+			OTLPEndpoint: config.Config.Tracing.OTLPEndpoint,
+			SampleRatio:  config.Config.Tracing.SampleRatio,
+			Stdout:       os.Stdout,
+		},
+	)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing: " + err.Error())
+	}
+
+	return shutdown
+}
+
+// tracingEnabled reports whether spans are exported, and so worth
+// recording.
+func tracingEnabled() bool {
+	return config.Config.Tracing.Exporter != config.TracingExporterNone
+}
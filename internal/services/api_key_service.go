@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+// lastUsedResolution limits how often a busy key's last_used_at is
+// written.
+const lastUsedResolution = time.Minute
+
+// APIKeyService manages the API keys machine clients authenticate with.
+type APIKeyService interface {
+	// CreateAPIKey returns the new key and its plaintext, which is not
+	// stored. granted are the permissions of the admin creating it; the
+	// key cannot be given any other.
+	CreateAPIKey(
+		ctx context.Context, createdBy int, granted []string,
+		req *models.CreateAPIKey,
+	) (*models.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// Authenticate returns the active key matching the plaintext. Its
+	// scopes are narrowed to the permissions the role of its creator
+	// grants now, so demoting the creator demotes their keys too.
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo     repositories.APIKeyRepository
+	userRepo repositories.UserRepository
+	roles    repositories.RoleRepository
+}
+
+func NewAPIKeyService(
+	repo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	roles repositories.RoleRepository,
+) APIKeyService {
+	return &apiKeyService{repo: repo, userRepo: userRepo, roles: roles}
+}
+
+func (s *apiKeyService) CreateAPIKey(
+	ctx context.Context, createdBy int, granted []string,
+	req *models.CreateAPIKey,
+) (*models.APIKey, string, error) {
+	held := make(map[string]bool, len(granted))
+	for _, p := range granted {
+		held[p] = true
+	}
+	for _, scope := range req.Scopes {
+		if !held[string(scope)] {
+			return nil, "", errs.ErrScopeNotHeld
+		}
+	}
+
+	plaintext, prefix, hash, err := jwt.GenerateAPIKey()
+	if err != nil {
+		return nil, "", errs.ErrTokenGeneration
+	}
+
+	key := &models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+	key.SetPermissions(req.Scopes)
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(
+	ctx context.Context,
+) ([]models.APIKey, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.repo.Revoke(ctx, id, time.Now())
+}
+
+func (s *apiKeyService) Authenticate(
+	ctx context.Context, plaintext string,
+) (*models.APIKey, error) {
+	key, err := s.repo.GetByHash(ctx, jwt.HashOneTimeToken(plaintext))
+	if err != nil {
+		if errors.Is(err, errs.ErrAPIKeyNotFound) {
+			return nil, errs.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, errs.ErrInvalidAPIKey
+	}
+
+	creator, err := s.userRepo.GetByID(ctx, key.CreatedBy)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return nil, errs.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	grants, err := roleGrants(ctx, s.roles, creator.Role)
+	if err != nil {
+		return nil, err
+	}
+	granted := make(map[models.Permission]bool, len(grants))
+	for _, p := range grants {
+		granted[p] = true
+	}
+	var scopes []models.Permission
+	for _, p := range key.Permissions() {
+		if granted[p] {
+			scopes = append(scopes, p)
+		}
+	}
+	key.SetPermissions(scopes)
+
+	if key.LastUsedAt == nil ||
+		now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			logger.FromContext(ctx).Warn(
+				"Failed to record the use of an API key",
+				"api_key_id", key.ID, "error", err,
+			)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,200 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/mocks"
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// newAPIKeyService returns a service whose keys were created by user 1,
+// holding creatorRole.
+func newAPIKeyService(
+	creatorRole models.Role, grants ...models.Permission,
+) (services.APIKeyService, *mocks.APIKeyRepository) {
+	repo := new(mocks.APIKeyRepository)
+	userRepo := new(mocks.UserRepository)
+	userRepo.
+		On("GetByID", mock.Anything, 1).
+		Return(&models.User{ID: 1, Role: creatorRole}, nil).
+		Maybe()
+
+	return services.NewAPIKeyService(
+		repo, userRepo, rolesGranting(creatorRole, grants...),
+	), repo
+}
+
+func warehouseKey() *models.APIKey {
+	key := &models.APIKey{ID: 1, Prefix: "mk_3f9a1c2e", CreatedBy: 1}
+	key.SetPermissions([]models.Permission{models.PermissionOrdersWrite})
+	return key
+}
+
+func TestCreateAPIKey_ScopeNotHeld(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	_, _, err := svc.CreateAPIKey(
+		ctx, 1, []string{string(models.PermissionOrdersWrite)},
+		&models.CreateAPIKey{
+			Name:   "Warehouse sync",
+			Scopes: []models.Permission{models.PermissionOrdersRefund},
+		},
+	)
+	assert.Equal(t, errs.ErrScopeNotHeld, err)
+
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateAPIKey_Success(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	var stored *models.APIKey
+	repo.
+		On("Create", mock.Anything, mock.AnythingOfType("*models.APIKey")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.APIKey)
+		}).
+		Return(nil)
+
+	key, plaintext, err := svc.CreateAPIKey(
+		ctx, 1, []string{string(models.PermissionOrdersWrite)},
+		&models.CreateAPIKey{
+			Name:   "Warehouse sync",
+			Scopes: []models.Permission{models.PermissionOrdersWrite},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Same(t, stored, key)
+	assert.Equal(t, 1, key.CreatedBy)
+	assert.True(t, len(plaintext) > len(key.Prefix))
+	assert.Equal(t, key.Prefix+"_", plaintext[:len(key.Prefix)+1])
+	assert.Equal(t, jwt.HashOneTimeToken(plaintext), key.KeyHash)
+	assert.Equal(
+		t, []models.Permission{models.PermissionOrdersWrite},
+		key.Permissions(),
+	)
+}
+
+func TestAuthenticate_UnknownKey(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	repo.
+		On("GetByHash", mock.Anything, jwt.HashOneTimeToken("mk_x_y")).
+		Return(nil, errs.ErrAPIKeyNotFound)
+
+	_, err := svc.Authenticate(ctx, "mk_x_y")
+	assert.Equal(t, errs.ErrInvalidAPIKey, err)
+}
+
+func TestAuthenticate_Revoked(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	key := warehouseKey()
+	revokedAt := time.Now().Add(-time.Hour)
+	key.RevokedAt = &revokedAt
+	repo.On("GetByHash", mock.Anything, mock.Anything).Return(key, nil)
+
+	_, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	assert.Equal(t, errs.ErrInvalidAPIKey, err)
+
+	repo.AssertNotCalled(
+		t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+func TestAuthenticate_Expired(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	key := warehouseKey()
+	expiresAt := time.Now().Add(-time.Minute)
+	key.ExpiresAt = &expiresAt
+	repo.On("GetByHash", mock.Anything, mock.Anything).Return(key, nil)
+
+	_, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	assert.Equal(t, errs.ErrInvalidAPIKey, err)
+}
+
+func TestAuthenticate_RecordsUse(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	repo.
+		On("GetByHash", mock.Anything, mock.Anything).
+		Return(warehouseKey(), nil)
+	repo.
+		On("TouchLastUsed", mock.Anything, 1, mock.AnythingOfType("time.Time")).
+		Return(nil)
+
+	key, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	require.NoError(t, err)
+	assert.NotNil(t, key.LastUsedAt)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuthenticate_RecentlyUsed(t *testing.T) {
+	svc, repo := newAPIKeyService(models.RoleAdmin)
+
+	key := warehouseKey()
+	lastUsedAt := time.Now().Add(-10 * time.Second)
+	key.LastUsedAt = &lastUsedAt
+	repo.On("GetByHash", mock.Anything, mock.Anything).Return(key, nil)
+
+	_, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	require.NoError(t, err)
+
+	repo.AssertNotCalled(
+		t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+func TestAuthenticate_CreatorDemoted(t *testing.T) {
+	svc, repo := newAPIKeyService(
+		"support", models.PermissionItemsWrite,
+	)
+
+	key := warehouseKey()
+	key.SetPermissions(
+		[]models.Permission{
+			models.PermissionItemsWrite, models.PermissionOrdersWrite,
+		},
+	)
+	repo.On("GetByHash", mock.Anything, mock.Anything).Return(key, nil)
+	repo.
+		On("TouchLastUsed", mock.Anything, 1, mock.AnythingOfType("time.Time")).
+		Return(nil)
+
+	key, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	require.NoError(t, err)
+	assert.Equal(
+		t, []models.Permission{models.PermissionItemsWrite},
+		key.Permissions(),
+	)
+}
+
+func TestAuthenticate_CreatorDeleted(t *testing.T) {
+	repo := new(mocks.APIKeyRepository)
+	userRepo := new(mocks.UserRepository)
+	svc := services.NewAPIKeyService(
+		repo, userRepo, rolesGranting(models.RoleUser),
+	)
+
+	repo.
+		On("GetByHash", mock.Anything, mock.Anything).
+		Return(warehouseKey(), nil)
+	userRepo.
+		On("GetByID", mock.Anything, 1).
+		Return(nil, errs.ErrUserNotFound)
+
+	_, err := svc.Authenticate(ctx, "mk_3f9a1c2e_secret")
+	assert.Equal(t, errs.ErrInvalidAPIKey, err)
+}
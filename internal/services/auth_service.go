@@ -1,54 +1,173 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"fmt"
+	"net/url"
 	"strconv"
+	"sync"
+	"time"
 
 	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
 
 	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/pkg/audit"
 	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+	"github.com/DaniilKalts/market-rest-api/pkg/mailer"
 	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+	"github.com/DaniilKalts/market-rest-api/pkg/totp"
 )
 
+// recoveryCodeCount is how many recovery codes ConfirmMFA hands out.
+const recoveryCodeCount = 10
+
 type AuthService interface {
-	RegisterUser(user *models.RegisterUser) (string, string, error)
-	LoginUser(email, password string) (string, string, error)
-	LogoutUser(accessToken, refreshToken string) error
-	RefreshTokens(refreshToken string) (string, string, error)
+	RegisterUser(
+		ctx context.Context,
+		user *models.RegisterUser,
+		client models.ClientInfo,
+	) (string, string, error)
+	LoginUser(
+		ctx context.Context, email, password string, client models.ClientInfo,
+	) (*LoginResult, error)
+	LoginMFA(
+		ctx context.Context, mfaToken, code string, client models.ClientInfo,
+	) (string, string, error)
+	LogoutUser(ctx context.Context, accessToken, refreshToken string) error
+	RefreshTokens(
+		ctx context.Context, refreshToken string,
+	) (string, string, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	UnlockAccount(ctx context.Context, token string) error
+	LoginLockout(ctx context.Context, userID int) (*models.LoginLockout, error)
+	UnlockUser(ctx context.Context, userID int) error
+	EnrollMFA(ctx context.Context, userID int) (*models.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID int, code string) ([]string, error)
+	DisableMFA(ctx context.Context, userID int, code string) error
+	ListSessions(ctx context.Context, userID int) ([]redis.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int) error
+}
+
+// LoginResult holds either a token pair or, for accounts with two-factor
+// authentication, the challenge token to finish the login with.
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
+type AuthOptions struct {
+	// RequireVerifiedLogin refuses logins, and tokens on registration,
+	// until the email address is verified.
+	RequireVerifiedLogin bool
+	VerificationTTL      time.Duration
+	// VerificationURL is the page the emailed token is appended to.
+	VerificationURL  string
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the page the emailed reset token is appended to.
+	PasswordResetURL string
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+	// UnlockTTL is how long the link mailed on lockout works.
+	UnlockTTL time.Duration
+	// UnlockURL is the page the emailed unlock token is appended to.
+	UnlockURL string
 }
 
 type authService struct {
 	repo       repositories.UserRepository
+	roles      repositories.RoleRepository
+	uow        repositories.UnitOfWork
 	tokenStore redis.TokenStore
+	loginGuard redis.LoginGuard
+	mailer     mailer.Mailer
+	auditor    audit.Recorder
+	opts       AuthOptions
 }
 
 func NewAuthService(
-	repo repositories.UserRepository, tokenStore redis.TokenStore,
+	repo repositories.UserRepository,
+	roles repositories.RoleRepository,
+	uow repositories.UnitOfWork,
+	tokenStore redis.TokenStore,
+	loginGuard redis.LoginGuard,
+	mailer mailer.Mailer,
+	auditor audit.Recorder,
+	opts AuthOptions,
 ) AuthService {
 	return &authService{
 		repo:       repo,
+		roles:      roles,
+		uow:        uow,
 		tokenStore: tokenStore,
+		loginGuard: loginGuard,
+		mailer:     mailer,
+		auditor:    auditor,
+		opts:       opts,
 	}
 }
 
-func (s *authService) generateAndStoreTokens(userID int, role string) (
-	string, string, error,
-) {
-	uidStr := strconv.Itoa(userID)
-	accessToken, err := jwt.GenerateJWT(uidStr, 15, role)
+// openSession starts a session for the user and issues its first token
+// pair.
+func (s *authService) openSession(
+	ctx context.Context,
+	user *models.User,
+	mfa bool,
+	client models.ClientInfo,
+) (string, string, error) {
+	sessionID, err := redis.NewSessionID()
 	if err != nil {
 		return "", "", errs.ErrTokenGeneration
 	}
 
-	refreshToken, err := jwt.GenerateJWT(uidStr, 1440, role)
+	return s.issueTokens(
+		ctx, &redis.Session{
+			ID:        sessionID,
+			UserID:    user.ID,
+			UserAgent: client.UserAgent,
+			IP:        client.IP,
+			MFA:       mfa,
+		},
+		user.Role,
+	)
+}
+
+// grantsOf returns the permissions of role in the form tokens carry them.
+func (s *authService) grantsOf(
+	ctx context.Context, role models.Role,
+) ([]string, error) {
+	grants, err := roleGrants(ctx, s.roles, role)
 	if err != nil {
-		return "", "", errs.ErrTokenGeneration
+		return nil, err
+	}
+
+	permissions := make([]string, len(grants))
+	for i, p := range grants {
+		permissions[i] = string(p)
+	}
+	return permissions, nil
+}
+
+// issueTokens generates the first token pair of a new session and stores
+// it together with the session.
+func (s *authService) issueTokens(
+	ctx context.Context, session *redis.Session, role models.Role,
+) (string, string, error) {
+	accessToken, refreshToken, err := s.newTokenPair(ctx, session, role)
+	if err != nil {
+		return "", "", err
 	}
 
-	if err := s.tokenStore.SaveJWTokens(
-		userID, accessToken, refreshToken,
+	if err := s.tokenStore.SaveSession(
+		ctx, session, accessToken, refreshToken,
 	); err != nil {
 		return "", "", errs.ErrTokenStorage
 	}
@@ -56,10 +175,39 @@ func (s *authService) generateAndStoreTokens(userID int, role string) (
 	return accessToken, refreshToken, nil
 }
 
-func (s *authService) RegisterUser(req *models.RegisterUser) (
-	string, string, error,
-) {
-	existingUser, err := s.repo.GetByEmail(req.Email)
+// newTokenPair generates a token pair for session. The tokens carry the
+// permissions role grants at this moment, so changes to a role reach its
+// users on their next refresh.
+func (s *authService) newTokenPair(
+	ctx context.Context, session *redis.Session, role models.Role,
+) (string, string, error) {
+	permissions, err := s.grantsOf(ctx, role)
+	if err != nil {
+		return "", "", err
+	}
+
+	uidStr := strconv.Itoa(session.UserID)
+	accessToken, err := jwt.GenerateSessionJWT(
+		uidStr, 15, string(role), permissions, session.ID, session.MFA,
+	)
+	if err != nil {
+		return "", "", errs.ErrTokenGeneration
+	}
+
+	refreshToken, err := jwt.GenerateSessionJWT(
+		uidStr, jwt.RefreshTokenMinutes, string(role), permissions, session.ID, session.MFA,
+	)
+	if err != nil {
+		return "", "", errs.ErrTokenGeneration
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (s *authService) RegisterUser(
+	ctx context.Context, req *models.RegisterUser, client models.ClientInfo,
+) (string, string, error) {
+	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, errs.ErrUserNotFound) {
 			existingUser = nil
@@ -80,32 +228,654 @@ func (s *authService) RegisterUser(req *models.RegisterUser) (
 		Role:        models.RoleUser,
 	}
 
-	if err := s.repo.Create(user); err != nil {
-		return "", "", errs.ErrUserCreationFailed
+	// The account and its verification token are created together, so
+	// there is never a user that can not be verified.
+	var token string
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := repos.Users.Create(ctx, user); err != nil {
+				return errs.ErrUserCreationFailed
+			}
+
+			var err error
+			token, err = issueUserToken(
+				ctx, repos.UserTokens, user.ID,
+				models.TokenPurposeEmailVerification, s.opts.VerificationTTL,
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return "", "", err
 	}
 
-	return s.generateAndStoreTokens(user.ID, string(user.Role))
+	// A lost email is not worth failing the registration over: the user
+	// can ask for another one.
+	if err := s.sendVerification(ctx, user.Email, token); err != nil {
+		logger.FromContext(ctx).Warn(
+			"Failed to send the verification email",
+			"user_id", user.ID, "error", err,
+		)
+	}
+
+	if s.opts.RequireVerifiedLogin {
+		return "", "", nil
+	}
+
+	return s.openSession(ctx, user, false, client)
 }
 
-func (s *authService) LoginUser(email, password string) (
-	string, string, error,
-) {
-	user, err := s.repo.GetByEmail(email)
+func issueUserToken(
+	ctx context.Context,
+	tokens repositories.UserTokenRepository,
+	userID int,
+	purpose models.TokenPurpose,
+	ttl time.Duration,
+) (string, error) {
+	token, hash, err := jwt.GenerateOneTimeToken(string(purpose))
+	if err != nil {
+		return "", errs.ErrTokenGeneration
+	}
+
+	if err := tokens.Create(
+		ctx, &models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		},
+	); err != nil {
+		return "", errs.ErrTokenStorage
+	}
+
+	return token, nil
+}
+
+func (s *authService) sendVerification(
+	ctx context.Context, email, token string,
+) error {
+	link := s.opts.VerificationURL + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(
+		ctx, mailer.Message{
+			To:      email,
+			Subject: "Confirm your email address",
+			Body: fmt.Sprintf(
+				"Open the link below to confirm your email address:\n\n%s\n\n"+
+					"The link expires in %s. If you did not sign up, "+
+					"ignore this email.",
+				link, s.opts.VerificationTTL,
+			),
+		},
+	)
+}
+
+// VerifyEmail redeems a token from a verification email.
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	hash, err := jwt.VerifyOneTimeToken(
+		string(models.TokenPurposeEmailVerification), token,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			userToken, err := repos.UserTokens.Consume(
+				ctx, models.TokenPurposeEmailVerification, hash,
+			)
+			if err != nil {
+				return err
+			}
+			return repos.Users.MarkEmailVerified(ctx, userToken.UserID)
+		},
+	)
+}
+
+// ResendVerification replaces any outstanding verification token with a
+// new one and mails it. Unknown and already verified addresses are ignored
+// without an error, so the endpoint does not reveal which emails exist.
+func (s *authService) ResendVerification(
+	ctx context.Context, email string,
+) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	var token string
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := repos.UserTokens.DeleteByUserID(
+				ctx, user.ID, models.TokenPurposeEmailVerification,
+			); err != nil {
+				return err
+			}
+
+			var err error
+			token, err = issueUserToken(
+				ctx, repos.UserTokens, user.ID,
+				models.TokenPurposeEmailVerification, s.opts.VerificationTTL,
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.sendVerification(ctx, user.Email, token)
+}
+
+// ForgotPassword mails a password reset link, replacing any link sent
+// before. Unknown addresses are ignored without an error, so the endpoint
+// does not reveal which emails exist.
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var token string
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := repos.UserTokens.DeleteByUserID(
+				ctx, user.ID, models.TokenPurposePasswordReset,
+			); err != nil {
+				return err
+			}
+
+			var err error
+			token, err = issueUserToken(
+				ctx, repos.UserTokens, user.ID,
+				models.TokenPurposePasswordReset, s.opts.PasswordResetTTL,
+			)
+			return err
+		},
+	)
 	if err != nil {
-		return "", "", errs.ErrUserVerifyFailed
+		return err
+	}
+
+	link := s.opts.PasswordResetURL + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(
+		ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"Open the link below to choose a new password:\n\n%s\n\n"+
+					"The link expires in %s and works once. If you did not "+
+					"ask for a reset, ignore this email.",
+				link, s.opts.PasswordResetTTL,
+			),
+		},
+	)
+}
+
+// ResetPassword redeems a token from a reset email, sets the new password
+// and signs the user out of every session.
+func (s *authService) ResetPassword(
+	ctx context.Context, token, password string,
+) error {
+	hash, err := jwt.VerifyOneTimeToken(
+		string(models.TokenPurposePasswordReset), token,
+	)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := jwt.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var userID int
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			userToken, err := repos.UserTokens.Consume(
+				ctx, models.TokenPurposePasswordReset, hash,
+			)
+			if err != nil {
+				return err
+			}
+
+			user, err := repos.Users.GetByID(ctx, userToken.UserID)
+			if err != nil {
+				return err
+			}
+			user.Password = hashedPassword
+			if _, err := repos.Users.Update(ctx, user); err != nil {
+				return err
+			}
+			userID = user.ID
+
+			// Links mailed before this one must not work either.
+			return repos.UserTokens.DeleteByUserID(
+				ctx, user.ID, models.TokenPurposePasswordReset,
+			)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenStore.DeleteAllSessions(ctx, userID); err != nil {
+		return errs.ErrTokenDeletionFailed
+	}
+
+	return nil
+}
+
+func (s *authService) LoginUser(
+	ctx context.Context, email, password string, client models.ClientInfo,
+) (*LoginResult, error) {
+	// The attempt is counted before the password is checked, so parallel
+	// guesses cannot all slip in under the lockout threshold.
+	status, err := s.loginGuard.Attempt(ctx, email, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if status.Locked || status.RetryAfter > 0 {
+		return nil, &errs.LoginThrottledError{
+			RetryAfter: status.RetryAfter, Locked: status.Locked,
+		}
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, errs.ErrUserNotFound) {
+		return nil, err
 	}
 	if user == nil {
-		return "", "", errs.ErrUserNotFound
+		// Spend the time a password check takes, so unknown emails
+		// cannot be told apart by the response time either.
+		_, _ = jwt.CheckPassword(password, dummyPasswordHash())
+		return nil, s.loginFailed(ctx, email, client, nil)
 	}
 
 	if _, err := jwt.CheckPassword(password, user.Password); err != nil {
-		return "", "", errs.ErrInvalidCreds
+		return nil, s.loginFailed(ctx, email, client, user)
+	}
+
+	if err := s.loginGuard.Reset(ctx, email); err != nil {
+		logger.FromContext(ctx).Warn(
+			"Failed to reset the failed logins",
+			"user_id", user.ID, "error", err,
+		)
+	}
+
+	if s.opts.RequireVerifiedLogin && !user.EmailVerified {
+		return nil, errs.ErrEmailNotVerified
+	}
+
+	if user.MFAEnabled {
+		var token string
+		err := s.uow.Do(
+			ctx, func(repos repositories.Repositories) error {
+				var err error
+				token, err = issueUserToken(
+					ctx, repos.UserTokens, user.ID,
+					models.TokenPurposeMFAChallenge, s.opts.MFAChallengeTTL,
+				)
+				return err
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: token}, nil
+	}
+
+	accessToken, refreshToken, err := s.openSession(ctx, user, false, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// dummyPasswordHash is checked against for unknown emails.
+var dummyPasswordHash = sync.OnceValue(
+	func() string {
+		hash, _ := jwt.HashPassword("not the password of any account")
+		return hash
+	},
+)
+
+// loginFailed records the failed login counted for email, user being nil
+// when no account has it, and returns the error to answer with. The failure that
+// locks a known account mails its owner an unlock link.
+func (s *authService) loginFailed(
+	ctx context.Context, email string, client models.ClientInfo,
+	user *models.User,
+) error {
+	status, err := s.loginGuard.RecordFailure(ctx, email, client.IP)
+	if err != nil {
+		return err
+	}
+	if !status.NewlyLocked {
+		return errs.ErrInvalidCreds
 	}
 
-	return s.generateAndStoreTokens(user.ID, string(user.Role))
+	throttled := &errs.LoginThrottledError{
+		RetryAfter: status.RetryAfter, Locked: true,
+	}
+	if user == nil {
+		return throttled
+	}
+
+	s.auditor.Record(
+		ctx, audit.Event{
+			Type:    audit.AccountLocked,
+			UserID:  user.ID,
+			Details: "locked after failed logins, last from " + client.IP,
+			At:      time.Now(),
+		},
+	)
+
+	if err := s.sendUnlockLink(ctx, user); err != nil {
+		logger.FromContext(ctx).Error(
+			"Failed to send the unlock email",
+			"user_id", user.ID, "error", err,
+		)
+	}
+
+	return throttled
+}
+
+func (s *authService) sendUnlockLink(
+	ctx context.Context, user *models.User,
+) error {
+	var token string
+	err := s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := repos.UserTokens.DeleteByUserID(
+				ctx, user.ID, models.TokenPurposeAccountUnlock,
+			); err != nil {
+				return err
+			}
+
+			var err error
+			token, err = issueUserToken(
+				ctx, repos.UserTokens, user.ID,
+				models.TokenPurposeAccountUnlock, s.opts.UnlockTTL,
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	link := s.opts.UnlockURL + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(
+		ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Your account was locked",
+			Body: fmt.Sprintf(
+				"Your account was locked after too many failed sign-in "+
+					"attempts. It unlocks on its own in %s, or open the "+
+					"link below to unlock it now:\n\n%s\n\n"+
+					"If these attempts were not yours, consider changing "+
+					"your password.",
+				s.opts.UnlockTTL, link,
+			),
+		},
+	)
+}
+
+// UnlockAccount redeems a token from an unlock email.
+func (s *authService) UnlockAccount(ctx context.Context, token string) error {
+	hash, err := jwt.VerifyOneTimeToken(
+		string(models.TokenPurposeAccountUnlock), token,
+	)
+	if err != nil {
+		return err
+	}
+
+	var userToken *models.UserToken
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			var err error
+			userToken, err = repos.UserTokens.Consume(
+				ctx, models.TokenPurposeAccountUnlock, hash,
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.UnlockUser(ctx, userToken.UserID)
+}
+
+func (s *authService) LoginLockout(
+	ctx context.Context, userID int,
+) (*models.LoginLockout, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.loginGuard.Check(ctx, user.Email, "")
+	if err != nil {
+		return nil, err
+	}
+
+	throttled := errs.LoginThrottledError{RetryAfter: status.RetryAfter}
+	return &models.LoginLockout{
+		Failures:          status.Failures,
+		Locked:            status.Locked,
+		RetryAfterSeconds: throttled.RetryAfterSeconds(),
+	}, nil
+}
+
+func (s *authService) UnlockUser(ctx context.Context, userID int) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginGuard.Unlock(ctx, user.Email); err != nil {
+		return err
+	}
+
+	s.auditor.Record(
+		ctx, audit.Event{
+			Type:   audit.AccountUnlocked,
+			UserID: user.ID,
+			At:     time.Now(),
+		},
+	)
+	return nil
+}
+
+// LoginMFA finishes a login with the challenge token from LoginUser and a
+// code from the authenticator or a recovery code. The challenge is spent
+// before the code is checked, so every challenge allows a single guess.
+func (s *authService) LoginMFA(
+	ctx context.Context, mfaToken, code string, client models.ClientInfo,
+) (string, string, error) {
+	hash, err := jwt.VerifyOneTimeToken(
+		string(models.TokenPurposeMFAChallenge), mfaToken,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	var challenge *models.UserToken
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			var err error
+			challenge, err = repos.UserTokens.Consume(
+				ctx, models.TokenPurposeMFAChallenge, hash,
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		return "", "", err
+	}
+	if !user.MFAEnabled {
+		return "", "", errs.ErrMFANotEnabled
+	}
+
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			return checkSecondFactor(ctx, repos.RecoveryCodes, user, code)
+		},
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.openSession(ctx, user, true, client)
+}
+
+// checkSecondFactor accepts a current authenticator code or redeems an
+// unused recovery code.
+func checkSecondFactor(
+	ctx context.Context,
+	codes repositories.MFARecoveryCodeRepository,
+	user *models.User,
+	code string,
+) error {
+	if totp.Validate(user.MFASecret, code, time.Now()) {
+		return nil
+	}
+
+	return codes.Consume(
+		ctx, user.ID, jwt.HashOneTimeToken(totp.NormalizeRecoveryCode(code)),
+	)
+}
+
+// EnrollMFA starts two-factor enrollment with a new secret. It takes
+// effect once ConfirmMFA sees a code generated from it; until then a
+// repeated call replaces the secret.
+func (s *authService) EnrollMFA(
+	ctx context.Context, userID int,
+) (*models.MFAEnrollment, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, errs.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateMFA(ctx, user.ID, false, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.opts.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor authentication once code matches the
+// enrolled secret, and returns fresh recovery codes. They are shown only
+// this once.
+func (s *authService) ConfirmMFA(
+	ctx context.Context, userID int, code string,
+) ([]string, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, errs.ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, errs.ErrMFANotEnrolled
+	}
+	if !totp.Validate(user.MFASecret, code, time.Now()) {
+		return nil, errs.ErrInvalidMFACode
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = jwt.HashOneTimeToken(totp.NormalizeRecoveryCode(code))
+	}
+
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := repos.Users.UpdateMFA(
+				ctx, user.ID, true, user.MFASecret,
+			); err != nil {
+				return err
+			}
+			return repos.RecoveryCodes.Replace(ctx, user.ID, hashes)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
 }
 
-func (s *authService) LogoutUser(accessToken, refreshToken string) error {
+// DisableMFA turns two-factor authentication off after checking code, an
+// authenticator or a recovery code, and drops the remaining recovery
+// codes.
+func (s *authService) DisableMFA(
+	ctx context.Context, userID int, code string,
+) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return errs.ErrMFANotEnabled
+	}
+
+	return s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			if err := checkSecondFactor(
+				ctx, repos.RecoveryCodes, user, code,
+			); err != nil {
+				return err
+			}
+			if err := repos.Users.UpdateMFA(
+				ctx, user.ID, false, "",
+			); err != nil {
+				return err
+			}
+			return repos.RecoveryCodes.DeleteByUserID(ctx, user.ID)
+		},
+	)
+}
+
+func (s *authService) LogoutUser(
+	ctx context.Context, accessToken, refreshToken string,
+) error {
 	claims, err := jwt.ParseJWT(accessToken)
 	if err != nil {
 		return errs.ErrTokenParsingFailed
@@ -116,16 +886,26 @@ func (s *authService) LogoutUser(accessToken, refreshToken string) error {
 		return errs.ErrInvalidTokenSub
 	}
 
-	if err := s.tokenStore.DeleteJWTokens(
-		userID, accessToken, refreshToken,
-	); err != nil {
+	// Tokens issued before sessions existed have no session to end.
+	if claims.SessionID == "" {
+		err = s.tokenStore.DeleteJWTokens(
+			ctx, userID, accessToken, refreshToken,
+		)
+	} else {
+		err = s.tokenStore.DeleteSession(ctx, userID, claims.SessionID)
+	}
+	if err != nil {
 		return errs.ErrTokenDeletionFailed
 	}
 
 	return nil
 }
 
-func (s *authService) RefreshTokens(refreshToken string) (
+// RefreshTokens swaps a stored refresh token for a new pair of the same
+// session. A session is a token family: every refresh token in it is
+// good for one swap, and presenting one that was already swapped revokes
+// the whole session, since either the user or a thief holds a copy.
+func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (
 	string, string, error,
 ) {
 	claims, err := jwt.ParseJWT(refreshToken)
@@ -138,22 +918,96 @@ func (s *authService) RefreshTokens(refreshToken string) (
 		return "", "", errs.ErrInvalidTokenSub
 	}
 
-	if err := s.tokenStore.DeleteJWToken(userID, refreshToken); err != nil {
-		return "", "", errs.ErrTokenDeletionFailed
+	// The role is read again rather than taken from the token, so a user
+	// whose role changed gets the new permissions.
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return "", "", errs.ErrUnauthorizedToken
+		}
+		return "", "", err
+	}
+
+	sessionID := claims.SessionID
+	if sessionID == "" {
+		if sessionID, err = redis.NewSessionID(); err != nil {
+			return "", "", errs.ErrTokenGeneration
+		}
 	}
 
-	accessToken, newRefreshToken, err := s.generateAndStoreTokens(
-		userID, claims.Role,
+	session := &redis.Session{ID: sessionID, UserID: userID, MFA: claims.MFA}
+	accessToken, newRefreshToken, err := s.newTokenPair(
+		ctx, session, user.Role,
 	)
 	if err != nil {
 		return "", "", err
 	}
 
-	if err := s.tokenStore.SaveJWTokens(
-		userID, accessToken, newRefreshToken,
-	); err != nil {
+	// The presented token is redeemed and the new pair saved in one step,
+	// so neither a second refresh nor a revocation running alongside can
+	// slip in between.
+	rotated, err := s.tokenStore.RotateSession(
+		ctx, session, refreshToken, accessToken, newRefreshToken,
+	)
+	if err != nil {
 		return "", "", errs.ErrTokenStorage
 	}
+	if !rotated {
+		return "", "", s.rejectRefreshToken(ctx, userID, claims, refreshToken)
+	}
 
 	return accessToken, newRefreshToken, nil
 }
+
+// rejectRefreshToken explains why a refresh token is not in the store and
+// revokes its session when the token is being reused.
+func (s *authService) rejectRefreshToken(
+	ctx context.Context, userID int, claims *jwt.Claims, refreshToken string,
+) error {
+	rotated, err := s.tokenStore.IsRotatedJWToken(ctx, userID, refreshToken)
+	if err != nil || !rotated {
+		return errs.ErrUnauthorizedToken
+	}
+
+	err = s.tokenStore.DeleteSession(ctx, userID, claims.SessionID)
+	if err != nil && !errors.Is(err, errs.ErrSessionNotFound) {
+		logger.FromContext(ctx).Error(
+			"Failed to revoke the session after refresh token reuse",
+			"session_id", claims.SessionID, "error", err,
+		)
+	}
+
+	s.auditor.Record(
+		ctx, audit.Event{
+			Type:      audit.RefreshTokenReuse,
+			UserID:    userID,
+			SessionID: claims.SessionID,
+			Details:   "rotated refresh token " + claims.ID + " presented again",
+			At:        time.Now(),
+		},
+	)
+
+	return errs.ErrRefreshTokenReused
+}
+
+func (s *authService) ListSessions(
+	ctx context.Context, userID int,
+) ([]redis.Session, error) {
+	return s.tokenStore.ListSessions(ctx, userID)
+}
+
+func (s *authService) RevokeSession(
+	ctx context.Context, userID int, sessionID string,
+) error {
+	return s.tokenStore.DeleteSession(ctx, userID, sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere. Unknown users are
+// reported, so admins notice a wrong ID.
+func (s *authService) RevokeAllSessions(ctx context.Context, userID int) error {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.tokenStore.DeleteAllSessions(ctx, userID)
+}
@@ -1,7 +1,10 @@
 package services_test
 
 import (
+	"bytes"
+	"context"
 	mocks2 "github.com/DaniilKalts/market-rest-api/internal/mocks"
+	"io"
 	"strconv"
 	"testing"
 	"time"
@@ -13,8 +16,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
 	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/audit"
 	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/mailer"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+	"github.com/DaniilKalts/market-rest-api/pkg/totp"
 )
 
 var (
@@ -31,10 +39,115 @@ var (
 	}
 )
 
+var client = models.ClientInfo{UserAgent: "test-agent", IP: "127.0.0.1"}
+
+// sessionOf matches a session of the user.
+func sessionOf(userID int) interface{} {
+	return mock.MatchedBy(
+		func(session *redis.Session) bool {
+			return session.UserID == userID && session.ID != ""
+		},
+	)
+}
+
+var authOptions = services.AuthOptions{
+	VerificationTTL:  24 * time.Hour,
+	VerificationURL:  "http://localhost:8080/verify-email",
+	PasswordResetTTL: time.Hour,
+	PasswordResetURL: "http://localhost:8080/reset-password",
+	UnlockTTL:        30 * time.Minute,
+	UnlockURL:        "http://localhost:8080/unlock-account",
+}
+
+// passthroughUnitOfWork runs every unit of work on repos without a
+// transaction.
+func passthroughUnitOfWork(repos repositories.Repositories) *mocks2.UnitOfWork {
+	uow := new(mocks2.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).Return(
+		func(
+			_ context.Context, fn func(repositories.Repositories) error,
+		) error {
+			return fn(repos)
+		},
+	)
+	return uow
+}
+
+// rolesGranting stores role with permissions; every other role is
+// unknown.
+func rolesGranting(
+	role models.Role, permissions ...models.Permission,
+) *mocks2.RoleRepository {
+	definition := &models.RoleDefinition{Name: role}
+	definition.SetGrants(permissions)
+
+	roles := new(mocks2.RoleRepository)
+	roles.On("GetByName", mock.Anything, role).Return(definition, nil).Maybe()
+	roles.
+		On("GetByName", mock.Anything, mock.Anything).
+		Return(nil, errs.ErrRoleNotFound).
+		Maybe()
+	return roles
+}
+
+// openLoginGuard lets every login attempt through.
+func openLoginGuard() *mocks2.LoginGuard {
+	guard := new(mocks2.LoginGuard)
+	guard.
+		On("Check", mock.Anything, mock.Anything, mock.Anything).
+		Return(redis.LoginStatus{}, nil).
+		Maybe()
+	guard.
+		On("Attempt", mock.Anything, mock.Anything, mock.Anything).
+		Return(redis.LoginStatus{Failures: 1}, nil).
+		Maybe()
+	guard.
+		On("RecordFailure", mock.Anything, mock.Anything, mock.Anything).
+		Return(redis.LoginStatus{Failures: 1}, nil).
+		Maybe()
+	guard.On("Reset", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return guard
+}
+
+// newAuthService runs the unit of work on the given mocks and collects sent
+// emails in the returned buffer.
+func newAuthService(
+	repo *mocks2.UserRepository,
+	tokenStore *mocks2.TokenStore,
+	opts services.AuthOptions,
+) (services.AuthService, *mocks2.UserTokenRepository, *bytes.Buffer) {
+	return newGuardedAuthService(
+		repo, tokenStore, openLoginGuard(), new(mocks2.Recorder), opts,
+	)
+}
+
+// newGuardedAuthService is newAuthService with the given login guard and
+// audit recorder.
+func newGuardedAuthService(
+	repo *mocks2.UserRepository,
+	tokenStore *mocks2.TokenStore,
+	guard *mocks2.LoginGuard,
+	auditor *mocks2.Recorder,
+	opts services.AuthOptions,
+) (services.AuthService, *mocks2.UserTokenRepository, *bytes.Buffer) {
+	tokenRepo := new(mocks2.UserTokenRepository)
+	uow := passthroughUnitOfWork(
+		repositories.Repositories{Users: repo, UserTokens: tokenRepo},
+	)
+
+	mailbox := new(bytes.Buffer)
+	svc := services.NewAuthService(
+		repo, rolesGranting(models.RoleUser), uow, tokenStore, guard,
+		mailer.NewWriterMailer(mailbox, "test@localhost"), auditor, opts,
+	)
+
+	return svc, tokenRepo, mailbox
+}
+
 func TestRegisterUser_UserExists(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	req := &models.RegisterUser{
 		FirstName:       "Martin",
@@ -46,10 +159,10 @@ func TestRegisterUser_UserExists(t *testing.T) {
 	}
 
 	repoMock.
-		On("GetByEmail", req.Email).
+		On("GetByEmail", mock.Anything, req.Email).
 		Return(martinUser, nil)
 
-	access, refresh, err := svc.RegisterUser(req)
+	access, refresh, err := svc.RegisterUser(ctx, req, client)
 	assert.Empty(t, access)
 	assert.Empty(t, refresh)
 	assert.Equal(t, errs.ErrUserExists, err)
@@ -61,7 +174,9 @@ func TestRegisterUser_UserExists(t *testing.T) {
 func TestRegisterUser_Success(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, tokenRepo, mailbox := newAuthService(
+		repoMock, tokenStoreMock, authOptions,
+	)
 
 	req := &models.RegisterUser{
 		FirstName:       "New",
@@ -73,45 +188,100 @@ func TestRegisterUser_Success(t *testing.T) {
 	}
 
 	repoMock.
-		On("GetByEmail", req.Email).
+		On("GetByEmail", mock.Anything, req.Email).
 		Return(nil, errs.ErrUserNotFound)
 
 	repoMock.
-		On("Create", mock.AnythingOfType("*models.User")).
+		On("Create", mock.Anything, mock.AnythingOfType("*models.User")).
 		Run(
 			func(args mock.Arguments) {
-				u := args.Get(0).(*models.User)
+				u := args.Get(1).(*models.User)
 				u.ID = 2
 			},
 		).
 		Return(nil)
 
+	tokenRepo.
+		On(
+			"Create", mock.Anything, mock.MatchedBy(
+				func(token *models.UserToken) bool {
+					return token.UserID == 2 &&
+						token.Purpose == models.TokenPurposeEmailVerification &&
+						len(token.TokenHash) == 64
+				},
+			),
+		).
+		Return(nil)
+
 	tokenStoreMock.
-		On("SaveJWTokens", 2, mock.Anything, mock.Anything).
+		On(
+			"SaveSession", mock.Anything, sessionOf(2), mock.Anything,
+			mock.Anything,
+		).
 		Return(nil)
 
-	access, refresh, err := svc.RegisterUser(req)
+	access, refresh, err := svc.RegisterUser(ctx, req, client)
 	require.NoError(t, err)
 	assert.NotEmpty(t, access)
 	assert.NotEmpty(t, refresh)
+	assert.Contains(t, mailbox.String(), "To: newuser@example.com")
+	assert.Contains(t, mailbox.String(), authOptions.VerificationURL+"?token=")
 
 	repoMock.AssertExpectations(t)
+	tokenRepo.AssertExpectations(t)
 	tokenStoreMock.AssertExpectations(t)
 }
 
-func TestLoginUser_UserNotFound(t *testing.T) {
+func TestRegisterUser_RequireVerifiedLogin(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	opts := authOptions
+	opts.RequireVerifiedLogin = true
+	svc, tokenRepo, mailbox := newAuthService(repoMock, tokenStoreMock, opts)
+
+	req := &models.RegisterUser{
+		FirstName:       "New",
+		LastName:        "User",
+		Email:           "newuser@example.com",
+		Password:        "12341234",
+		ConfirmPassword: "12341234",
+		PhoneNumber:     "+77007473472",
+	}
 
 	repoMock.
-		On("GetByEmail", "nonexistent@example.com").
+		On("GetByEmail", mock.Anything, req.Email).
 		Return(nil, errs.ErrUserNotFound)
+	repoMock.
+		On("Create", mock.Anything, mock.AnythingOfType("*models.User")).
+		Return(nil)
+	tokenRepo.
+		On("Create", mock.Anything, mock.AnythingOfType("*models.UserToken")).
+		Return(nil)
 
-	access, refresh, err := svc.LoginUser("nonexistent@example.com", "12341234")
+	access, refresh, err := svc.RegisterUser(ctx, req, client)
+	require.NoError(t, err)
 	assert.Empty(t, access)
 	assert.Empty(t, refresh)
-	assert.Equal(t, errs.ErrUserVerifyFailed, err)
+	assert.Contains(t, mailbox.String(), "To: newuser@example.com")
+
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestLoginUser_UserNotFound(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, "nonexistent@example.com").
+		Return(nil, errs.ErrUserNotFound)
+
+	result, err := svc.LoginUser(ctx, "nonexistent@example.com", "12341234", client)
+	assert.Nil(t, result)
+	assert.Equal(t, errs.ErrInvalidCreds, err)
 
 	repoMock.AssertExpectations(t)
 }
@@ -119,15 +289,14 @@ func TestLoginUser_UserNotFound(t *testing.T) {
 func TestLoginUser_InvalidCreds(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	repoMock.
-		On("GetByEmail", martinUser.Email).
+		On("GetByEmail", mock.Anything, martinUser.Email).
 		Return(martinUser, nil)
 
-	access, refresh, err := svc.LoginUser(martinUser.Email, "wrongpass")
-	assert.Empty(t, access)
-	assert.Empty(t, refresh)
+	result, err := svc.LoginUser(ctx, martinUser.Email, "wrongpass", client)
+	assert.Nil(t, result)
 	assert.Equal(t, errs.ErrInvalidCreds, err)
 
 	repoMock.AssertExpectations(t)
@@ -136,25 +305,729 @@ func TestLoginUser_InvalidCreds(t *testing.T) {
 func TestLoginUser_Success(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	repoMock.
-		On("GetByEmail", martinUser.Email).
+		On("GetByEmail", mock.Anything, martinUser.Email).
 		Return(martinUser, nil)
 
 	tokenStoreMock.
-		On("SaveJWTokens", martinUser.ID, mock.Anything, mock.Anything).
+		On(
+			"SaveSession", mock.Anything, sessionOf(martinUser.ID),
+			mock.Anything, mock.Anything,
+		).
 		Return(nil)
 
-	access, refresh, err := svc.LoginUser(martinUser.Email, "12341234")
+	result, err := svc.LoginUser(ctx, martinUser.Email, "12341234", client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.Empty(t, result.MFAToken)
+
+	repoMock.AssertExpectations(t)
+	tokenStoreMock.AssertExpectations(t)
+}
+
+func TestLoginUser_EmailNotVerified(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	opts := authOptions
+	opts.RequireVerifiedLogin = true
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, opts)
+
+	unverified := *martinUser
+	unverified.EmailVerified = false
+	repoMock.
+		On("GetByEmail", mock.Anything, unverified.Email).
+		Return(&unverified, nil)
+
+	result, err := svc.LoginUser(ctx, unverified.Email, "12341234", client)
+	assert.Nil(t, result)
+	assert.Equal(t, errs.ErrEmailNotVerified, err)
+
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestLoginUser_Throttled(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	guard := new(mocks2.LoginGuard)
+	svc, _, _ := newGuardedAuthService(
+		repoMock, new(mocks2.TokenStore), guard, new(mocks2.Recorder),
+		authOptions,
+	)
+
+	guard.
+		On("Attempt", mock.Anything, martinUser.Email, client.IP).
+		Return(
+			redis.LoginStatus{
+				Failures: 4, RetryAfter: 1500 * time.Millisecond,
+			}, nil,
+		)
+
+	result, err := svc.LoginUser(ctx, martinUser.Email, "12341234", client)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errs.ErrTooManyLoginAttempts)
+
+	var throttled *errs.LoginThrottledError
+	require.ErrorAs(t, err, &throttled)
+	assert.Equal(t, int64(2), throttled.RetryAfterSeconds())
+
+	repoMock.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
+func TestLoginUser_LockoutMailsUnlockLink(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	guard := new(mocks2.LoginGuard)
+	auditor := new(mocks2.Recorder)
+	svc, tokenRepo, mailbox := newGuardedAuthService(
+		repoMock, new(mocks2.TokenStore), guard, auditor, authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, martinUser.Email).
+		Return(martinUser, nil)
+	guard.
+		On("Attempt", mock.Anything, martinUser.Email, client.IP).
+		Return(redis.LoginStatus{Failures: 9}, nil)
+	guard.
+		On("RecordFailure", mock.Anything, martinUser.Email, client.IP).
+		Return(
+			redis.LoginStatus{
+				Locked: true, NewlyLocked: true, RetryAfter: 30 * time.Minute,
+			}, nil,
+		)
+	tokenRepo.
+		On(
+			"DeleteByUserID", mock.Anything, martinUser.ID,
+			models.TokenPurposeAccountUnlock,
+		).
+		Return(nil)
+	tokenRepo.
+		On(
+			"Create", mock.Anything, mock.MatchedBy(
+				func(token *models.UserToken) bool {
+					return token.Purpose == models.TokenPurposeAccountUnlock
+				},
+			),
+		).
+		Return(nil)
+	auditor.
+		On(
+			"Record", mock.Anything, mock.MatchedBy(
+				func(event audit.Event) bool {
+					return event.Type == audit.AccountLocked &&
+						event.UserID == martinUser.ID
+				},
+			),
+		).
+		Once()
+
+	_, err := svc.LoginUser(ctx, martinUser.Email, "wrongpass", client)
+	assert.ErrorIs(t, err, errs.ErrAccountLocked)
+	assert.Contains(t, mailbox.String(), authOptions.UnlockURL+"?token=")
+
+	tokenRepo.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+}
+
+func TestLoginUser_UnknownEmailLocksLikeKnown(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	guard := new(mocks2.LoginGuard)
+	svc, tokenRepo, mailbox := newGuardedAuthService(
+		repoMock, new(mocks2.TokenStore), guard, new(mocks2.Recorder),
+		authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, "ghost@example.com").
+		Return(nil, errs.ErrUserNotFound)
+	guard.
+		On("Attempt", mock.Anything, "ghost@example.com", client.IP).
+		Return(redis.LoginStatus{}, nil)
+	guard.
+		On("RecordFailure", mock.Anything, "ghost@example.com", client.IP).
+		Return(
+			redis.LoginStatus{
+				Locked: true, NewlyLocked: true, RetryAfter: 30 * time.Minute,
+			}, nil,
+		)
+
+	_, err := svc.LoginUser(ctx, "ghost@example.com", "12341234", client)
+	assert.ErrorIs(t, err, errs.ErrAccountLocked)
+	assert.Empty(t, mailbox.String())
+
+	tokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestLoginUser_SuccessResetsFailures(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	guard := new(mocks2.LoginGuard)
+	svc, _, _ := newGuardedAuthService(
+		repoMock, tokenStoreMock, guard, new(mocks2.Recorder), authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, martinUser.Email).
+		Return(martinUser, nil)
+	guard.
+		On("Attempt", mock.Anything, martinUser.Email, client.IP).
+		Return(redis.LoginStatus{Failures: 2}, nil)
+	guard.On("Reset", mock.Anything, martinUser.Email).Return(nil).Once()
+	tokenStoreMock.
+		On(
+			"SaveSession", mock.Anything, sessionOf(martinUser.ID),
+			mock.Anything, mock.Anything,
+		).
+		Return(nil)
+
+	_, err := svc.LoginUser(ctx, martinUser.Email, "12341234", client)
+	require.NoError(t, err)
+
+	guard.AssertExpectations(t)
+}
+
+func TestUnlockAccount_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	guard := new(mocks2.LoginGuard)
+	auditor := new(mocks2.Recorder)
+	svc, tokenRepo, _ := newGuardedAuthService(
+		repoMock, new(mocks2.TokenStore), guard, auditor, authOptions,
+	)
+
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeAccountUnlock),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeAccountUnlock, hash).
+		Return(&models.UserToken{UserID: martinUser.ID}, nil).
+		Once()
+	repoMock.On("GetByID", mock.Anything, martinUser.ID).Return(martinUser, nil)
+	guard.On("Unlock", mock.Anything, martinUser.Email).Return(nil).Once()
+	auditor.On("Record", mock.Anything, mock.Anything).Once()
+
+	require.NoError(t, svc.UnlockAccount(ctx, token))
+
+	tokenRepo.AssertExpectations(t)
+	guard.AssertExpectations(t)
+}
+
+// newMFAAuthService is newAuthService with recovery codes, for accounts
+// with two-factor authentication.
+func newMFAAuthService(
+	repo *mocks2.UserRepository, tokenStore *mocks2.TokenStore,
+) (
+	services.AuthService,
+	*mocks2.UserTokenRepository,
+	*mocks2.MFARecoveryCodeRepository,
+) {
+	tokenRepo := new(mocks2.UserTokenRepository)
+	codesRepo := new(mocks2.MFARecoveryCodeRepository)
+	uow := passthroughUnitOfWork(
+		repositories.Repositories{
+			Users:         repo,
+			UserTokens:    tokenRepo,
+			RecoveryCodes: codesRepo,
+		},
+	)
+
+	svc := services.NewAuthService(
+		repo, rolesGranting(models.RoleUser), uow, tokenStore,
+		openLoginGuard(), mailer.NewWriterMailer(io.Discard, ""),
+		new(mocks2.Recorder), authOptions,
+	)
+
+	return svc, tokenRepo, codesRepo
+}
+
+func mfaUser(t *testing.T) (*models.User, string) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	user := *martinUser
+	user.MFAEnabled = true
+	user.MFASecret = secret
+	return &user, secret
+}
+
+func TestLoginUser_MFAChallenge(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, _ := newMFAAuthService(repoMock, tokenStoreMock)
+
+	user, _ := mfaUser(t)
+	repoMock.
+		On("GetByEmail", mock.Anything, user.Email).
+		Return(user, nil)
+	tokenRepo.
+		On(
+			"Create", mock.Anything, mock.MatchedBy(
+				func(token *models.UserToken) bool {
+					return token.Purpose == models.TokenPurposeMFAChallenge
+				},
+			),
+		).
+		Return(nil).
+		Once()
+
+	result, err := svc.LoginUser(ctx, user.Email, "12341234", client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.MFAToken)
+	assert.Empty(t, result.AccessToken)
+
+	tokenRepo.AssertExpectations(t)
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestLoginMFA_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, _ := newMFAAuthService(repoMock, tokenStoreMock)
+
+	user, secret := mfaUser(t)
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeMFAChallenge),
+	)
+	require.NoError(t, err)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeMFAChallenge, hash).
+		Return(&models.UserToken{UserID: user.ID}, nil).
+		Once()
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	var stored string
+	tokenStoreMock.
+		On(
+			"SaveSession", mock.Anything, sessionOf(user.ID), mock.Anything,
+			mock.Anything,
+		).
+		Run(
+			func(args mock.Arguments) {
+				stored = args.String(2)
+			},
+		).
+		Return(nil)
+
+	access, refresh, err := svc.LoginMFA(ctx, token, code, client)
 	require.NoError(t, err)
-	assert.NotEmpty(t, access)
 	assert.NotEmpty(t, refresh)
+	assert.Equal(t, stored, access)
+
+	claims, err := jwt.ParseJWT(access)
+	require.NoError(t, err)
+	assert.True(t, claims.MFA)
+}
+
+func TestLoginMFA_RecoveryCode(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, codesRepo := newMFAAuthService(repoMock, tokenStoreMock)
+
+	user, _ := mfaUser(t)
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeMFAChallenge),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeMFAChallenge, hash).
+		Return(&models.UserToken{UserID: user.ID}, nil).
+		Once()
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	codesRepo.
+		On(
+			"Consume", mock.Anything, user.ID,
+			jwt.HashOneTimeToken("abcdefghij"),
+		).
+		Return(nil).
+		Once()
+	tokenStoreMock.
+		On(
+			"SaveSession", mock.Anything, sessionOf(user.ID), mock.Anything,
+			mock.Anything,
+		).
+		Return(nil)
+
+	_, _, err = svc.LoginMFA(ctx, token, "ABCDE-FGHIJ", client)
+	require.NoError(t, err)
+
+	codesRepo.AssertExpectations(t)
+}
+
+func TestLoginMFA_InvalidCode(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, codesRepo := newMFAAuthService(repoMock, tokenStoreMock)
+
+	user, _ := mfaUser(t)
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeMFAChallenge),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeMFAChallenge, hash).
+		Return(&models.UserToken{UserID: user.ID}, nil).
+		Once()
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	codesRepo.
+		On("Consume", mock.Anything, user.ID, mock.Anything).
+		Return(errs.ErrInvalidMFACode)
+
+	_, _, err = svc.LoginMFA(ctx, token, "000000", client)
+	assert.Equal(t, errs.ErrInvalidMFACode, err)
+
+	tokenRepo.AssertExpectations(t)
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestEnrollMFA_AlreadyEnabled(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, _, _ := newMFAAuthService(repoMock, new(mocks2.TokenStore))
+
+	user, _ := mfaUser(t)
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	_, err := svc.EnrollMFA(ctx, user.ID)
+	assert.Equal(t, errs.ErrMFAAlreadyEnabled, err)
+
+	repoMock.AssertNotCalled(
+		t, "UpdateMFA", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestEnrollAndConfirmMFA(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, _, codesRepo := newMFAAuthService(repoMock, new(mocks2.TokenStore))
+
+	user := *martinUser
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(&user, nil)
+	repoMock.
+		On("UpdateMFA", mock.Anything, user.ID, false, mock.Anything).
+		Run(
+			func(args mock.Arguments) {
+				user.MFASecret = args.String(3)
+			},
+		).
+		Return(nil).
+		Once()
+
+	enrollment, err := svc.EnrollMFA(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.MFASecret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	_, err = svc.ConfirmMFA(ctx, user.ID, "000000")
+	assert.Equal(t, errs.ErrInvalidMFACode, err)
+
+	code, err := totp.Code(user.MFASecret, time.Now())
+	require.NoError(t, err)
+	repoMock.
+		On("UpdateMFA", mock.Anything, user.ID, true, user.MFASecret).
+		Return(nil).
+		Once()
+	codesRepo.
+		On("Replace", mock.Anything, user.ID, mock.Anything).
+		Return(nil).
+		Once()
+
+	codes, err := svc.ConfirmMFA(ctx, user.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	repoMock.AssertExpectations(t)
+	codesRepo.AssertExpectations(t)
+}
+
+func TestDisableMFA_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, _, codesRepo := newMFAAuthService(repoMock, new(mocks2.TokenStore))
+
+	user, secret := mfaUser(t)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	repoMock.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	repoMock.
+		On("UpdateMFA", mock.Anything, user.ID, false, "").
+		Return(nil).
+		Once()
+	codesRepo.
+		On("DeleteByUserID", mock.Anything, user.ID).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.DisableMFA(ctx, user.ID, code))
+
+	repoMock.AssertExpectations(t)
+	codesRepo.AssertExpectations(t)
+}
+
+func TestVerifyEmail_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, _ := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeEmailVerification),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeEmailVerification, hash).
+		Return(&models.UserToken{UserID: martinUser.ID}, nil).
+		Once()
+	repoMock.
+		On("MarkEmailVerified", mock.Anything, martinUser.ID).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.VerifyEmail(ctx, token))
+
+	tokenRepo.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+}
+
+func TestVerifyEmail_WrongPurpose(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, _ := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	token, _, err := jwt.GenerateOneTimeToken("password_reset")
+	require.NoError(t, err)
+
+	err = svc.VerifyEmail(ctx, token)
+	assert.Equal(t, errs.ErrInvalidOneTimeToken, err)
+
+	tokenRepo.AssertNotCalled(
+		t, "Consume", mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+func TestVerifyEmail_AlreadyUsed(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, _ := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeEmailVerification),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposeEmailVerification, hash).
+		Return(nil, errs.ErrInvalidOneTimeToken).
+		Once()
+
+	err = svc.VerifyEmail(ctx, token)
+	assert.Equal(t, errs.ErrInvalidOneTimeToken, err)
+
+	repoMock.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+}
+
+func TestResendVerification_UnknownEmail(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, mailbox := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, "ghost@example.com").
+		Return(nil, errs.ErrUserNotFound)
+
+	require.NoError(t, svc.ResendVerification(ctx, "ghost@example.com"))
+	assert.Empty(t, mailbox.String())
+
+	tokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestResendVerification_ReplacesToken(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, mailbox := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, martinUser.Email).
+		Return(martinUser, nil)
+	tokenRepo.
+		On(
+			"DeleteByUserID", mock.Anything, martinUser.ID,
+			models.TokenPurposeEmailVerification,
+		).
+		Return(nil).
+		Once()
+	tokenRepo.
+		On("Create", mock.Anything, mock.AnythingOfType("*models.UserToken")).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.ResendVerification(ctx, martinUser.Email))
+	assert.Contains(t, mailbox.String(), "To: "+martinUser.Email)
+
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, mailbox := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	repoMock.
+		On("GetByEmail", mock.Anything, "ghost@example.com").
+		Return(nil, errs.ErrUserNotFound)
+
+	require.NoError(t, svc.ForgotPassword(ctx, "ghost@example.com"))
+	assert.Empty(t, mailbox.String())
+
+	tokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestForgotPassword_SendsLink(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, mailbox := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
 
+	repoMock.
+		On("GetByEmail", mock.Anything, martinUser.Email).
+		Return(martinUser, nil)
+	tokenRepo.
+		On(
+			"DeleteByUserID", mock.Anything, martinUser.ID,
+			models.TokenPurposePasswordReset,
+		).
+		Return(nil).
+		Once()
+	tokenRepo.
+		On(
+			"Create", mock.Anything, mock.MatchedBy(
+				func(token *models.UserToken) bool {
+					return token.Purpose == models.TokenPurposePasswordReset &&
+						token.UserID == martinUser.ID
+				},
+			),
+		).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.ForgotPassword(ctx, martinUser.Email))
+	assert.Contains(t, mailbox.String(), "To: "+martinUser.Email)
+	assert.Contains(t, mailbox.String(), authOptions.PasswordResetURL+"?token=")
+
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposePasswordReset),
+	)
+	require.NoError(t, err)
+
+	user := *martinUser
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposePasswordReset, hash).
+		Return(&models.UserToken{UserID: user.ID}, nil).
+		Once()
+	repoMock.
+		On("GetByID", mock.Anything, user.ID).
+		Return(&user, nil)
+	repoMock.
+		On(
+			"Update", mock.Anything, mock.MatchedBy(
+				func(u *models.User) bool {
+					ok, _ := jwt.CheckPassword("43214321", u.Password)
+					return ok
+				},
+			),
+		).
+		Return(&user, nil).
+		Once()
+	tokenRepo.
+		On(
+			"DeleteByUserID", mock.Anything, user.ID,
+			models.TokenPurposePasswordReset,
+		).
+		Return(nil).
+		Once()
+	tokenStoreMock.
+		On("DeleteAllSessions", mock.Anything, user.ID).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.ResetPassword(ctx, token, "43214321"))
+
+	tokenRepo.AssertExpectations(t)
 	repoMock.AssertExpectations(t)
 	tokenStoreMock.AssertExpectations(t)
 }
 
+func TestResetPassword_AlreadyUsed(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, tokenRepo, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	token, hash, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposePasswordReset),
+	)
+	require.NoError(t, err)
+
+	tokenRepo.
+		On("Consume", mock.Anything, models.TokenPurposePasswordReset, hash).
+		Return(nil, errs.ErrInvalidOneTimeToken).
+		Once()
+
+	err = svc.ResetPassword(ctx, token, "43214321")
+	assert.Equal(t, errs.ErrInvalidOneTimeToken, err)
+
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	tokenStoreMock.AssertNotCalled(
+		t, "DeleteAllSessions", mock.Anything, mock.Anything,
+	)
+}
+
+func TestResetPassword_VerificationToken(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	svc, tokenRepo, _ := newAuthService(
+		repoMock, new(mocks2.TokenStore), authOptions,
+	)
+
+	token, _, err := jwt.GenerateOneTimeToken(
+		string(models.TokenPurposeEmailVerification),
+	)
+	require.NoError(t, err)
+
+	err = svc.ResetPassword(ctx, token, "43214321")
+	assert.Equal(t, errs.ErrInvalidOneTimeToken, err)
+
+	tokenRepo.AssertNotCalled(
+		t, "Consume", mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
 func generateValidToken(userID int, role string, minutes uint) string {
 	uidStr := strconv.Itoa(userID)
 	token, err := jwt.GenerateJWT(uidStr, minutes, role)
@@ -167,12 +1040,12 @@ func generateValidToken(userID int, role string, minutes uint) string {
 func TestLogoutUser_ParseError(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	invalidAccessToken := "invalid.token"
 	refreshToken := "dummy-refresh-token"
 
-	err := svc.LogoutUser(invalidAccessToken, refreshToken)
+	err := svc.LogoutUser(ctx, invalidAccessToken, refreshToken)
 	assert.Equal(t, errs.ErrTokenParsingFailed, err)
 
 	tokenStoreMock.AssertExpectations(t)
@@ -181,17 +1054,17 @@ func TestLogoutUser_ParseError(t *testing.T) {
 func TestLogoutUser_DeleteError(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	userID := 1
 	accessToken := generateValidToken(userID, string(models.RoleUser), 15)
 	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
 
 	tokenStoreMock.
-		On("DeleteJWTokens", userID, accessToken, refreshToken).
+		On("DeleteJWTokens", mock.Anything, userID, accessToken, refreshToken).
 		Return(errs.ErrTokenDeletionFailed)
 
-	err := svc.LogoutUser(accessToken, refreshToken)
+	err := svc.LogoutUser(ctx, accessToken, refreshToken)
 	assert.Equal(t, errs.ErrTokenDeletionFailed, err)
 
 	tokenStoreMock.AssertExpectations(t)
@@ -200,17 +1073,17 @@ func TestLogoutUser_DeleteError(t *testing.T) {
 func TestLogoutUser_Success(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	userID := 1
 	accessToken := generateValidToken(userID, string(models.RoleUser), 15)
 	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
 
 	tokenStoreMock.
-		On("DeleteJWTokens", userID, accessToken, refreshToken).
+		On("DeleteJWTokens", mock.Anything, userID, accessToken, refreshToken).
 		Return(nil)
 
-	err := svc.LogoutUser(accessToken, refreshToken)
+	err := svc.LogoutUser(ctx, accessToken, refreshToken)
 	require.NoError(t, err)
 
 	tokenStoreMock.AssertExpectations(t)
@@ -219,79 +1092,296 @@ func TestLogoutUser_Success(t *testing.T) {
 func TestRefreshTokens_ParseError(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	invalidRefreshToken := "invalid.token"
-	access, refresh, err := svc.RefreshTokens(invalidRefreshToken)
+	access, refresh, err := svc.RefreshTokens(ctx, invalidRefreshToken)
 	assert.Empty(t, access)
 	assert.Empty(t, refresh)
 	assert.Equal(t, errs.ErrTokenParsingFailed, err)
 }
 
-func TestRefreshTokens_DeleteError(t *testing.T) {
+func TestRefreshTokens_StoreError(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	userID := 1
 	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
 
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: models.RoleUser}, nil)
 	tokenStoreMock.
-		On("DeleteJWToken", userID, refreshToken).
-		Return(errs.ErrTokenDeletionFailed)
+		On(
+			"RotateSession", mock.Anything, sessionOf(userID), refreshToken,
+			mock.Anything, mock.Anything,
+		).
+		Return(false, errs.ErrTokenStorage)
 
-	access, newRefresh, err := svc.RefreshTokens(refreshToken)
+	access, newRefresh, err := svc.RefreshTokens(ctx, refreshToken)
 	assert.Empty(t, access)
 	assert.Empty(t, newRefresh)
-	assert.Equal(t, errs.ErrTokenDeletionFailed, err)
+	assert.Equal(t, errs.ErrTokenStorage, err)
 
 	tokenStoreMock.AssertExpectations(t)
 }
 
-func TestRefreshTokens_SaveError(t *testing.T) {
+func TestRefreshTokens_Success(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	userID := 1
+	oldRefreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
+
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: models.RoleUser}, nil)
+	tokenStoreMock.
+		On(
+			"RotateSession", mock.Anything, sessionOf(userID), oldRefreshToken,
+			mock.Anything, mock.Anything,
+		).
+		Return(true, nil)
+
+	access, newRefresh, err := svc.RefreshTokens(ctx, oldRefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, access)
+	assert.NotEmpty(t, newRefresh)
+
+	tokenStoreMock.AssertExpectations(t)
+}
+
+func TestRefreshTokens_Revoked(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
 
 	userID := 1
 	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
 
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: models.RoleUser}, nil)
 	tokenStoreMock.
-		On("DeleteJWToken", userID, refreshToken).
-		Return(nil)
+		On(
+			"RotateSession", mock.Anything, mock.Anything, refreshToken,
+			mock.Anything, mock.Anything,
+		).
+		Return(false, nil)
+	tokenStoreMock.
+		On("IsRotatedJWToken", mock.Anything, userID, refreshToken).
+		Return(false, nil)
+
+	_, _, err := svc.RefreshTokens(ctx, refreshToken)
+	assert.Equal(t, errs.ErrUnauthorizedToken, err)
+
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestRefreshTokens_KeepsSession(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	userID := 1
+	refreshToken, err := jwt.GenerateSessionJWT(
+		strconv.Itoa(userID), 1440, string(models.RoleUser), nil, "session-1",
+		true,
+	)
+	require.NoError(t, err)
 
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: models.RoleUser}, nil)
 	tokenStoreMock.
-		On("SaveJWTokens", userID, mock.Anything, mock.Anything).
-		Return(errs.ErrTokenStorage)
+		On(
+			"RotateSession", mock.Anything, mock.MatchedBy(
+				func(session *redis.Session) bool {
+					return session.ID == "session-1" && session.MFA
+				},
+			), refreshToken, mock.Anything, mock.Anything,
+		).
+		Return(true, nil)
 
-	access, newRefresh, err := svc.RefreshTokens(refreshToken)
-	assert.Empty(t, access)
-	assert.Empty(t, newRefresh)
-	assert.Equal(t, errs.ErrTokenStorage, err)
+	access, _, err := svc.RefreshTokens(ctx, refreshToken)
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseJWT(access)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.True(t, claims.MFA)
 
 	tokenStoreMock.AssertExpectations(t)
 }
 
-func TestRefreshTokens_Success(t *testing.T) {
+func TestRefreshTokens_ReuseRevokesSession(t *testing.T) {
 	repoMock := new(mocks2.UserRepository)
 	tokenStoreMock := new(mocks2.TokenStore)
-	svc := services.NewAuthService(repoMock, tokenStoreMock)
+	auditor := new(mocks2.Recorder)
+	svc := services.NewAuthService(
+		repoMock, rolesGranting(models.RoleUser),
+		passthroughUnitOfWork(repositories.Repositories{}),
+		tokenStoreMock, openLoginGuard(),
+		mailer.NewWriterMailer(io.Discard, ""), auditor, authOptions,
+	)
 
 	userID := 1
-	oldRefreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
+	refreshToken, err := jwt.GenerateSessionJWT(
+		strconv.Itoa(userID), 1440, string(models.RoleUser), nil, "session-1",
+		false,
+	)
+	require.NoError(t, err)
 
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: models.RoleUser}, nil)
 	tokenStoreMock.
-		On("DeleteJWToken", userID, oldRefreshToken).
-		Return(nil)
+		On(
+			"RotateSession", mock.Anything, mock.Anything, refreshToken,
+			mock.Anything, mock.Anything,
+		).
+		Return(false, nil)
+	tokenStoreMock.
+		On("IsRotatedJWToken", mock.Anything, userID, refreshToken).
+		Return(true, nil)
+	tokenStoreMock.
+		On("DeleteSession", mock.Anything, userID, "session-1").
+		Return(nil).
+		Once()
+	auditor.
+		On(
+			"Record", mock.Anything, mock.MatchedBy(
+				func(event audit.Event) bool {
+					return event.Type == audit.RefreshTokenReuse &&
+						event.UserID == userID &&
+						event.SessionID == "session-1"
+				},
+			),
+		).
+		Once()
+
+	_, _, err = svc.RefreshTokens(ctx, refreshToken)
+	assert.Equal(t, errs.ErrRefreshTokenReused, err)
+
+	tokenStoreMock.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+	tokenStoreMock.AssertNotCalled(
+		t, "SaveSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+}
+
+func TestRefreshTokens_UsesCurrentRole(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc := services.NewAuthService(
+		repoMock,
+		rolesGranting("catalog-manager", models.PermissionItemsWrite),
+		passthroughUnitOfWork(repositories.Repositories{}),
+		tokenStoreMock, openLoginGuard(),
+		mailer.NewWriterMailer(io.Discard, ""), new(mocks2.Recorder),
+		authOptions,
+	)
 
+	userID := 1
+	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
+
+	// The user was made a catalog manager after the token was issued.
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(&models.User{ID: userID, Role: "catalog-manager"}, nil)
 	tokenStoreMock.
-		On("SaveJWTokens", userID, mock.Anything, mock.Anything).
-		Return(nil)
+		On(
+			"RotateSession", mock.Anything, sessionOf(userID), refreshToken,
+			mock.Anything, mock.Anything,
+		).
+		Return(true, nil)
 
-	access, newRefresh, err := svc.RefreshTokens(oldRefreshToken)
+	access, _, err := svc.RefreshTokens(ctx, refreshToken)
 	require.NoError(t, err)
-	assert.NotEmpty(t, access)
-	assert.NotEmpty(t, newRefresh)
+
+	claims, err := jwt.ParseJWT(access)
+	require.NoError(t, err)
+	assert.Equal(t, "catalog-manager", claims.Role)
+	assert.True(t, claims.HasPermission(string(models.PermissionItemsWrite)))
+	assert.False(t, claims.HasPermission(string(models.PermissionUsersRead)))
+}
+
+func TestRefreshTokens_UserDeleted(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	userID := 1
+	refreshToken := generateValidToken(userID, string(models.RoleUser), 1440)
+
+	repoMock.
+		On("GetByID", mock.Anything, userID).
+		Return(nil, errs.ErrUserNotFound)
+
+	_, _, err := svc.RefreshTokens(ctx, refreshToken)
+	assert.Equal(t, errs.ErrUnauthorizedToken, err)
+
+	tokenStoreMock.AssertNotCalled(
+		t, "RotateSession", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything,
+	)
+}
+
+func TestLogoutUser_EndsSession(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	userID := 1
+	accessToken, err := jwt.GenerateSessionJWT(
+		strconv.Itoa(userID), 15, string(models.RoleUser), nil, "session-1",
+		false,
+	)
+	require.NoError(t, err)
+
+	tokenStoreMock.
+		On("DeleteSession", mock.Anything, userID, "session-1").
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.LogoutUser(ctx, accessToken, ""))
+
+	tokenStoreMock.AssertExpectations(t)
+}
+
+func TestRevokeAllSessions_UnknownUser(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	repoMock.
+		On("GetByID", mock.Anything, 42).
+		Return(nil, errs.ErrUserNotFound)
+
+	err := svc.RevokeAllSessions(ctx, 42)
+	assert.Equal(t, errs.ErrUserNotFound, err)
+
+	tokenStoreMock.AssertNotCalled(t, "DeleteAllSessions", mock.Anything, 42)
+}
+
+func TestRevokeAllSessions_Success(t *testing.T) {
+	repoMock := new(mocks2.UserRepository)
+	tokenStoreMock := new(mocks2.TokenStore)
+	svc, _, _ := newAuthService(repoMock, tokenStoreMock, authOptions)
+
+	repoMock.On("GetByID", mock.Anything, martinUser.ID).Return(martinUser, nil)
+	tokenStoreMock.
+		On("DeleteAllSessions", mock.Anything, martinUser.ID).
+		Return(nil).
+		Once()
+
+	require.NoError(t, svc.RevokeAllSessions(ctx, martinUser.ID))
 
 	tokenStoreMock.AssertExpectations(t)
 }
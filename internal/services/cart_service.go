@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
 	repo "github.com/DaniilKalts/market-rest-api/internal/repositories"
@@ -10,33 +13,52 @@ import (
 )
 
 type CartService interface {
-	AddItem(cartID int, itemID int) (*models.CartItem, error)
-	GetCartByUserID(cartID int) (*models.Cart, error)
-	UpdateItem(cartID int, itemID int, quantity uint) (*models.CartItem, error)
-	DeleteItem(cartID int, itemID int) error
-	ClearCart(cartID int) error
+	AddItem(
+		ctx context.Context, cartID int, itemID int,
+	) (*models.CartItem, error)
+	GetCartByUserID(ctx context.Context, cartID int) (*models.Cart, error)
+	UpdateItem(
+		ctx context.Context, cartID int, itemID int, quantity uint,
+	) (*models.CartItem, error)
+	DeleteItem(ctx context.Context, cartID int, itemID int) error
+	ClearCart(ctx context.Context, cartID int) error
 }
 
 type cartService struct {
-	repo        repo.CartRepository
-	itemService ItemService
+	repo           repo.CartRepository
+	itemService    ItemService
+	reservationTTL time.Duration
 }
 
+// NewCartService creates the cart service. Every cart line holds a stock
+// reservation that lasts reservationTTL after the line was last changed.
 func NewCartService(
 	repo repo.CartRepository,
 	itemService ItemService,
+	reservationTTL time.Duration,
 ) CartService {
 	return &cartService{
-		repo:        repo,
-		itemService: itemService,
+		repo:           repo,
+		itemService:    itemService,
+		reservationTTL: reservationTTL,
 	}
 }
 
-func (s *cartService) AddItem(cartID int, itemID int) (
+// stockShortage extracts the shortage reported by the repository when a
+// cart line would exceed the stock that is not reserved by other carts.
+func stockShortage(err error) (*errs.StockShortage, bool) {
+	var stockErr *errs.InsufficientStockError
+	if !errors.As(err, &stockErr) || len(stockErr.Lines) == 0 {
+		return nil, false
+	}
+	return &stockErr.Lines[0], true
+}
+
+func (s *cartService) AddItem(ctx context.Context, cartID int, itemID int) (
 	*models.CartItem,
 	error,
 ) {
-	item, err := s.itemService.GetItemByID(itemID)
+	item, err := s.itemService.GetItemByID(ctx, itemID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,52 +66,63 @@ func (s *cartService) AddItem(cartID int, itemID int) (
 		return nil, errs.ErrItemNotFound
 	}
 
-	existingCartItem, err := s.repo.GetCartItem(cartID, itemID)
-	currentQuantity := uint(0)
-	if err == nil && existingCartItem != nil {
-		currentQuantity = existingCartItem.Quantity
-	}
-
-	if currentQuantity+1 > item.Stock {
+	cartItem, err := s.repo.Add(ctx, cartID, itemID, s.reservationTTL)
+	if shortage, ok := stockShortage(err); ok {
 		return nil, fmt.Errorf(
-			"available stock is %d and you already have %d in your cart",
-			item.Stock, currentQuantity,
+			"%w: available stock is %d and you already have %d in your cart",
+			errs.ErrInsufficientStock, shortage.Available,
+			shortage.Requested-1,
 		)
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return s.repo.Add(cartID, itemID)
+	return cartItem, nil
 }
 
-func (s *cartService) GetCartByUserID(userID int) (*models.Cart, error) {
-	return s.repo.GetByUserID(userID)
+func (s *cartService) GetCartByUserID(
+	ctx context.Context, userID int,
+) (*models.Cart, error) {
+	return s.repo.GetByUserID(ctx, userID)
 }
 
 func (s *cartService) UpdateItem(
+	ctx context.Context,
 	cartID int,
 	itemID int,
 	quantity uint,
 ) (*models.CartItem, error) {
-	item, err := s.itemService.GetItemByID(itemID)
+	item, err := s.itemService.GetItemByID(ctx, itemID)
 	if err != nil {
 		return nil, err
 	}
 	if item == nil {
 		return nil, errs.ErrItemNotFound
 	}
-	if quantity > item.Stock {
+
+	cartItem, err := s.repo.Update(
+		ctx, cartID, itemID, quantity, s.reservationTTL,
+	)
+	if shortage, ok := stockShortage(err); ok {
 		return nil, fmt.Errorf(
-			"requested quantity %d exceeds available stock %d", quantity,
-			item.Stock,
+			"%w: requested quantity %d exceeds available stock %d",
+			errs.ErrInsufficientStock, quantity, shortage.Available,
 		)
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return s.repo.Update(cartID, itemID, quantity)
+	return cartItem, nil
 }
 
-func (s *cartService) DeleteItem(cartID int, itemID int) error {
-	return s.repo.Delete(cartID, itemID)
+func (s *cartService) DeleteItem(
+	ctx context.Context, cartID int, itemID int,
+) error {
+	return s.repo.Delete(ctx, cartID, itemID)
 }
 
-func (s *cartService) ClearCart(cartID int) error {
-	return s.repo.Clear(cartID)
+func (s *cartService) ClearCart(ctx context.Context, cartID int) error {
+	return s.repo.Clear(ctx, cartID)
 }
@@ -1,12 +1,15 @@
 package services_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/DaniilKalts/market-rest-api/internal/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 
 	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
 
@@ -19,35 +22,42 @@ type itemServiceStub struct {
 	err  error
 }
 
-func (s *itemServiceStub) CreateItem(item *models.Item) error {
+func (s *itemServiceStub) CreateItem(
+	ctx context.Context, item *models.Item,
+) error {
 	return nil
 }
 
-func (s *itemServiceStub) GetItemByID(id int) (
-	*models.Item,
-	error,
-) {
+func (s *itemServiceStub) GetItemByID(
+	ctx context.Context, id int,
+) (*models.Item, error) {
 	return s.item, s.err
 }
 
-func (s *itemServiceStub) GetAllItems() (
-	[]models.Item,
-	error,
-) {
+func (s *itemServiceStub) GetAllItems(
+	ctx context.Context, opts *models.ItemQueryOptions,
+) (*models.ItemPage, error) {
+	return nil, nil
+}
+
+func (s *itemServiceStub) SearchItems(
+	ctx context.Context, opts *models.ItemSearchOptions,
+) (*models.ItemSearchPage, error) {
 	return nil, nil
 }
 
 func (s *itemServiceStub) UpdateItem(
-	id int,
-	updateItemDTO *models.UpdateItem,
+	ctx context.Context, id int, updateItemDTO *models.UpdateItem,
 ) (*models.Item, error) {
 	return nil, nil
 }
 
-func (s *itemServiceStub) DeleteItem(id int) error {
+func (s *itemServiceStub) DeleteItem(ctx context.Context, id int) error {
 	return nil
 }
 
+const reservationTTL = 15 * time.Minute
+
 var (
 	sampleCartItem = &models.CartItem{
 		CartID:    1,
@@ -69,9 +79,9 @@ func TestAddItem_Err(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	someErr := fmt.Errorf("service error")
 	itemService := &itemServiceStub{item: nil, err: someErr}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	cartItem, err := cartService.AddItem(1, 42)
+	cartItem, err := cartService.AddItem(ctx, 1, 42)
 	assert.Nil(t, cartItem)
 	assert.EqualError(t, err, someErr.Error())
 
@@ -81,9 +91,9 @@ func TestAddItem_Err(t *testing.T) {
 func TestAddItem_NotFound(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{item: nil, err: nil}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	cartItem, err := cartService.AddItem(1, 42)
+	cartItem, err := cartService.AddItem(ctx, 1, 42)
 	assert.Nil(t, cartItem)
 	assert.EqualError(t, err, errs.ErrItemNotFound.Error())
 
@@ -93,14 +103,11 @@ func TestAddItem_NotFound(t *testing.T) {
 func TestAddItem_Success(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{item: sampleItem, err: nil}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	mockRepo.On("GetCartItem", 1, 42).Return(
-		nil, errors.New("not found"),
-	).Once()
-	mockRepo.On("Add", 1, 42).Return(sampleCartItem, nil).Once()
+	mockRepo.On("Add", mock.Anything, 1, 42, reservationTTL).Return(sampleCartItem, nil).Once()
 
-	cartItem, err := cartService.AddItem(1, 42)
+	cartItem, err := cartService.AddItem(ctx, 1, 42)
 	assert.NoError(t, err)
 	assert.Equal(t, sampleCartItem, cartItem)
 
@@ -114,31 +121,51 @@ func TestAddItem_ExceedStock(t *testing.T) {
 			ID: 42, Name: "Test Item", Stock: 3,
 		},
 	}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	existing := &models.CartItem{
-		CartID: 1, ItemID: 42, Quantity: 3, CreatedAt: now, UpdatedAt: now,
-	}
-	mockRepo.On("GetCartItem", 1, 42).Return(existing, nil).Once()
+	mockRepo.On("Add", mock.Anything, 1, 42, reservationTTL).Return(
+		nil, &errs.InsufficientStockError{
+			Lines: []errs.StockShortage{
+				{ItemID: 42, Name: "Test Item", Requested: 4, Available: 3},
+			},
+		},
+	).Once()
 
-	cartItem, err := cartService.AddItem(1, 42)
+	cartItem, err := cartService.AddItem(ctx, 1, 42)
 	assert.Nil(t, cartItem)
+	require.ErrorIs(t, err, errs.ErrInsufficientStock)
 	expectedErrMsg := fmt.Sprintf(
-		"available stock is %d and you already have %d in your cart", 3, 3,
+		"%s: available stock is %d and you already have %d in your cart",
+		errs.ErrInsufficientStock, 3, 3,
 	)
 	assert.EqualError(t, err, expectedErrMsg)
 
 	mockRepo.AssertExpectations(t)
 }
 
+func TestAddItem_RepoErr(t *testing.T) {
+	mockRepo := new(mocks.CartRepository)
+	itemService := &itemServiceStub{item: sampleItem, err: nil}
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
+
+	someErr := errors.New("deadlock detected")
+	mockRepo.On("Add", mock.Anything, 1, 42, reservationTTL).Return(nil, someErr).Once()
+
+	cartItem, err := cartService.AddItem(ctx, 1, 42)
+	assert.Nil(t, cartItem)
+	assert.Equal(t, someErr, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetCartByUserID_Success(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	mockRepo.On("GetByUserID", 1).Return(sampleCart, nil).Once()
+	mockRepo.On("GetByUserID", mock.Anything, 1).Return(sampleCart, nil).Once()
 
-	cart, err := cartService.GetCartByUserID(1)
+	cart, err := cartService.GetCartByUserID(ctx, 1)
 	require.NoError(t, err)
 	assert.Equal(t, sampleCart, cart)
 
@@ -148,7 +175,7 @@ func TestGetCartByUserID_Success(t *testing.T) {
 func TestUpdateItem_Success(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{item: sampleItem}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
 	updated := &models.CartItem{
 		CartID:    sampleCartItem.CartID,
@@ -157,9 +184,10 @@ func TestUpdateItem_Success(t *testing.T) {
 		CreatedAt: sampleCartItem.CreatedAt,
 		UpdatedAt: sampleCartItem.UpdatedAt,
 	}
-	mockRepo.On("Update", 1, 42, uint(4)).Return(updated, nil).Once()
+	mockRepo.On("Update", mock.Anything, 1, 42, uint(4), reservationTTL).
+		Return(updated, nil).Once()
 
-	result, err := cartService.UpdateItem(1, 42, 4)
+	result, err := cartService.UpdateItem(ctx, 1, 42, 4)
 	require.NoError(t, err)
 	assert.Equal(t, updated, result)
 
@@ -173,36 +201,50 @@ func TestUpdateItem_ExceedStock(t *testing.T) {
 			ID: 42, Name: "Test Item", Stock: 5,
 		},
 	}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
+
+	mockRepo.On("Update", mock.Anything, 1, 42, uint(6), reservationTTL).Return(
+		nil, &errs.InsufficientStockError{
+			Lines: []errs.StockShortage{
+				{ItemID: 42, Name: "Test Item", Requested: 6, Available: 5},
+			},
+		},
+	).Once()
 
-	result, err := cartService.UpdateItem(1, 42, 6)
+	result, err := cartService.UpdateItem(ctx, 1, 42, 6)
 	assert.Nil(t, result)
+	require.ErrorIs(t, err, errs.ErrInsufficientStock)
 	expectedErrMsg := fmt.Sprintf(
-		"requested quantity %d exceeds available stock %d", 6, 5,
+		"%s: requested quantity %d exceeds available stock %d",
+		errs.ErrInsufficientStock, 6, 5,
 	)
 	assert.EqualError(t, err, expectedErrMsg)
-	mockRepo.AssertNotCalled(t, "Update")
+
+	mockRepo.AssertExpectations(t)
 }
 
 func TestUpdateItem_Err(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	someErr := fmt.Errorf("service error")
 	itemService := &itemServiceStub{item: nil, err: someErr}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	cartItem, err := cartService.UpdateItem(1, 42, 6)
+	cartItem, err := cartService.UpdateItem(ctx, 1, 42, 6)
 	assert.Nil(t, cartItem)
 	assert.EqualError(t, err, someErr.Error())
 
-	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(
+		t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything,
+	)
 }
 
 func TestUpdateItem_NotFound(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{item: nil, err: nil}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	cartItem, err := cartService.UpdateItem(1, 42, 6)
+	cartItem, err := cartService.UpdateItem(ctx, 1, 42, 6)
 	assert.Nil(t, cartItem)
 	assert.EqualError(t, err, errs.ErrItemNotFound.Error())
 
@@ -212,10 +254,10 @@ func TestUpdateItem_NotFound(t *testing.T) {
 func TestDeleteItem_Success(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	mockRepo.On("Delete", 1, 42).Return(nil).Once()
-	err := cartService.DeleteItem(1, 42)
+	mockRepo.On("Delete", mock.Anything, 1, 42).Return(nil).Once()
+	err := cartService.DeleteItem(ctx, 1, 42)
 	require.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
@@ -224,10 +266,10 @@ func TestDeleteItem_Success(t *testing.T) {
 func TestClearCart_Success(t *testing.T) {
 	mockRepo := new(mocks.CartRepository)
 	itemService := &itemServiceStub{}
-	cartService := services.NewCartService(mockRepo, itemService)
+	cartService := services.NewCartService(mockRepo, itemService, reservationTTL)
 
-	mockRepo.On("Clear", 1).Return(nil).Once()
-	err := cartService.ClearCart(1)
+	mockRepo.On("Clear", mock.Anything, 1).Return(nil).Once()
+	err := cartService.ClearCart(ctx, 1)
 	require.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
@@ -1,18 +1,33 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
 
 	"github.com/DaniilKalts/market-rest-api/internal/models"
 	"github.com/DaniilKalts/market-rest-api/internal/repositories"
 )
 
 type ItemService interface {
-	CreateItem(item *models.Item) error
-	GetItemByID(id int) (*models.Item, error)
-	GetAllItems() ([]models.Item, error)
-	UpdateItem(id int, item *models.UpdateItem) (*models.Item, error)
-	DeleteItem(id int) error
+	CreateItem(ctx context.Context, item *models.Item) error
+	GetItemByID(ctx context.Context, id int) (*models.Item, error)
+	GetAllItems(
+		ctx context.Context, opts *models.ItemQueryOptions,
+	) (*models.ItemPage, error)
+	SearchItems(
+		ctx context.Context, opts *models.ItemSearchOptions,
+	) (*models.ItemSearchPage, error)
+	UpdateItem(
+		ctx context.Context, id int, item *models.UpdateItem,
+	) (*models.Item, error)
+	DeleteItem(ctx context.Context, id int) error
 }
 
 type itemService struct {
@@ -23,23 +38,153 @@ func NewItemService(repo repositories.ItemRepository) ItemService {
 	return &itemService{repo: repo}
 }
 
-func (s *itemService) CreateItem(item *models.Item) error {
-	return s.repo.Create(item)
+func (s *itemService) CreateItem(ctx context.Context, item *models.Item) error {
+	return s.repo.Create(ctx, item)
+}
+
+func (s *itemService) GetItemByID(
+	ctx context.Context, id int,
+) (*models.Item, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func encodeItemCursor(cursor models.ItemCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeItemCursor(encoded string) (*models.ItemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	var cursor models.ItemCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	return &cursor, nil
 }
 
-func (s *itemService) GetItemByID(id int) (*models.Item, error) {
-	return s.repo.GetByID(id)
+func itemCursorFor(
+	item models.Item, field models.ItemSortField, order models.SortOrder,
+) models.ItemCursor {
+	cursor := models.ItemCursor{SortBy: field, Order: order, ID: item.ID}
+
+	switch field {
+	case models.ItemSortName:
+		cursor.Value = item.Name
+	case models.ItemSortPrice:
+		cursor.Value = strconv.FormatUint(uint64(item.Price), 10)
+	case models.ItemSortStock:
+		cursor.Value = strconv.FormatUint(uint64(item.Stock), 10)
+	default:
+		cursor.Value = item.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return cursor
 }
 
-func (s *itemService) GetAllItems() ([]models.Item, error) {
-	return s.repo.GetAll()
+func (s *itemService) GetAllItems(
+	ctx context.Context, opts *models.ItemQueryOptions,
+) (*models.ItemPage, error) {
+	query := *opts
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultItemLimit
+	}
+	if query.Limit > models.MaxItemLimit {
+		query.Limit = models.MaxItemLimit
+	}
+	if query.SortBy == "" {
+		query.SortBy = models.ItemSortCreatedAt
+	}
+	if query.Order == "" {
+		query.Order = models.SortAsc
+	}
+	if query.Cursor != "" {
+		after, err := decodeItemCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.SortBy != query.SortBy || after.Order != query.Order {
+			return nil, errs.ErrCursorMismatch
+		}
+		query.After = after
+		query.Page = 0
+	} else if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	limit := query.Limit
+	// One extra row tells us whether there is a next page without a
+	// second query.
+	query.Limit = limit + 1
+
+	items, total, err := s.repo.GetAll(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ItemPage{
+		Items: items,
+		Total: total,
+		Page:  query.Page,
+		Limit: limit,
+	}
+	if page.Items == nil {
+		page.Items = []models.Item{}
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeItemCursor(
+			itemCursorFor(page.Items[limit-1], query.SortBy, query.Order),
+		)
+	}
+
+	return page, nil
+}
+
+func (s *itemService) SearchItems(
+	ctx context.Context, opts *models.ItemSearchOptions,
+) (*models.ItemSearchPage, error) {
+	query := *opts
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, errs.ErrEmptySearchQuery
+	}
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultItemLimit
+	}
+	if query.Limit > models.MaxItemLimit {
+		query.Limit = models.MaxItemLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	results, err := s.repo.Search(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []models.ItemSearchResult{}
+	}
+
+	return &models.ItemSearchPage{
+		Results: results,
+		Page:    query.Page,
+		Limit:   query.Limit,
+	}, nil
 }
 
 func (s *itemService) UpdateItem(
+	ctx context.Context,
 	id int,
 	updateItemDTO *models.UpdateItem,
 ) (*models.Item, error) {
-	existingItem, err := s.repo.GetByID(id)
+	existingItem, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -60,13 +205,13 @@ func (s *itemService) UpdateItem(
 		existingItem.Stock = *updateItemDTO.Stock
 	}
 
-	err = s.repo.Update(existingItem)
+	err = s.repo.Update(ctx, existingItem)
 	if err != nil {
 		return nil, err
 	}
 	return existingItem, nil
 }
 
-func (s *itemService) DeleteItem(id int) error {
-	return s.repo.Delete(id)
+func (s *itemService) DeleteItem(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
@@ -1,6 +1,7 @@
 package services_test
 
 import (
+	"context"
 	"errors"
 	"github.com/DaniilKalts/market-rest-api/internal/mocks"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +19,8 @@ import (
 
 var now = time.Now()
 
+var ctx = context.Background()
+
 var sampleItem = &models.Item{
 	ID:          1,
 	Name:        "T-shirt",
@@ -31,10 +34,10 @@ var sampleItem = &models.Item{
 func TestItem_Create_Success(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
-	mockRepo.On("Create", sampleItem).Return(nil).Once()
+	mockRepo.On("Create", mock.Anything, sampleItem).Return(nil).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	err := itemService.CreateItem(sampleItem)
+	err := itemService.CreateItem(ctx, sampleItem)
 	require.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
@@ -44,10 +47,10 @@ func TestItem_Create_Error(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
 	expectedErr := errors.New("create error")
-	mockRepo.On("Create", sampleItem).Return(expectedErr).Once()
+	mockRepo.On("Create", mock.Anything, sampleItem).Return(expectedErr).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	err := itemService.CreateItem(sampleItem)
+	err := itemService.CreateItem(ctx, sampleItem)
 	require.Error(t, err)
 	assert.EqualError(t, err, expectedErr.Error())
 
@@ -57,10 +60,10 @@ func TestItem_Create_Error(t *testing.T) {
 func TestItem_GetByID_Success(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
-	mockRepo.On("GetByID", sampleItem.ID).Return(sampleItem, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, sampleItem.ID).Return(sampleItem, nil).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	result, err := itemService.GetItemByID(sampleItem.ID)
+	result, err := itemService.GetItemByID(ctx, sampleItem.ID)
 	require.NoError(t, err)
 	assert.Equal(t, sampleItem, result)
 
@@ -72,10 +75,10 @@ func TestItem_GetByID_Error(t *testing.T) {
 
 	id := 42
 	repoErr := errs.ErrItemNotFound
-	mockRepo.On("GetByID", id).Return(nil, repoErr).Once()
+	mockRepo.On("GetByID", mock.Anything, id).Return(nil, repoErr).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	result, err := itemService.GetItemByID(id)
+	result, err := itemService.GetItemByID(ctx, id)
 	require.Error(t, err)
 	assert.Nil(t, result)
 	assert.EqualError(t, err, repoErr.Error())
@@ -98,16 +101,219 @@ func TestItem_GetAll_Success(t *testing.T) {
 	}
 
 	mockRepo := new(mocks.ItemRepository)
-	mockRepo.On("GetAll").Return(expectedItems, nil).Once()
+	mockRepo.On(
+		"GetAll", mock.Anything, mock.MatchedBy(
+			func(opts *models.ItemQueryOptions) bool {
+				return opts.Limit == models.DefaultItemLimit+1 &&
+					opts.Page == 1 &&
+					opts.SortBy == models.ItemSortCreatedAt &&
+					opts.Order == models.SortAsc
+			},
+		),
+	).Return(expectedItems, int64(2), nil).Once()
+
+	itemService := services.NewItemService(mockRepo)
+	page, err := itemService.GetAllItems(ctx, &models.ItemQueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expectedItems, page.Items)
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, models.DefaultItemLimit, page.Limit)
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestItem_GetAll_HasMore(t *testing.T) {
+	expectedItems := []models.Item{
+		{ID: 1, Name: "T-shirt", Price: 30},
+		{ID: 2, Name: "Sweater", Price: 50},
+		{ID: 3, Name: "Hoodie", Price: 70},
+	}
+
+	mockRepo := new(mocks.ItemRepository)
+	mockRepo.On(
+		"GetAll", mock.Anything, mock.AnythingOfType("*models.ItemQueryOptions"),
+	).Return(expectedItems, int64(5), nil).Once()
+
+	itemService := services.NewItemService(mockRepo)
+	page, err := itemService.GetAllItems(
+		ctx, &models.ItemQueryOptions{Limit: 2, SortBy: models.ItemSortPrice},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expectedItems[:2], page.Items)
+	assert.True(t, page.HasMore)
+	assert.NotEmpty(t, page.NextCursor)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestItem_GetAll_Cursor(t *testing.T) {
+	firstRepo := new(mocks.ItemRepository)
+	firstRepo.On(
+		"GetAll", mock.Anything, mock.AnythingOfType("*models.ItemQueryOptions"),
+	).Return(
+		[]models.Item{{ID: 1, Price: 30}, {ID: 2, Price: 50}}, int64(2), nil,
+	).Once()
+
+	firstPage, err := services.NewItemService(firstRepo).GetAllItems(
+		ctx,
+		&models.ItemQueryOptions{Limit: 1, SortBy: models.ItemSortPrice},
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, firstPage.NextCursor)
+
+	secondRepo := new(mocks.ItemRepository)
+	secondRepo.On(
+		"GetAll", mock.Anything, mock.MatchedBy(
+			func(opts *models.ItemQueryOptions) bool {
+				return opts.After != nil &&
+					opts.After.ID == 1 &&
+					opts.After.Value == "30" &&
+					opts.Page == 0
+			},
+		),
+	).Return([]models.Item{{ID: 2, Price: 50}}, int64(2), nil).Once()
+
+	secondPage, err := services.NewItemService(secondRepo).GetAllItems(
+		ctx,
+		&models.ItemQueryOptions{
+			Limit:  1,
+			SortBy: models.ItemSortPrice,
+			Cursor: firstPage.NextCursor,
+		},
+	)
+	require.NoError(t, err)
+	assert.False(t, secondPage.HasMore)
+	assert.Len(t, secondPage.Items, 1)
+
+	secondRepo.AssertExpectations(t)
+}
+
+func TestItem_GetAll_InvalidCursor(t *testing.T) {
+	mockRepo := new(mocks.ItemRepository)
+
+	itemService := services.NewItemService(mockRepo)
+	page, err := itemService.GetAllItems(
+		ctx, &models.ItemQueryOptions{Cursor: "not a cursor"},
+	)
+	require.Error(t, err)
+	assert.Nil(t, page)
+	assert.Equal(t, errs.ErrInvalidCursor, err)
+
+	mockRepo.AssertNotCalled(t, "GetAll")
+}
+
+func TestItem_GetAll_CursorOfOtherSort(t *testing.T) {
+	firstRepo := new(mocks.ItemRepository)
+	firstRepo.On(
+		"GetAll", mock.Anything, mock.AnythingOfType("*models.ItemQueryOptions"),
+	).Return(
+		[]models.Item{{ID: 1, Price: 30}, {ID: 2, Price: 50}}, int64(2), nil,
+	).Once()
+
+	firstPage, err := services.NewItemService(firstRepo).GetAllItems(
+		ctx,
+		&models.ItemQueryOptions{Limit: 1, SortBy: models.ItemSortPrice},
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, firstPage.NextCursor)
+
+	tests := []struct {
+		name   string
+		sortBy models.ItemSortField
+		order  models.SortOrder
+	}{
+		{name: "other field", sortBy: models.ItemSortName},
+		{name: "default field"},
+		{
+			name:   "other order",
+			sortBy: models.ItemSortPrice,
+			order:  models.SortDesc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.ItemRepository)
+
+			page, err := services.NewItemService(mockRepo).GetAllItems(
+				ctx, &models.ItemQueryOptions{
+					Limit:  1,
+					SortBy: tt.sortBy,
+					Order:  tt.order,
+					Cursor: firstPage.NextCursor,
+				},
+			)
+			assert.Nil(t, page)
+			assert.Equal(t, errs.ErrCursorMismatch, err)
+
+			mockRepo.AssertNotCalled(t, "GetAll")
+		})
+	}
+}
+
+func TestItem_GetAll_Error(t *testing.T) {
+	mockRepo := new(mocks.ItemRepository)
+
+	expectedErr := errors.New("get all error")
+	mockRepo.On(
+		"GetAll", mock.Anything, mock.AnythingOfType("*models.ItemQueryOptions"),
+	).Return(nil, int64(0), expectedErr).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	items, err := itemService.GetAllItems()
+	page, err := itemService.GetAllItems(ctx, &models.ItemQueryOptions{})
+	require.Error(t, err)
+	assert.Nil(t, page)
+	assert.EqualError(t, err, expectedErr.Error())
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestItem_Search_Success(t *testing.T) {
+	expected := []models.ItemSearchResult{
+		{
+			Item:    *sampleItem,
+			Rank:    0.6,
+			Snippet: "<mark>T-shirt</mark>: A premium quality T-shirt",
+		},
+	}
+
+	mockRepo := new(mocks.ItemRepository)
+	mockRepo.On(
+		"Search", mock.Anything, mock.MatchedBy(
+			func(opts *models.ItemSearchOptions) bool {
+				return opts.Query == "t-shirt" &&
+					opts.Page == 1 &&
+					opts.Limit == models.DefaultItemLimit
+			},
+		),
+	).Return(expected, nil).Once()
+
+	itemService := services.NewItemService(mockRepo)
+	page, err := itemService.SearchItems(
+		ctx, &models.ItemSearchOptions{Query: "  t-shirt "},
+	)
 	require.NoError(t, err)
-	assert.Equal(t, expectedItems, items)
+	assert.Equal(t, expected, page.Results)
+	assert.Equal(t, 1, page.Page)
 
 	mockRepo.AssertExpectations(t)
 }
 
+func TestItem_Search_EmptyQuery(t *testing.T) {
+	mockRepo := new(mocks.ItemRepository)
+
+	itemService := services.NewItemService(mockRepo)
+	page, err := itemService.SearchItems(ctx, &models.ItemSearchOptions{Query: "   "})
+	require.Error(t, err)
+	assert.Nil(t, page)
+	assert.Equal(t, errs.ErrEmptySearchQuery, err)
+
+	mockRepo.AssertNotCalled(t, "Search")
+}
+
 func TestItem_Update_Success(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
@@ -118,13 +324,13 @@ func TestItem_Update_Success(t *testing.T) {
 		Stock:       ptrUint(15),
 	}
 
-	mockRepo.On("GetByID", sampleItem.ID).Return(sampleItem, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, sampleItem.ID).Return(sampleItem, nil).Once()
 	mockRepo.On(
-		"Update", mock.AnythingOfType("*models.Item"),
+		"Update", mock.Anything, mock.AnythingOfType("*models.Item"),
 	).Return(nil).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	updatedItem, err := itemService.UpdateItem(sampleItem.ID, updateDTO)
+	updatedItem, err := itemService.UpdateItem(ctx, sampleItem.ID, updateDTO)
 	require.NoError(t, err)
 	assert.Equal(t, "T-shirt Updated", updatedItem.Name)
 	assert.Equal(t, "Updated description.", updatedItem.Description)
@@ -139,14 +345,14 @@ func TestItem_Update_GetByIDError(t *testing.T) {
 
 	id := sampleItem.ID
 	expectedErr := errors.New("get error")
-	mockRepo.On("GetByID", id).Return(nil, expectedErr).Once()
+	mockRepo.On("GetByID", mock.Anything, id).Return(nil, expectedErr).Once()
 
 	updateDTO := &models.UpdateItem{
 		Name: ptr("T-shirt Updated"),
 	}
 
 	itemService := services.NewItemService(mockRepo)
-	updatedItem, err := itemService.UpdateItem(id, updateDTO)
+	updatedItem, err := itemService.UpdateItem(ctx, id, updateDTO)
 	require.Error(t, err)
 	assert.Nil(t, updatedItem)
 	assert.EqualError(t, err, expectedErr.Error())
@@ -158,14 +364,14 @@ func TestItem_Update_ItemNotFound(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
 	id := 42
-	mockRepo.On("GetByID", id).Return(nil, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, id).Return(nil, nil).Once()
 
 	updateDTO := &models.UpdateItem{
 		Name: ptr("T-shirt Updated"),
 	}
 
 	itemService := services.NewItemService(mockRepo)
-	updatedItem, err := itemService.UpdateItem(id, updateDTO)
+	updatedItem, err := itemService.UpdateItem(ctx, id, updateDTO)
 	require.Error(t, err)
 	assert.Nil(t, updatedItem)
 	assert.EqualError(t, err, "item not found")
@@ -177,15 +383,15 @@ func TestItem_Update_UpdateError(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 
 	updateDTO := &models.UpdateItem{Name: ptr("T-shirt Updated")}
-	mockRepo.On("GetByID", sampleItem.ID).Return(sampleItem, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, sampleItem.ID).Return(sampleItem, nil).Once()
 
 	expectedErr := errors.New("update error")
 	mockRepo.On(
-		"Update", mock.AnythingOfType("*models.Item"),
+		"Update", mock.Anything, mock.AnythingOfType("*models.Item"),
 	).Return(expectedErr).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	updatedItem, err := itemService.UpdateItem(sampleItem.ID, updateDTO)
+	updatedItem, err := itemService.UpdateItem(ctx, sampleItem.ID, updateDTO)
 	require.Error(t, err)
 	assert.Nil(t, updatedItem)
 	assert.EqualError(t, err, expectedErr.Error())
@@ -195,10 +401,10 @@ func TestItem_Update_UpdateError(t *testing.T) {
 
 func TestItem_Delete_Success(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
-	mockRepo.On("Delete", sampleItem.ID).Return(nil).Once()
+	mockRepo.On("Delete", mock.Anything, sampleItem.ID).Return(nil).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	err := itemService.DeleteItem(sampleItem.ID)
+	err := itemService.DeleteItem(ctx, sampleItem.ID)
 	require.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
@@ -207,10 +413,10 @@ func TestItem_Delete_Success(t *testing.T) {
 func TestItem_Delete_Error(t *testing.T) {
 	mockRepo := new(mocks.ItemRepository)
 	expectedErr := errors.New("delete error")
-	mockRepo.On("Delete", sampleItem.ID).Return(expectedErr).Once()
+	mockRepo.On("Delete", mock.Anything, sampleItem.ID).Return(expectedErr).Once()
 
 	itemService := services.NewItemService(mockRepo)
-	err := itemService.DeleteItem(sampleItem.ID)
+	err := itemService.DeleteItem(ctx, sampleItem.ID)
 	require.Error(t, err)
 	assert.EqualError(t, err, expectedErr.Error())
 
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+type OrderService interface {
+	Checkout(ctx context.Context, userID int) (*models.Order, error)
+	GetOrdersByUserID(ctx context.Context, userID int) ([]models.Order, error)
+	GetUserOrderByID(
+		ctx context.Context, userID int, orderID int,
+	) (*models.Order, error)
+	CancelOrder(
+		ctx context.Context, userID int, orderID int,
+	) (*models.Order, error)
+	UpdateOrderStatus(
+		ctx context.Context,
+		adminID int, orderID int, update *models.UpdateOrderStatus,
+	) (*models.Order, error)
+}
+
+type orderService struct {
+	repo     repositories.OrderRepository
+	userRepo repositories.UserRepository
+
+	requireVerifiedEmail bool
+}
+
+func NewOrderService(
+	repo repositories.OrderRepository,
+	userRepo repositories.UserRepository,
+	requireVerifiedEmail bool,
+) OrderService {
+	return &orderService{
+		repo:                 repo,
+		userRepo:             userRepo,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
+}
+
+func (s *orderService) Checkout(
+	ctx context.Context, userID int,
+) (*models.Order, error) {
+	if s.requireVerifiedEmail {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !user.EmailVerified {
+			return nil, errs.ErrEmailNotVerified
+		}
+	}
+
+	return s.repo.CreateFromCart(ctx, userID)
+}
+
+func (s *orderService) GetOrdersByUserID(
+	ctx context.Context, userID int,
+) ([]models.Order, error) {
+	orders, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
+	return orders, nil
+}
+
+func (s *orderService) GetUserOrderByID(
+	ctx context.Context, userID int, orderID int,
+) (*models.Order, error) {
+	order, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	// Orders of other users are reported as missing rather than forbidden
+	// so their ids cannot be probed.
+	if order.UserID != userID {
+		return nil, errs.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+func (s *orderService) CancelOrder(
+	ctx context.Context, userID int, orderID int,
+) (*models.Order, error) {
+	order, err := s.GetUserOrderByID(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransition(order.Status, models.OrderStatusCancelled) {
+		return nil, errs.ErrOrderNotCancellable
+	}
+
+	order, err = s.repo.UpdateStatus(
+		ctx, orderID, models.OrderStatusCancelled, &userID,
+		"cancelled by customer",
+	)
+	// The order may have been paid between the check above and the locked
+	// update in the repository.
+	if errors.Is(err, errs.ErrInvalidOrderTransition) {
+		return nil, errs.ErrOrderNotCancellable
+	}
+
+	return order, err
+}
+
+func (s *orderService) UpdateOrderStatus(
+	ctx context.Context,
+	adminID int, orderID int, update *models.UpdateOrderStatus,
+) (*models.Order, error) {
+	// Marking an order paid or refunded here would skip the payment record
+	// and the provider refund.
+	if models.IsPaymentStatus(update.Status) {
+		return nil, errs.ErrInvalidOrderTransition
+	}
+
+	return s.repo.UpdateStatus(
+		ctx, orderID, update.Status, &adminID, update.Note,
+	)
+}
@@ -0,0 +1,231 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/mocks"
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+)
+
+var sampleOrder = &models.Order{
+	ID:     1,
+	UserID: 1,
+	Status: models.OrderStatusPending,
+	Total:  60,
+	Items: []models.OrderItem{
+		{
+			ID:       1,
+			OrderID:  1,
+			ItemID:   &sampleItem.ID,
+			Name:     sampleItem.Name,
+			Price:    sampleItem.Price,
+			Quantity: 2,
+		},
+	},
+	CreatedAt: now,
+	UpdatedAt: now,
+}
+
+func TestCheckout_Success(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("CreateFromCart", mock.Anything, 1).Return(sampleOrder, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.Checkout(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, sampleOrder, order)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckout_InsufficientStock(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	stockErr := &errs.InsufficientStockError{
+		Lines: []errs.StockShortage{
+			{ItemID: 42, Name: "Test Item", Requested: 3, Available: 1},
+		},
+	}
+	mockRepo.On("CreateFromCart", mock.Anything, 1).Return(nil, stockErr).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.Checkout(ctx, 1)
+	assert.Nil(t, order)
+	require.ErrorIs(t, err, errs.ErrInsufficientStock)
+
+	var got *errs.InsufficientStockError
+	require.ErrorAs(t, err, &got)
+	assert.Equal(t, stockErr.Lines, got.Lines)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckout_EmailNotVerified(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	userRepo := new(mocks.UserRepository)
+	orderService := services.NewOrderService(mockRepo, userRepo, true)
+
+	userRepo.On("GetByID", mock.Anything, 1).Return(
+		&models.User{ID: 1, EmailVerified: false}, nil,
+	).Once()
+
+	order, err := orderService.Checkout(ctx, 1)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrEmailNotVerified, err)
+
+	mockRepo.AssertNotCalled(t, "CreateFromCart", mock.Anything, mock.Anything)
+	userRepo.AssertExpectations(t)
+}
+
+func TestGetOrdersByUserID_Empty(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByUserID", mock.Anything, 1).Return(nil, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	orders, err := orderService.GetOrdersByUserID(ctx, 1)
+	require.NoError(t, err)
+	assert.NotNil(t, orders)
+	assert.Empty(t, orders)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetUserOrderByID_Success(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByID", mock.Anything, sampleOrder.ID).Return(sampleOrder, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.GetUserOrderByID(ctx, 1, sampleOrder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sampleOrder, order)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetUserOrderByID_OtherUser(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByID", mock.Anything, sampleOrder.ID).Return(sampleOrder, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.GetUserOrderByID(ctx, 2, sampleOrder.ID)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrOrderNotFound, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetUserOrderByID_Error(t *testing.T) {
+	mockRepo := new(mocks.OrderRepository)
+	expectedErr := errors.New("get error")
+	mockRepo.On("GetByID", mock.Anything, 7).Return(nil, expectedErr).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.GetUserOrderByID(ctx, 1, 7)
+	assert.Nil(t, order)
+	assert.EqualError(t, err, expectedErr.Error())
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_Success(t *testing.T) {
+	pending := *sampleOrder
+	cancelled := *sampleOrder
+	cancelled.Status = models.OrderStatusCancelled
+
+	userID := 1
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByID", mock.Anything, pending.ID).Return(&pending, nil).Once()
+	mockRepo.On(
+		"UpdateStatus", mock.Anything, pending.ID, models.OrderStatusCancelled, &userID,
+		"cancelled by customer",
+	).Return(&cancelled, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.CancelOrder(ctx, userID, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_NotPending(t *testing.T) {
+	paid := *sampleOrder
+	paid.Status = models.OrderStatusPaid
+
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByID", mock.Anything, paid.ID).Return(&paid, nil).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.CancelOrder(ctx, 1, paid.ID)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrOrderNotCancellable, err)
+
+	mockRepo.AssertNotCalled(t, "UpdateStatus")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_PaidConcurrently(t *testing.T) {
+	pending := *sampleOrder
+
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On("GetByID", mock.Anything, pending.ID).Return(&pending, nil).Once()
+	mockRepo.On(
+		"UpdateStatus", mock.Anything, pending.ID, models.OrderStatusCancelled,
+		mock.Anything, mock.Anything,
+	).Return(nil, errs.ErrInvalidOrderTransition).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.CancelOrder(ctx, 1, pending.ID)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrOrderNotCancellable, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateOrderStatus_InvalidTransition(t *testing.T) {
+	adminID := 99
+	mockRepo := new(mocks.OrderRepository)
+	mockRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID, models.OrderStatusDelivered, &adminID,
+		"",
+	).Return(nil, errs.ErrInvalidOrderTransition).Once()
+
+	orderService := services.NewOrderService(mockRepo, nil, false)
+	order, err := orderService.UpdateOrderStatus(
+		ctx, adminID, sampleOrder.ID,
+		&models.UpdateOrderStatus{Status: models.OrderStatusDelivered},
+	)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrInvalidOrderTransition, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateOrderStatus_PaymentStatus(t *testing.T) {
+	for _, status := range []models.OrderStatus{
+		models.OrderStatusPaid,
+		models.OrderStatusFailed,
+		models.OrderStatusRefunded,
+	} {
+		t.Run(string(status), func(t *testing.T) {
+			mockRepo := new(mocks.OrderRepository)
+
+			orderService := services.NewOrderService(mockRepo, nil, false)
+			order, err := orderService.UpdateOrderStatus(
+				ctx, 99, sampleOrder.ID,
+				&models.UpdateOrderStatus{Status: status},
+			)
+			assert.Nil(t, order)
+			assert.Equal(t, errs.ErrInvalidOrderTransition, err)
+
+			mockRepo.AssertNotCalled(t, "UpdateStatus")
+		})
+	}
+}
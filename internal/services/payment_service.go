@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
+)
+
+type PaymentService interface {
+	CreatePayment(
+		ctx context.Context, userID int, orderID int,
+	) (*models.Payment, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	RefundOrder(
+		ctx context.Context, adminID int, orderID int,
+	) (*models.Order, error)
+}
+
+type paymentService struct {
+	repo      repositories.PaymentRepository
+	orderRepo repositories.OrderRepository
+	uow       repositories.UnitOfWork
+	provider  payments.PaymentProvider
+	currency  string
+}
+
+func NewPaymentService(
+	repo repositories.PaymentRepository,
+	orderRepo repositories.OrderRepository,
+	uow repositories.UnitOfWork,
+	provider payments.PaymentProvider,
+	currency string,
+) PaymentService {
+	return &paymentService{
+		repo:      repo,
+		orderRepo: orderRepo,
+		uow:       uow,
+		provider:  provider,
+		currency:  currency,
+	}
+}
+
+func (s *paymentService) CreatePayment(
+	ctx context.Context, userID int, orderID int,
+) (*models.Payment, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, errs.ErrOrderNotFound
+	}
+	if !models.CanTransition(order.Status, models.OrderStatusPaid) {
+		return nil, errs.ErrOrderNotPayable
+	}
+
+	intent, err := s.provider.CreateIntent(
+		ctx, order.ID, order.Total, s.currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrPaymentProvider, err)
+	}
+
+	payment := &models.Payment{
+		OrderID:  order.ID,
+		Provider: s.provider.Name(),
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Currency: intent.Currency,
+		Status:   models.PaymentStatusPending,
+	}
+	if err := s.repo.Create(ctx, payment); err != nil {
+		return nil, err
+	}
+	payment.ClientSecret = intent.ClientSecret
+
+	return payment, nil
+}
+
+// HandleWebhook applies a verified provider event. The payment row stays
+// locked until the event is applied, so a webhook redelivered meanwhile
+// waits and then finds the payment no longer pending, which is ignored.
+func (s *paymentService) HandleWebhook(
+	ctx context.Context, payload []byte, signature string,
+) error {
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	// The payment and its order are updated together so a captured payment
+	// is never recorded against an order still waiting for it.
+	var refund *models.Payment
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			payment, err := repos.Payments.GetByIntentIDForUpdate(
+				ctx, event.IntentID,
+			)
+			if err != nil {
+				return err
+			}
+			if payment.Status != models.PaymentStatusPending {
+				return nil
+			}
+
+			switch event.Type {
+			case payments.EventPaymentAuthorized:
+				cancelled, err := s.capture(ctx, repos, payment)
+				if cancelled {
+					refund = payment
+				}
+				return err
+			case payments.EventPaymentFailed:
+				return s.fail(ctx, repos, payment, event.FailureReason)
+			default:
+				return nil
+			}
+		},
+	)
+	if err != nil || refund == nil {
+		return err
+	}
+
+	// The order was cancelled while the customer was paying, so the money
+	// goes straight back.
+	return s.refundCaptured(ctx, refund)
+}
+
+// capture captures a pending payment and marks its order paid. It reports
+// whether the order had been cancelled meanwhile and must be refunded.
+func (s *paymentService) capture(
+	ctx context.Context, repos repositories.Repositories,
+	payment *models.Payment,
+) (bool, error) {
+	if err := s.provider.Capture(ctx, payment.IntentID); err != nil {
+		return false, fmt.Errorf("%w: %v", errs.ErrPaymentProvider, err)
+	}
+
+	payment.Status = models.PaymentStatusSucceeded
+	if err := repos.Payments.Update(ctx, payment); err != nil {
+		return false, err
+	}
+
+	_, err := repos.Orders.UpdateStatus(
+		ctx, payment.OrderID, models.OrderStatusPaid, nil,
+		"payment "+payment.IntentID+" captured",
+	)
+	if errors.Is(err, errs.ErrInvalidOrderTransition) {
+		return true, nil
+	}
+	return false, err
+}
+
+// fail records a declined payment and marks its order failed, unless the
+// order was cancelled meanwhile.
+func (s *paymentService) fail(
+	ctx context.Context, repos repositories.Repositories,
+	payment *models.Payment, reason string,
+) error {
+	payment.Status = models.PaymentStatusFailed
+	payment.FailureReason = reason
+	if err := repos.Payments.Update(ctx, payment); err != nil {
+		return err
+	}
+
+	_, err := repos.Orders.UpdateStatus(
+		ctx, payment.OrderID, models.OrderStatusFailed, nil,
+		"payment "+payment.IntentID+" failed: "+reason,
+	)
+	if errors.Is(err, errs.ErrInvalidOrderTransition) {
+		return nil
+	}
+	return err
+}
+
+func (s *paymentService) refundCaptured(
+	ctx context.Context, payment *models.Payment,
+) error {
+	if err := s.provider.Refund(
+		ctx, payment.IntentID, payment.Amount,
+	); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrPaymentProvider, err)
+	}
+	payment.Status = models.PaymentStatusRefunded
+	return s.repo.Update(ctx, payment)
+}
+
+func (s *paymentService) RefundOrder(
+	ctx context.Context, adminID int, orderID int,
+) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransition(order.Status, models.OrderStatusRefunded) {
+		return nil, errs.ErrOrderNotRefundable
+	}
+
+	payment, err := s.repo.GetSucceededByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.provider.Refund(
+		ctx, payment.IntentID, payment.Amount,
+	); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrPaymentProvider, err)
+	}
+
+	err = s.uow.Do(
+		ctx, func(repos repositories.Repositories) error {
+			payment.Status = models.PaymentStatusRefunded
+			if err := repos.Payments.Update(ctx, payment); err != nil {
+				return err
+			}
+
+			order, err = repos.Orders.UpdateStatus(
+				ctx, orderID, models.OrderStatusRefunded, &adminID,
+				"payment "+payment.IntentID+" refunded",
+			)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
@@ -0,0 +1,301 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/mocks"
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+	"github.com/DaniilKalts/market-rest-api/pkg/payments"
+)
+
+const webhookSecret = "test-webhook-secret"
+
+func newPaymentService() (
+	services.PaymentService,
+	*mocks.PaymentRepository,
+	*mocks.OrderRepository,
+	*payments.FakeProvider,
+) {
+	paymentRepo := new(mocks.PaymentRepository)
+	orderRepo := new(mocks.OrderRepository)
+	provider := payments.NewFakeProvider(webhookSecret)
+
+	// The unit of work runs the callback on the same mocks, so expectations
+	// hold whether or not a call happens inside a transaction.
+	uow := new(mocks.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).Return(
+		func(
+			_ context.Context, fn func(repositories.Repositories) error,
+		) error {
+			return fn(
+				repositories.Repositories{
+					Payments: paymentRepo,
+					Orders:   orderRepo,
+				},
+			)
+		},
+	)
+
+	svc := services.NewPaymentService(
+		paymentRepo, orderRepo, uow, provider, "KZT",
+	)
+
+	return svc, paymentRepo, orderRepo, provider
+}
+
+func TestCreatePayment_Success(t *testing.T) {
+	svc, paymentRepo, orderRepo, _ := newPaymentService()
+
+	orderRepo.On("GetByID", mock.Anything, sampleOrder.ID).Return(sampleOrder, nil).Once()
+	paymentRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Payment")).
+		Return(nil).Once()
+
+	payment, err := svc.CreatePayment(ctx, sampleOrder.UserID, sampleOrder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sampleOrder.Total, payment.Amount)
+	assert.Equal(t, "KZT", payment.Currency)
+	assert.Equal(t, models.PaymentStatusPending, payment.Status)
+	assert.NotEmpty(t, payment.IntentID)
+	assert.NotEmpty(t, payment.ClientSecret)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestCreatePayment_NotPending(t *testing.T) {
+	svc, paymentRepo, orderRepo, _ := newPaymentService()
+
+	paid := *sampleOrder
+	paid.Status = models.OrderStatusPaid
+	orderRepo.On("GetByID", mock.Anything, paid.ID).Return(&paid, nil).Once()
+
+	payment, err := svc.CreatePayment(ctx, paid.UserID, paid.ID)
+	assert.Nil(t, payment)
+	assert.Equal(t, errs.ErrOrderNotPayable, err)
+
+	paymentRepo.AssertNotCalled(t, "Create")
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_Authorized(t *testing.T) {
+	svc, paymentRepo, orderRepo, provider := newPaymentService()
+
+	intent, err := provider.CreateIntent(ctx, sampleOrder.ID, sampleOrder.Total, "KZT")
+	require.NoError(t, err)
+	payment := &models.Payment{
+		ID:       1,
+		OrderID:  sampleOrder.ID,
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Status:   models.PaymentStatusPending,
+	}
+
+	payload, signature, err := provider.Simulate(
+		intent.ID, payments.EventPaymentAuthorized,
+	)
+	require.NoError(t, err)
+
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, intent.ID).Return(payment, nil).Once()
+	paymentRepo.On(
+		"Update", mock.Anything, mock.MatchedBy(
+			func(p *models.Payment) bool {
+				return p.Status == models.PaymentStatusSucceeded
+			},
+		),
+	).Return(nil).Once()
+	orderRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID, models.OrderStatusPaid,
+		(*int)(nil), mock.Anything,
+	).Return(sampleOrder, nil).Once()
+
+	err = svc.HandleWebhook(ctx, payload, signature)
+	require.NoError(t, err)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_OrderCancelledMeanwhile(t *testing.T) {
+	svc, paymentRepo, orderRepo, provider := newPaymentService()
+
+	intent, err := provider.CreateIntent(ctx, sampleOrder.ID, sampleOrder.Total, "KZT")
+	require.NoError(t, err)
+	payment := &models.Payment{
+		OrderID:  sampleOrder.ID,
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Status:   models.PaymentStatusPending,
+	}
+
+	payload, signature, err := provider.Simulate(
+		intent.ID, payments.EventPaymentAuthorized,
+	)
+	require.NoError(t, err)
+
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, intent.ID).Return(payment, nil).Once()
+	paymentRepo.On("Update", mock.Anything, payment).Return(nil).Twice()
+	orderRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID, models.OrderStatusPaid,
+		(*int)(nil), mock.Anything,
+	).Return(nil, errs.ErrInvalidOrderTransition).Once()
+
+	err = svc.HandleWebhook(ctx, payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusRefunded, payment.Status)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_OrderUpdateFails(t *testing.T) {
+	svc, paymentRepo, orderRepo, provider := newPaymentService()
+
+	intent, err := provider.CreateIntent(ctx, sampleOrder.ID, sampleOrder.Total, "KZT")
+	require.NoError(t, err)
+	payment := &models.Payment{
+		OrderID:  sampleOrder.ID,
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Status:   models.PaymentStatusPending,
+	}
+
+	payload, signature, err := provider.Simulate(
+		intent.ID, payments.EventPaymentAuthorized,
+	)
+	require.NoError(t, err)
+
+	dbErr := errors.New("connection reset")
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, intent.ID).Return(payment, nil).Once()
+	paymentRepo.On("Update", mock.Anything, payment).Return(nil).Once()
+	orderRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID, models.OrderStatusPaid,
+		(*int)(nil), mock.Anything,
+	).Return(nil, dbErr).Once()
+
+	// The error aborts the unit of work, rolling back the payment update,
+	// and is not mistaken for a cancelled order that needs a refund.
+	err = svc.HandleWebhook(ctx, payload, signature)
+	assert.Equal(t, dbErr, err)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_Failed(t *testing.T) {
+	svc, paymentRepo, orderRepo, provider := newPaymentService()
+
+	intent, err := provider.CreateIntent(ctx, sampleOrder.ID, sampleOrder.Total, "KZT")
+	require.NoError(t, err)
+	payment := &models.Payment{
+		OrderID:  sampleOrder.ID,
+		IntentID: intent.ID,
+		Status:   models.PaymentStatusPending,
+	}
+
+	payload, signature, err := provider.Simulate(
+		intent.ID, payments.EventPaymentFailed,
+	)
+	require.NoError(t, err)
+
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, intent.ID).Return(payment, nil).Once()
+	paymentRepo.On("Update", mock.Anything, payment).Return(nil).Once()
+	orderRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID,
+		models.OrderStatusFailed, (*int)(nil), mock.Anything,
+	).Return(sampleOrder, nil).Once()
+
+	err = svc.HandleWebhook(ctx, payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusFailed, payment.Status)
+	assert.Equal(t, "card_declined", payment.FailureReason)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_FailedAfterCancel(t *testing.T) {
+	svc, paymentRepo, orderRepo, provider := newPaymentService()
+
+	intent, err := provider.CreateIntent(ctx, sampleOrder.ID, sampleOrder.Total, "KZT")
+	require.NoError(t, err)
+	payment := &models.Payment{
+		OrderID:  sampleOrder.ID,
+		IntentID: intent.ID,
+		Status:   models.PaymentStatusPending,
+	}
+
+	payload, signature, err := provider.Simulate(
+		intent.ID, payments.EventPaymentFailed,
+	)
+	require.NoError(t, err)
+
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, intent.ID).Return(payment, nil).Once()
+	paymentRepo.On("Update", mock.Anything, payment).Return(nil).Once()
+	orderRepo.On(
+		"UpdateStatus", mock.Anything, sampleOrder.ID,
+		models.OrderStatusFailed, (*int)(nil), mock.Anything,
+	).Return(nil, errs.ErrInvalidOrderTransition).Once()
+
+	err = svc.HandleWebhook(ctx, payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusFailed, payment.Status)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_InvalidSignature(t *testing.T) {
+	svc, paymentRepo, _, _ := newPaymentService()
+
+	payload := []byte(`{"type":"payment.authorized","intent_id":"fake_pi_1"}`)
+	signature := payments.SignPayload(payload, "wrong-secret", time.Now())
+
+	err := svc.HandleWebhook(ctx, payload, signature)
+	assert.Equal(t, errs.ErrInvalidSignature, err)
+
+	paymentRepo.AssertNotCalled(t, "GetByIntentIDForUpdate")
+}
+
+func TestHandleWebhook_Replayed(t *testing.T) {
+	svc, paymentRepo, orderRepo, _ := newPaymentService()
+
+	payload := []byte(`{"type":"payment.authorized","intent_id":"fake_pi_1"}`)
+	signature := payments.SignPayload(payload, webhookSecret, time.Now())
+
+	paymentRepo.On("GetByIntentIDForUpdate", mock.Anything, "fake_pi_1").Return(
+		&models.Payment{
+			IntentID: "fake_pi_1",
+			Status:   models.PaymentStatusSucceeded,
+		}, nil,
+	).Once()
+
+	err := svc.HandleWebhook(ctx, payload, signature)
+	require.NoError(t, err)
+
+	paymentRepo.AssertExpectations(t)
+	orderRepo.AssertNotCalled(t, "UpdateStatus")
+}
+
+func TestRefundOrder_NotRefundable(t *testing.T) {
+	svc, paymentRepo, orderRepo, _ := newPaymentService()
+
+	orderRepo.On("GetByID", mock.Anything, sampleOrder.ID).Return(sampleOrder, nil).Once()
+
+	order, err := svc.RefundOrder(ctx, 99, sampleOrder.ID)
+	assert.Nil(t, order)
+	assert.Equal(t, errs.ErrOrderNotRefundable, err)
+
+	paymentRepo.AssertNotCalled(t, "GetSucceededByOrderID")
+	orderRepo.AssertExpectations(t)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/repositories"
+)
+
+// RoleService manages roles and who holds them. Tokens carry the
+// permissions of a role from when they were issued, so changes reach
+// signed-in users on their next refresh.
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]models.RoleDefinition, error)
+	GetRole(ctx context.Context, id int) (*models.RoleDefinition, error)
+	CreateRole(
+		ctx context.Context, req *models.CreateRole,
+	) (*models.RoleDefinition, error)
+	UpdateRole(
+		ctx context.Context, id int, req *models.UpdateRole,
+	) (*models.RoleDefinition, error)
+	DeleteRole(ctx context.Context, id int) error
+	AssignRole(ctx context.Context, userID int, role models.Role) error
+}
+
+type roleService struct {
+	repo     repositories.RoleRepository
+	userRepo repositories.UserRepository
+}
+
+func NewRoleService(
+	repo repositories.RoleRepository, userRepo repositories.UserRepository,
+) RoleService {
+	return &roleService{repo: repo, userRepo: userRepo}
+}
+
+// roleGrants returns the permissions role grants now. A role that no
+// longer exists grants nothing.
+func roleGrants(
+	ctx context.Context, roles repositories.RoleRepository, role models.Role,
+) ([]models.Permission, error) {
+	definition := &models.RoleDefinition{Name: role}
+	if role != models.RoleAdmin {
+		var err error
+		definition, err = roles.GetByName(ctx, role)
+		if errors.Is(err, errs.ErrRoleNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return definition.Grants(), nil
+}
+
+func (s *roleService) ListRoles(
+	ctx context.Context,
+) ([]models.RoleDefinition, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *roleService) GetRole(
+	ctx context.Context, id int,
+) (*models.RoleDefinition, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *roleService) CreateRole(
+	ctx context.Context, req *models.CreateRole,
+) (*models.RoleDefinition, error) {
+	_, err := s.repo.GetByName(ctx, req.Name)
+	if err == nil {
+		return nil, errs.ErrRoleExists
+	}
+	if !errors.Is(err, errs.ErrRoleNotFound) {
+		return nil, err
+	}
+
+	role := &models.RoleDefinition{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	role.SetGrants(req.Permissions)
+
+	if err := s.repo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(
+	ctx context.Context, id int, req *models.UpdateRole,
+) (*models.RoleDefinition, error) {
+	role, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		// Admins hold every permission by definition.
+		if role.Name == models.RoleAdmin {
+			return nil, errs.ErrRoleBuiltin
+		}
+		role.SetGrants(req.Permissions)
+	}
+
+	if err := s.repo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteRole removes a role nobody holds; users have to be moved to
+// another role first.
+func (s *roleService) DeleteRole(ctx context.Context, id int) error {
+	role, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.Name.IsBuiltin() {
+		return errs.ErrRoleBuiltin
+	}
+
+	holders, err := s.repo.CountUsers(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+	if holders > 0 {
+		return errs.ErrRoleInUse
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *roleService) AssignRole(
+	ctx context.Context, userID int, role models.Role,
+) error {
+	if _, err := s.repo.GetByName(ctx, role); err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdateRole(ctx, userID, role)
+}
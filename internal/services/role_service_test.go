@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/mocks"
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/internal/services"
+)
+
+func catalogManagerRole() *models.RoleDefinition {
+	role := &models.RoleDefinition{ID: 3, Name: "catalog-manager"}
+	role.SetGrants([]models.Permission{models.PermissionItemsWrite})
+	return role
+}
+
+func TestCreateRole_Exists(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.
+		On("GetByName", mock.Anything, models.Role("catalog-manager")).
+		Return(catalogManagerRole(), nil)
+
+	_, err := svc.CreateRole(
+		ctx, &models.CreateRole{Name: "catalog-manager"},
+	)
+	assert.Equal(t, errs.ErrRoleExists, err)
+
+	roleRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateRole_Success(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.
+		On("GetByName", mock.Anything, models.Role("support")).
+		Return(nil, errs.ErrRoleNotFound)
+	roleRepo.
+		On(
+			"Create", mock.Anything,
+			mock.AnythingOfType("*models.RoleDefinition"),
+		).
+		Return(nil)
+
+	role, err := svc.CreateRole(
+		ctx, &models.CreateRole{
+			Name: "support",
+			Permissions: []models.Permission{
+				models.PermissionUsersRead,
+				models.PermissionUsersRead,
+				models.PermissionSessionsRevoke,
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []models.Permission{
+			models.PermissionUsersRead, models.PermissionSessionsRevoke,
+		}, role.Grants(),
+	)
+
+	roleRepo.AssertExpectations(t)
+}
+
+func TestUpdateRole_AdminPermissionsFixed(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.
+		On("GetByID", mock.Anything, 1).
+		Return(&models.RoleDefinition{ID: 1, Name: models.RoleAdmin}, nil)
+
+	_, err := svc.UpdateRole(
+		ctx, 1, &models.UpdateRole{Permissions: []models.Permission{}},
+	)
+	assert.Equal(t, errs.ErrRoleBuiltin, err)
+
+	roleRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateRole_ReplacesPermissions(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.On("GetByID", mock.Anything, 3).Return(catalogManagerRole(), nil)
+	roleRepo.
+		On(
+			"Update", mock.Anything, mock.MatchedBy(
+				func(role *models.RoleDefinition) bool {
+					grants := role.Grants()
+					return len(grants) == 1 &&
+						grants[0] == models.PermissionUsersRead
+				},
+			),
+		).
+		Return(nil)
+
+	_, err := svc.UpdateRole(
+		ctx, 3, &models.UpdateRole{
+			Permissions: []models.Permission{models.PermissionUsersRead},
+		},
+	)
+	require.NoError(t, err)
+
+	roleRepo.AssertExpectations(t)
+}
+
+func TestDeleteRole_Builtin(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.
+		On("GetByID", mock.Anything, 2).
+		Return(&models.RoleDefinition{ID: 2, Name: models.RoleUser}, nil)
+
+	assert.Equal(t, errs.ErrRoleBuiltin, svc.DeleteRole(ctx, 2))
+
+	roleRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole_InUse(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	svc := services.NewRoleService(roleRepo, new(mocks.UserRepository))
+
+	roleRepo.On("GetByID", mock.Anything, 3).Return(catalogManagerRole(), nil)
+	roleRepo.
+		On("CountUsers", mock.Anything, models.Role("catalog-manager")).
+		Return(int64(2), nil)
+
+	assert.Equal(t, errs.ErrRoleInUse, svc.DeleteRole(ctx, 3))
+
+	roleRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestAssignRole_UnknownRole(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	userRepo := new(mocks.UserRepository)
+	svc := services.NewRoleService(roleRepo, userRepo)
+
+	roleRepo.
+		On("GetByName", mock.Anything, models.Role("ghost")).
+		Return(nil, errs.ErrRoleNotFound)
+
+	assert.Equal(t, errs.ErrRoleNotFound, svc.AssignRole(ctx, 1, "ghost"))
+
+	userRepo.AssertNotCalled(
+		t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	roleRepo := new(mocks.RoleRepository)
+	userRepo := new(mocks.UserRepository)
+	svc := services.NewRoleService(roleRepo, userRepo)
+
+	roleRepo.
+		On("GetByName", mock.Anything, models.Role("catalog-manager")).
+		Return(catalogManagerRole(), nil)
+	userRepo.
+		On("UpdateRole", mock.Anything, 1, models.Role("catalog-manager")).
+		Return(nil)
+
+	require.NoError(t, svc.AssignRole(ctx, 1, "catalog-manager"))
+
+	userRepo.AssertExpectations(t)
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"context"
+
+	"github.com/DaniilKalts/market-rest-api/internal/models"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+	"github.com/DaniilKalts/market-rest-api/pkg/tracing"
+)
+
+type tracedItemService struct {
+	next ItemService
+}
+
+// NewTracedItemService records a span for every call to next.
+func NewTracedItemService(next ItemService) ItemService {
+	return &tracedItemService{next: next}
+}
+
+func (s *tracedItemService) CreateItem(
+	ctx context.Context, item *models.Item,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.CreateItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.CreateItem(ctx, item)
+}
+
+func (s *tracedItemService) GetItemByID(
+	ctx context.Context, id int,
+) (_ *models.Item, err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.GetItemByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetItemByID(ctx, id)
+}
+
+func (s *tracedItemService) GetAllItems(
+	ctx context.Context, opts *models.ItemQueryOptions,
+) (_ *models.ItemPage, err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.GetAllItems")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetAllItems(ctx, opts)
+}
+
+func (s *tracedItemService) SearchItems(
+	ctx context.Context, opts *models.ItemSearchOptions,
+) (_ *models.ItemSearchPage, err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.SearchItems")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.SearchItems(ctx, opts)
+}
+
+func (s *tracedItemService) UpdateItem(
+	ctx context.Context, id int, item *models.UpdateItem,
+) (_ *models.Item, err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.UpdateItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UpdateItem(ctx, id, item)
+}
+
+func (s *tracedItemService) DeleteItem(
+	ctx context.Context, id int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "ItemService.DeleteItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.DeleteItem(ctx, id)
+}
+
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService records a span for every call to next.
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func (s *tracedUserService) GetUserByID(
+	ctx context.Context, id int,
+) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetUserByID(ctx, id)
+}
+
+func (s *tracedUserService) GetUserByEmail(
+	ctx context.Context, email string,
+) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetUserByEmail(ctx, email)
+}
+
+func (s *tracedUserService) GetAllUsers(
+	ctx context.Context,
+) (_ []models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetAllUsers(ctx)
+}
+
+func (s *tracedUserService) UpdateUserByID(
+	ctx context.Context, id int, updateUserDTO *models.UpdateUser,
+) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UpdateUserByID(ctx, id, updateUserDTO)
+}
+
+func (s *tracedUserService) DeleteUserByID(
+	ctx context.Context, id int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUserByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.DeleteUserByID(ctx, id)
+}
+
+type tracedAuthService struct {
+	next AuthService
+}
+
+// NewTracedAuthService records a span for every call to next.
+func NewTracedAuthService(next AuthService) AuthService {
+	return &tracedAuthService{next: next}
+}
+
+func (s *tracedAuthService) RegisterUser(
+	ctx context.Context, user *models.RegisterUser, client models.ClientInfo,
+) (_ string, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RegisterUser")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RegisterUser(ctx, user, client)
+}
+
+func (s *tracedAuthService) LoginUser(
+	ctx context.Context, email string, password string, client models.ClientInfo,
+) (_ *LoginResult, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginUser")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.LoginUser(ctx, email, password, client)
+}
+
+func (s *tracedAuthService) LoginMFA(
+	ctx context.Context, mfaToken string, code string, client models.ClientInfo,
+) (_ string, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginMFA")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.LoginMFA(ctx, mfaToken, code, client)
+}
+
+func (s *tracedAuthService) LogoutUser(
+	ctx context.Context, accessToken string, refreshToken string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LogoutUser")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.LogoutUser(ctx, accessToken, refreshToken)
+}
+
+func (s *tracedAuthService) RefreshTokens(
+	ctx context.Context, refreshToken string,
+) (_ string, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshTokens")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RefreshTokens(ctx, refreshToken)
+}
+
+func (s *tracedAuthService) VerifyEmail(
+	ctx context.Context, token string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyEmail")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.VerifyEmail(ctx, token)
+}
+
+func (s *tracedAuthService) ResendVerification(
+	ctx context.Context, email string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ResendVerification")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ResendVerification(ctx, email)
+}
+
+func (s *tracedAuthService) ForgotPassword(
+	ctx context.Context, email string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ForgotPassword")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ForgotPassword(ctx, email)
+}
+
+func (s *tracedAuthService) ResetPassword(
+	ctx context.Context, token string, password string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ResetPassword")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ResetPassword(ctx, token, password)
+}
+
+func (s *tracedAuthService) UnlockAccount(
+	ctx context.Context, token string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.UnlockAccount")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UnlockAccount(ctx, token)
+}
+
+func (s *tracedAuthService) LoginLockout(
+	ctx context.Context, userID int,
+) (_ *models.LoginLockout, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginLockout")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.LoginLockout(ctx, userID)
+}
+
+func (s *tracedAuthService) UnlockUser(
+	ctx context.Context, userID int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.UnlockUser")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UnlockUser(ctx, userID)
+}
+
+func (s *tracedAuthService) EnrollMFA(
+	ctx context.Context, userID int,
+) (_ *models.MFAEnrollment, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.EnrollMFA")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.EnrollMFA(ctx, userID)
+}
+
+func (s *tracedAuthService) ConfirmMFA(
+	ctx context.Context, userID int, code string,
+) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ConfirmMFA")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ConfirmMFA(ctx, userID, code)
+}
+
+func (s *tracedAuthService) DisableMFA(
+	ctx context.Context, userID int, code string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.DisableMFA")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.DisableMFA(ctx, userID, code)
+}
+
+func (s *tracedAuthService) ListSessions(
+	ctx context.Context, userID int,
+) (_ []redis.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ListSessions")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ListSessions(ctx, userID)
+}
+
+func (s *tracedAuthService) RevokeSession(
+	ctx context.Context, userID int, sessionID string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RevokeSession")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RevokeSession(ctx, userID, sessionID)
+}
+
+func (s *tracedAuthService) RevokeAllSessions(
+	ctx context.Context, userID int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RevokeAllSessions")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RevokeAllSessions(ctx, userID)
+}
+
+type tracedCartService struct {
+	next CartService
+}
+
+// NewTracedCartService records a span for every call to next.
+func NewTracedCartService(next CartService) CartService {
+	return &tracedCartService{next: next}
+}
+
+func (s *tracedCartService) AddItem(
+	ctx context.Context, cartID int, itemID int,
+) (_ *models.CartItem, err error) {
+	ctx, span := tracing.Start(ctx, "CartService.AddItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.AddItem(ctx, cartID, itemID)
+}
+
+func (s *tracedCartService) GetCartByUserID(
+	ctx context.Context, cartID int,
+) (_ *models.Cart, err error) {
+	ctx, span := tracing.Start(ctx, "CartService.GetCartByUserID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetCartByUserID(ctx, cartID)
+}
+
+func (s *tracedCartService) UpdateItem(
+	ctx context.Context, cartID int, itemID int, quantity uint,
+) (_ *models.CartItem, err error) {
+	ctx, span := tracing.Start(ctx, "CartService.UpdateItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UpdateItem(ctx, cartID, itemID, quantity)
+}
+
+func (s *tracedCartService) DeleteItem(
+	ctx context.Context, cartID int, itemID int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "CartService.DeleteItem")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.DeleteItem(ctx, cartID, itemID)
+}
+
+func (s *tracedCartService) ClearCart(
+	ctx context.Context, cartID int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "CartService.ClearCart")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ClearCart(ctx, cartID)
+}
+
+type tracedOrderService struct {
+	next OrderService
+}
+
+// NewTracedOrderService records a span for every call to next.
+func NewTracedOrderService(next OrderService) OrderService {
+	return &tracedOrderService{next: next}
+}
+
+func (s *tracedOrderService) Checkout(
+	ctx context.Context, userID int,
+) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.Checkout")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.Checkout(ctx, userID)
+}
+
+func (s *tracedOrderService) GetOrdersByUserID(
+	ctx context.Context, userID int,
+) (_ []models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrdersByUserID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetOrdersByUserID(ctx, userID)
+}
+
+func (s *tracedOrderService) GetUserOrderByID(
+	ctx context.Context, userID int, orderID int,
+) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetUserOrderByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetUserOrderByID(ctx, userID, orderID)
+}
+
+func (s *tracedOrderService) CancelOrder(
+	ctx context.Context, userID int, orderID int,
+) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.CancelOrder")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.CancelOrder(ctx, userID, orderID)
+}
+
+func (s *tracedOrderService) UpdateOrderStatus(
+	ctx context.Context, adminID int, orderID int,
+	update *models.UpdateOrderStatus,
+) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.UpdateOrderStatus")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UpdateOrderStatus(ctx, adminID, orderID, update)
+}
+
+type tracedPaymentService struct {
+	next PaymentService
+}
+
+// NewTracedPaymentService records a span for every call to next.
+func NewTracedPaymentService(next PaymentService) PaymentService {
+	return &tracedPaymentService{next: next}
+}
+
+func (s *tracedPaymentService) CreatePayment(
+	ctx context.Context, userID int, orderID int,
+) (_ *models.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.CreatePayment")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.CreatePayment(ctx, userID, orderID)
+}
+
+func (s *tracedPaymentService) HandleWebhook(
+	ctx context.Context, payload []byte, signature string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.HandleWebhook")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.HandleWebhook(ctx, payload, signature)
+}
+
+func (s *tracedPaymentService) RefundOrder(
+	ctx context.Context, adminID int, orderID int,
+) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.RefundOrder")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RefundOrder(ctx, adminID, orderID)
+}
+
+type tracedRoleService struct {
+	next RoleService
+}
+
+// NewTracedRoleService records a span for every call to next.
+func NewTracedRoleService(next RoleService) RoleService {
+	return &tracedRoleService{next: next}
+}
+
+func (s *tracedRoleService) ListRoles(
+	ctx context.Context,
+) (_ []models.RoleDefinition, err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.ListRoles")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ListRoles(ctx)
+}
+
+func (s *tracedRoleService) GetRole(
+	ctx context.Context, id int,
+) (_ *models.RoleDefinition, err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.GetRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.GetRole(ctx, id)
+}
+
+func (s *tracedRoleService) CreateRole(
+	ctx context.Context, req *models.CreateRole,
+) (_ *models.RoleDefinition, err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.CreateRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.CreateRole(ctx, req)
+}
+
+func (s *tracedRoleService) UpdateRole(
+	ctx context.Context, id int, req *models.UpdateRole,
+) (_ *models.RoleDefinition, err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.UpdateRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.UpdateRole(ctx, id, req)
+}
+
+func (s *tracedRoleService) DeleteRole(
+	ctx context.Context, id int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.DeleteRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.DeleteRole(ctx, id)
+}
+
+func (s *tracedRoleService) AssignRole(
+	ctx context.Context, userID int, role models.Role,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "RoleService.AssignRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.AssignRole(ctx, userID, role)
+}
+
+type tracedAPIKeyService struct {
+	next APIKeyService
+}
+
+// NewTracedAPIKeyService records a span for every call to next.
+func NewTracedAPIKeyService(next APIKeyService) APIKeyService {
+	return &tracedAPIKeyService{next: next}
+}
+
+func (s *tracedAPIKeyService) CreateAPIKey(
+	ctx context.Context, createdBy int, granted []string, req *models.CreateAPIKey,
+) (_ *models.APIKey, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.CreateAPIKey(ctx, createdBy, granted, req)
+}
+
+func (s *tracedAPIKeyService) ListAPIKeys(
+	ctx context.Context,
+) (_ []models.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.ListAPIKeys")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.ListAPIKeys(ctx)
+}
+
+func (s *tracedAPIKeyService) RevokeAPIKey(
+	ctx context.Context, id int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.RevokeAPIKey(ctx, id)
+}
+
+func (s *tracedAPIKeyService) Authenticate(
+	ctx context.Context, key string,
+) (_ *models.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	return s.next.Authenticate(ctx, key)
+}
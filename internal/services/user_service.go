@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 
 	"github.com/DaniilKalts/market-rest-api/internal/models"
@@ -9,13 +10,13 @@ import (
 )
 
 type UserService interface {
-	GetUserByID(id int) (*models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
-	UpdateUserByID(id int, updateUserDTO *models.UpdateUser) (
-		*models.User, error,
-	)
-	DeleteUserByID(id int) error
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	UpdateUserByID(
+		ctx context.Context, id int, updateUserDTO *models.UpdateUser,
+	) (*models.User, error)
+	DeleteUserByID(ctx context.Context, id int) error
 }
 
 type userService struct {
@@ -26,23 +27,28 @@ func NewUserService(repo repositories.UserRepository) UserService {
 	return &userService{repo: repo}
 }
 
-func (s *userService) GetUserByID(id int) (*models.User, error) {
-	return s.repo.GetByID(id)
+func (s *userService) GetUserByID(
+	ctx context.Context, id int,
+) (*models.User, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *userService) GetUserByEmail(email string) (*models.User, error) {
-	return s.repo.GetByEmail(email)
+func (s *userService) GetUserByEmail(
+	ctx context.Context, email string,
+) (*models.User, error) {
+	return s.repo.GetByEmail(ctx, email)
 }
 
-func (s *userService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAll()
+func (s *userService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.repo.GetAll(ctx)
 }
 
 func (s *userService) UpdateUserByID(
+	ctx context.Context,
 	userID int,
 	updateUserDTO *models.UpdateUser,
 ) (*models.User, error) {
-	existingUser, err := s.repo.GetByID(userID)
+	existingUser, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -53,8 +59,12 @@ func (s *userService) UpdateUserByID(
 	if updateUserDTO.LastName != nil {
 		existingUser.LastName = *updateUserDTO.LastName
 	}
-	if updateUserDTO.Email != nil {
+	if updateUserDTO.Email != nil && *updateUserDTO.Email != existingUser.Email {
+		// A new address has to be verified again through the resend
+		// endpoint.
 		existingUser.Email = *updateUserDTO.Email
+		existingUser.EmailVerified = false
+		existingUser.EmailVerifiedAt = nil
 	}
 	if updateUserDTO.PhoneNumber != nil {
 		existingUser.PhoneNumber = *updateUserDTO.PhoneNumber
@@ -72,9 +82,9 @@ func (s *userService) UpdateUserByID(
 		}
 	}
 
-	return s.repo.Update(existingUser)
+	return s.repo.Update(ctx, existingUser)
 }
 
-func (s *userService) DeleteUserByID(id int) error {
-	return s.repo.Delete(id)
+func (s *userService) DeleteUserByID(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
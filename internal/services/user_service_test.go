@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	stdErrors "errors"
 	"github.com/DaniilKalts/market-rest-api/internal/mocks"
 	"testing"
@@ -15,6 +16,8 @@ import (
 	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
 )
 
+var ctx = context.Background()
+
 func ptr(s string) *string {
 	return &s
 }
@@ -49,11 +52,11 @@ func TestGetUserByEmail_Success(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("GetByEmail", martinUser.Email).Return(martinUser, nil)
+	mockRepo.On("GetByEmail", mock.Anything, martinUser.Email).Return(martinUser, nil)
 
 	userService := NewUserService(mockRepo)
 
-	user, err := userService.GetUserByEmail(martinUser.Email)
+	user, err := userService.GetUserByEmail(ctx, martinUser.Email)
 
 	require.NoError(t, err)
 	assert.Equal(t, martinUser, user)
@@ -67,11 +70,11 @@ func TestGetUserByEmail_RepoError(t *testing.T) {
 
 	email := "dummy@example.com"
 
-	mockRepo.On("GetByEmail", email).Return(nil, errs.ErrUserNotFound)
+	mockRepo.On("GetByEmail", mock.Anything, email).Return(nil, errs.ErrUserNotFound)
 
 	userService := NewUserService(mockRepo)
 
-	user, err := userService.GetUserByEmail(email)
+	user, err := userService.GetUserByEmail(ctx, email)
 
 	require.Error(t, err)
 	assert.Nil(t, user)
@@ -84,11 +87,11 @@ func TestGetUserByID_Success(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("GetByID", martinUser.ID).Return(martinUser, nil)
+	mockRepo.On("GetByID", mock.Anything, martinUser.ID).Return(martinUser, nil)
 
 	userService := NewUserService(mockRepo)
 
-	user, err := userService.GetUserByID(martinUser.ID)
+	user, err := userService.GetUserByID(ctx, martinUser.ID)
 
 	require.NoError(t, err)
 	assert.Equal(t, martinUser, user)
@@ -102,11 +105,11 @@ func TestGetUserByID_RepoError(t *testing.T) {
 
 	id := 42
 
-	mockRepo.On("GetByID", id).Return(nil, errs.ErrUserNotFound)
+	mockRepo.On("GetByID", mock.Anything, id).Return(nil, errs.ErrUserNotFound)
 
 	userService := NewUserService(mockRepo)
 
-	user, err := userService.GetUserByID(id)
+	user, err := userService.GetUserByID(ctx, id)
 
 	require.Error(t, err)
 	assert.Nil(t, user)
@@ -119,11 +122,11 @@ func TestGetAllUsers_NoUsers(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("GetAll").Return([]models.User{}, nil)
+	mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([]models.User{}, nil)
 
 	userService := NewUserService(mockRepo)
 
-	users, err := userService.GetAllUsers()
+	users, err := userService.GetAllUsers(ctx)
 
 	require.NoError(t, err)
 	assert.Empty(t, users)
@@ -140,11 +143,11 @@ func TestGetAllUsers_SomeUsers(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("GetAll").Return(expectedUsers, nil)
+	mockRepo.On("GetAll", mock.Anything, mock.Anything).Return(expectedUsers, nil)
 
 	userService := NewUserService(mockRepo)
 
-	users, err := userService.GetAllUsers()
+	users, err := userService.GetAllUsers(ctx)
 
 	require.NoError(t, err)
 	assert.Equal(t, expectedUsers, users)
@@ -177,16 +180,18 @@ func TestUpdateUserByID_Success_WithPasswordChange(t *testing.T) {
 		UpdatedAt:   time.Date(2025, 2, 25, 12, 37, 32, 0, time.UTC),
 	}
 
-	mockRepo.On("GetByID", 1).Return(existingUser, nil)
+	mockRepo.On("GetByID", mock.Anything, 1).Return(existingUser, nil)
 
-	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(
-		func(u *models.User) *models.User { return u },
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(
+		func(_ context.Context, u *models.User) *models.User {
+			return u
+		},
 		nil,
 	)
 
 	userService := NewUserService(mockRepo)
 
-	updatedUser, err := userService.UpdateUserByID(1, updateDTO)
+	updatedUser, err := userService.UpdateUserByID(ctx, 1, updateDTO)
 
 	require.NoError(t, err)
 	assert.Equal(t, "Martin", updatedUser.FirstName)
@@ -225,16 +230,18 @@ func TestUpdateUserByID_Success_WithoutPasswordChange(t *testing.T) {
 		UpdatedAt:   time.Date(2025, 2, 25, 12, 37, 32, 0, time.UTC),
 	}
 
-	mockRepo.On("GetByID", 1).Return(existingUser, nil)
+	mockRepo.On("GetByID", mock.Anything, 1).Return(existingUser, nil)
 
-	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(
-		func(u *models.User) *models.User { return u },
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(
+		func(_ context.Context, u *models.User) *models.User {
+			return u
+		},
 		nil,
 	)
 
 	userService := NewUserService(mockRepo)
 
-	updatedUser, err := userService.UpdateUserByID(1, updateDTO)
+	updatedUser, err := userService.UpdateUserByID(ctx, 1, updateDTO)
 
 	require.NoError(t, err)
 	assert.Equal(t, "Martin", updatedUser.FirstName)
@@ -263,7 +270,7 @@ func TestUpdateUserByID_PasswordMismatch(t *testing.T) {
 		Role:        models.RoleUser,
 	}
 
-	mockRepo.On("GetByID", 1).Return(existingUser, nil)
+	mockRepo.On("GetByID", mock.Anything, 1).Return(existingUser, nil)
 
 	updateDTO := &models.UpdateUser{
 		Password:        ptr("12341234"),
@@ -272,7 +279,7 @@ func TestUpdateUserByID_PasswordMismatch(t *testing.T) {
 
 	userService := NewUserService(mockRepo)
 
-	updatedUser, err := userService.UpdateUserByID(1, updateDTO)
+	updatedUser, err := userService.UpdateUserByID(ctx, 1, updateDTO)
 
 	require.Error(t, err)
 	assert.Nil(t, updatedUser)
@@ -285,7 +292,7 @@ func TestUpdateUserByID_GetByIDError(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("GetByID", 1).Return(nil, errs.ErrUserNotFound)
+	mockRepo.On("GetByID", mock.Anything, 1).Return(nil, errs.ErrUserNotFound)
 
 	updateDTO := &models.UpdateUser{
 		FirstName: ptr("Martin"),
@@ -293,7 +300,7 @@ func TestUpdateUserByID_GetByIDError(t *testing.T) {
 
 	userService := NewUserService(mockRepo)
 
-	updatedUser, err := userService.UpdateUserByID(1, updateDTO)
+	updatedUser, err := userService.UpdateUserByID(ctx, 1, updateDTO)
 
 	require.Error(t, err)
 	assert.Nil(t, updatedUser)
@@ -306,11 +313,11 @@ func TestDeleteUserByID_Success(t *testing.T) {
 
 	mockRepo := new(mocks.UserRepository)
 
-	mockRepo.On("Delete", 1).Return(nil)
+	mockRepo.On("Delete", mock.Anything, 1).Return(nil)
 
 	userService := NewUserService(mockRepo)
 
-	err := userService.DeleteUserByID(1)
+	err := userService.DeleteUserByID(ctx, 1)
 
 	require.NoError(t, err)
 
@@ -323,11 +330,11 @@ func TestDeleteUserByID_RepoError(t *testing.T) {
 
 	expectedErr := stdErrors.New("delete error")
 
-	mockRepo.On("Delete", 1).Return(expectedErr)
+	mockRepo.On("Delete", mock.Anything, 1).Return(expectedErr)
 
 	userService := NewUserService(mockRepo)
 
-	err := userService.DeleteUserByID(1)
+	err := userService.DeleteUserByID(ctx, 1)
 
 	require.Error(t, err)
 	assert.EqualError(t, err, expectedErr.Error())
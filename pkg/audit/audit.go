@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/logger"
+)
+
+// Event types.
+const (
+	// RefreshTokenReuse is a refresh token presented again after it was
+	// rotated, a sign that it was stolen.
+	RefreshTokenReuse = "refresh_token_reuse"
+	// AccountLocked is an account locked after repeated failed logins.
+	AccountLocked = "account_locked"
+	// AccountUnlocked is a lockout lifted through the emailed link or by
+	// an admin.
+	AccountUnlocked = "account_unlocked"
+)
+
+// Event is a security-relevant fact worth keeping apart from the ordinary
+// request log.
+type Event struct {
+	Type      string
+	UserID    int
+	SessionID string
+	Details   string
+	At        time.Time
+}
+
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+type logRecorder struct{}
+
+// NewLogRecorder returns a Recorder that writes events to the application
+// log.
+func NewLogRecorder() Recorder {
+	return logRecorder{}
+}
+
+func (logRecorder) Record(ctx context.Context, event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	logger.FromContext(ctx).Warn(
+		"audit",
+		"type", event.Type,
+		"user_id", event.UserID,
+		"session_id", event.SessionID,
+		"at", event.At.UTC(),
+		"details", event.Details,
+	)
+}
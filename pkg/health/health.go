@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Values of Report.Status and CheckResult.Status.
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check reports whether a dependency can be used. It should give up when
+// ctx is done.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	// Error is why the check failed. It is left out of the JSON, since
+	// driver errors can name hosts, users and databases; log it instead.
+	Error string `json:"-"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the checks of the dependencies the API cannot serve
+// without.
+type Checker struct {
+	timeout      time.Duration
+	names        []string
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker returns a Checker giving each check timeout to answer.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers check under name. Call it before the first Run.
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// SetShuttingDown makes every later report not ready, so that load
+// balancers stop routing requests before the server stops accepting them.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Run runs the checks concurrently. The report is ready when every check
+// passed and the server is not shutting down.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		report.Checks[c.names[i]] = result
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Ready reports whether report allows serving traffic.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
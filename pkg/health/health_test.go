@@ -0,0 +1,97 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/health"
+)
+
+func pass(context.Context) error { return nil }
+
+func TestChecker_AllPass(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", pass)
+	checker.Add("redis", pass)
+
+	report := checker.Run(context.Background())
+	assert.True(t, report.Ready())
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusOK, report.Checks["redis"].Status)
+}
+
+func TestChecker_OneFails(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", pass)
+	checker.Add("redis", func(context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	report := checker.Run(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+	assert.Equal(t, health.StatusUnavailable, report.Checks["redis"].Status)
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+}
+
+func TestReport_JSONLeavesOutErrors(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", func(context.Context) error {
+		return errors.New("dial tcp db.internal:5432: connection refused")
+	})
+
+	body, err := json.Marshal(checker.Run(context.Background()))
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "db.internal")
+	assert.NotContains(t, string(body), "error")
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := health.NewChecker(20 * time.Millisecond)
+	checker.Add("postgres", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, report.Ready())
+	assert.Equal(
+		t, context.DeadlineExceeded.Error(), report.Checks["postgres"].Error,
+	)
+}
+
+func TestChecker_ChecksRunConcurrently(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	for _, name := range []string{"postgres", "redis", "payments"} {
+		checker.Add(name, func(context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+	assert.True(t, report.Ready())
+}
+
+func TestChecker_ShuttingDown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", pass)
+	checker.SetShuttingDown()
+
+	report := checker.Run(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+}
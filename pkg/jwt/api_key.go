@@ -0,0 +1,27 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// APIKeyHeaderName is the header machine clients send their API key in.
+const APIKeyHeaderName = "X-API-Key"
+
+// GenerateAPIKey returns a new API key of the form mk_<prefix>_<secret>
+// together with its public prefix and the hash to store.
+func GenerateAPIKey() (string, string, string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix := "mk_" + hex.EncodeToString(b)
+
+	secret, err := generateTokenID()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key := prefix + "_" + secret
+	return key, prefix, HashOneTimeToken(key), nil
+}
@@ -2,10 +2,16 @@ package jwt
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/DaniilKalts/market-rest-api/internal/config"
 )
 
+const (
+	AccessTokenCookieName  = "access_token"
+	RefreshTokenCookieName = "refresh_token"
+)
+
 func SetCookie(w http.ResponseWriter, name, value, domain string, maxAge int, secure, httpOnly bool, sameSite http.SameSite) {
 	cookie := &http.Cookie{
 		Name:     name,
@@ -20,16 +26,49 @@ func SetCookie(w http.ResponseWriter, name, value, domain string, maxAge int, se
 	http.SetCookie(w, cookie)
 }
 
-func SetAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) error {
-	SetCookie(w, "access_token", accessToken, config.Config.Server.Domain, 900, true, true, http.SameSiteLaxMode)
-	SetCookie(w, "refresh_token", refreshToken, config.Config.Server.Domain, 86400, true, true, http.SameSiteLaxMode)
+func setConfiguredCookie(
+	w http.ResponseWriter, name, value string, ttl time.Duration,
+	httpOnly bool,
+) {
+	cfg := config.Config.Server.Cookies
 
-	return nil
+	// A negative max age deletes the cookie.
+	maxAge := -1
+	if ttl > 0 {
+		maxAge = int(ttl.Seconds())
+	}
+
+	SetCookie(
+		w, name, value, cfg.Domain, maxAge, cfg.Secure, httpOnly, cfg.SameSite,
+	)
+}
+
+// SetAuthCookies stores the token pair in HTTP-only cookies next to a CSRF
+// token for the session of accessToken, which it returns so clients that
+// cannot read the cookie can be given it too.
+func SetAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) (string, error) {
+	claims, err := ParseJWT(accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	csrfToken, err := NewCSRFToken(claims.SessionID)
+	if err != nil {
+		return "", err
+	}
+
+	cfg := config.Config.Server.Cookies
+	setConfiguredCookie(w, AccessTokenCookieName, accessToken, cfg.AccessTTL, true)
+	setConfiguredCookie(w, RefreshTokenCookieName, refreshToken, cfg.RefreshTTL, true)
+	setConfiguredCookie(w, CSRFCookieName, csrfToken, cfg.RefreshTTL, false)
+
+	return csrfToken, nil
 }
 
 func DeleteAuthCookies(w http.ResponseWriter) error {
-	SetCookie(w, "access_token", "", config.Config.Server.Domain, -1, true, true, http.SameSiteLaxMode)
-	SetCookie(w, "refresh_token", "", config.Config.Server.Domain, -1, true, true, http.SameSiteLaxMode)
+	setConfiguredCookie(w, AccessTokenCookieName, "", 0, true)
+	setConfiguredCookie(w, RefreshTokenCookieName, "", 0, true)
+	setConfiguredCookie(w, CSRFCookieName, "", 0, false)
 
 	return nil
 }
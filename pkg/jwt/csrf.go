@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strings"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+)
+
+// Browsers authenticating with cookies get the CSRF token in a cookie
+// scripts can read and send it back in a header on unsafe requests; a
+// cross-site page can make the browser send the cookie but cannot read it.
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+func csrfPurpose(sessionID string) string {
+	return "csrf:" + sessionID
+}
+
+// NewCSRFToken returns a random token signed for sessionID, so a token
+// planted from another session, say by a sibling subdomain, is rejected.
+func NewCSRFToken(sessionID string) (string, error) {
+	value, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	return value + "." + signOneTimeToken(csrfPurpose(sessionID), value), nil
+}
+
+// VerifyCSRF checks that r echoes its CSRF cookie in the CSRF header and
+// that the token was issued for sessionID.
+func VerifyCSRF(r *http.Request, sessionID string) error {
+	header := r.Header.Get(CSRFHeaderName)
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil || header == "" ||
+		!hmac.Equal([]byte(header), []byte(cookie.Value)) {
+		return errs.ErrInvalidCSRFToken
+	}
+
+	value, signature, ok := strings.Cut(header, ".")
+	if !ok || !hmac.Equal(
+		[]byte(signature),
+		[]byte(signOneTimeToken(csrfPurpose(sessionID), value)),
+	) {
+		return errs.ErrInvalidCSRFToken
+	}
+
+	return nil
+}
+
+// IsSafeMethod reports whether method cannot change state and so needs no
+// CSRF token.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,71 @@
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// csrfRequest returns a POST carrying cookie as the CSRF cookie and header
+// in the CSRF header; empty values are left out.
+func csrfRequest(cookie, header string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/cart", nil)
+	if cookie != "" {
+		r.AddCookie(&http.Cookie{Name: jwt.CSRFCookieName, Value: cookie})
+	}
+	if header != "" {
+		r.Header.Set(jwt.CSRFHeaderName, header)
+	}
+	return r
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	token, err := jwt.NewCSRFToken("session-1")
+	require.NoError(t, err)
+	other, err := jwt.NewCSRFToken("session-1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		session string
+		wantErr bool
+	}{
+		{"echoed", csrfRequest(token, token), "session-1", false},
+		{"no header", csrfRequest(token, ""), "session-1", true},
+		{"no cookie", csrfRequest("", token), "session-1", true},
+		{"mismatch", csrfRequest(token, other), "session-1", true},
+		{"other session", csrfRequest(token, token), "session-2", true},
+		{"unsigned", csrfRequest("forged", "forged"), "session-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := jwt.VerifyCSRF(tt.request, tt.session)
+			if tt.wantErr {
+				assert.Equal(t, errs.ErrInvalidCSRFToken, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	for _, method := range []string{
+		http.MethodGet, http.MethodHead, http.MethodOptions,
+	} {
+		assert.True(t, jwt.IsSafeMethod(method), method)
+	}
+	for _, method := range []string{
+		http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	} {
+		assert.False(t, jwt.IsSafeMethod(method), method)
+	}
+}
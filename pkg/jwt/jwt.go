@@ -13,9 +13,30 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// RefreshTokenMinutes is the lifetime of refresh tokens, as passed to
+// GenerateSessionJWT.
+const RefreshTokenMinutes = uint(config.RefreshTokenTTL / time.Minute)
+
 type Claims struct {
 	jwt.RegisteredClaims
 	Role string
+	// SessionID links the token to the session it was issued for.
+	SessionID string `json:"sid,omitempty"`
+	// MFA is set on tokens issued after a second factor was checked.
+	MFA bool `json:"mfa,omitempty"`
+	// Permissions are the ones the role granted when the token was
+	// issued.
+	Permissions []string `json:"perms,omitempty"`
+}
+
+// HasPermission reports whether the token grants permission.
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
 
 func generateTokenID() (string, error) {
@@ -28,6 +49,16 @@ func generateTokenID() (string, error) {
 }
 
 func GenerateJWT(subject string, minutes uint, role string) (string, error) {
+	return GenerateSessionJWT(subject, minutes, role, nil, "", false)
+}
+
+// GenerateSessionJWT is GenerateJWT for a token of the given session
+// granting permissions, mfa telling whether the session passed a second
+// factor.
+func GenerateSessionJWT(
+	subject string, minutes uint, role string, permissions []string,
+	sessionID string, mfa bool,
+) (string, error) {
 	secret := config.Config.Server.Secret
 	issuer := config.Config.Server.BaseURL
 
@@ -53,7 +84,18 @@ func GenerateJWT(subject string, minutes uint, role string) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(validity)),
 			ID:        tokenID,
 		},
-		Role: role,
+		Role:        role,
+		SessionID:   sessionID,
+		MFA:         mfa,
+		Permissions: permissions,
+	}
+
+	if keys != nil {
+		key := keys.signing()
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.id
+
+		return token.SignedString(key.private)
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -66,17 +108,38 @@ func GenerateJWT(subject string, minutes uint, role string) (string, error) {
 	return tokenString, nil
 }
 
+// verificationKey picks the key token was signed with: the ring key named
+// by its kid, or the server secret for HS256 tokens. With a ring in use,
+// HS256 tokens are those issued before asymmetric signing was enabled and
+// are accepted only until the migration ends.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if id, ok := token.Header["kid"].(string); ok {
+		if keys == nil {
+			return nil, errors.New("unknown signing key")
+		}
+		key, ok := keys.lookup(id)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("invalid signing method")
+		}
+		return key.private.Public(), nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("invalid signing method")
+	}
+	if keys != nil && !time.Now().Before(hs256Until) {
+		return nil, errors.New("HS256 tokens are no longer accepted")
+	}
+	return []byte(config.Config.Server.Secret), nil
+}
+
 func ParseJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	_, err := jwt.ParseWithClaims(
-		tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("invalid signing method")
-			}
-			return []byte(config.Config.Server.Secret), nil
-		},
-	)
+	_, err := jwt.ParseWithClaims(tokenString, claims, verificationKey)
 	if err != nil {
 		return claims, err
 	}
@@ -0,0 +1,408 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+)
+
+// createdHeader is the PEM header recording when a key was generated, so
+// the rotation schedule survives restarts.
+const createdHeader = "Created"
+
+const rsaKeyBits = 2048
+
+// minReloadInterval limits how often tokens naming an unknown kid make the
+// ring read its directory again, so forged kids cannot keep it busy.
+const minReloadInterval = 10 * time.Second
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// KeyRing holds the asymmetric keys tokens are signed with. The newest key
+// signs; older ones keep verifying tokens for the retention period after
+// they were replaced, so tokens issued just before a rotation stay valid.
+type KeyRing struct {
+	dir       string
+	method    jwt.SigningMethod
+	rotation  time.Duration
+	retention time.Duration
+
+	mu   sync.RWMutex
+	keys []*signingKey // oldest first
+
+	reloadMu   sync.Mutex
+	lastReload time.Time
+}
+
+// keys is the ring GenerateSessionJWT and ParseJWT use; nil means HS256
+// with the server secret.
+var keys *KeyRing
+
+// hs256Until is when HS256 tokens stop verifying while a ring is in use.
+var hs256Until time.Time
+
+// UseKeyRing makes ring sign new tokens and verify tokens carrying a kid.
+// Tokens signed with the server secret before the switch keep verifying
+// until migrationEnd, so sessions survive the migration; after it they are
+// rejected.
+func UseKeyRing(ring *KeyRing, migrationEnd time.Time) {
+	keys = ring
+	hs256Until = migrationEnd
+}
+
+// NewKeyRing loads the keys in dir for algorithm (RS256 or EdDSA) and
+// generates one if none is due to sign.
+func NewKeyRing(
+	dir, algorithm string, rotation, retention time.Duration,
+) (*KeyRing, error) {
+	var method jwt.SigningMethod
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		method = jwt.SigningMethodRS256
+	case jwt.SigningMethodEdDSA.Alg():
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{
+		dir:       dir,
+		method:    method,
+		rotation:  rotation,
+		retention: retention,
+	}
+	if err := ring.Rotate(); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Rotate reloads the keys from disk, picking up keys added by other
+// instances, and generates a new signing key once the current one is older
+// than the rotation period. Keys past their retention are dropped.
+func (r *KeyRing) Rotate() error {
+	loaded, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if len(loaded) == 0 ||
+		now.Sub(loaded[len(loaded)-1].createdAt) >= r.rotation {
+		key, err := r.generate(now)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, key)
+	}
+
+	r.activate(loaded, now)
+	return nil
+}
+
+// reload picks up the keys other instances added since the last load
+// without generating one. An empty directory leaves the ring as it was.
+func (r *KeyRing) reload() error {
+	loaded, err := r.load()
+	if err != nil {
+		return err
+	}
+	if len(loaded) == 0 {
+		return nil
+	}
+
+	r.activate(loaded, time.Now())
+	return nil
+}
+
+// activate makes loaded, sorted oldest first, the keys of the ring. A key
+// stops verifying once the key that replaced it has been signing for longer
+// than the retention period.
+func (r *KeyRing) activate(loaded []*signingKey, now time.Time) {
+	active := loaded[:0]
+	for i, key := range loaded {
+		if i+1 < len(loaded) &&
+			now.Sub(loaded[i+1].createdAt) > r.retention {
+			continue
+		}
+		active = append(active, key)
+	}
+
+	r.mu.Lock()
+	r.keys = active
+	r.mu.Unlock()
+}
+
+func (r *KeyRing) load() ([]*signingKey, error) {
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]*signingKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := r.readKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load signing key %s: %w", path, err)
+		}
+		if key.method != r.method {
+			continue
+		}
+		loaded = append(loaded, key)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].createdAt.Before(loaded[j].createdAt)
+	})
+
+	return loaded, nil
+}
+
+func (r *KeyRing) readKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		id: strings.TrimSuffix(filepath.Base(path), ".pem"),
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodRS256, private
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+
+	// Keys provisioned by hand may lack the header; their file time is the
+	// best guess of when they were added.
+	if created, ok := block.Headers[createdHeader]; ok {
+		key.createdAt, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		key.createdAt = info.ModTime()
+	}
+
+	return key, nil
+}
+
+func (r *KeyRing) generate(now time.Time) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	switch r.method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	// The random suffix keeps two instances rotating at the same moment
+	// from overwriting each other's key.
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := now.UTC().Format("20060102T150405Z") + "-" +
+		hex.EncodeToString(suffix)
+	block := &pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			createdHeader: now.UTC().Format(time.RFC3339),
+		},
+		Bytes: der,
+	}
+
+	// Write under a temporary name first so other instances never load a
+	// half-written key.
+	path := filepath.Join(r.dir, id+".pem")
+	tmp, err := os.CreateTemp(r.dir, id+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := pem.Encode(tmp, block); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{
+		id:        id,
+		method:    r.method,
+		private:   private,
+		createdAt: now.UTC(),
+	}, nil
+}
+
+func (r *KeyRing) signing() *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.keys[len(r.keys)-1]
+}
+
+// lookup returns the key named id. An unknown id may belong to a key
+// another instance generated since the last load, so the directory is read
+// again, at most once per minReloadInterval, before giving up.
+func (r *KeyRing) lookup(id string) (*signingKey, bool) {
+	if key, ok := r.find(id); ok {
+		return key, true
+	}
+
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	// A lookup waiting on the lock may find the key another one loaded.
+	if key, ok := r.find(id); ok {
+		return key, true
+	}
+	if time.Since(r.lastReload) < minReloadInterval {
+		return nil, false
+	}
+	r.lastReload = time.Now()
+
+	// A failed reload leaves the ring as it was; the token is rejected and
+	// the hourly rotation reports the error.
+	if err := r.reload(); err != nil {
+		return nil, false
+	}
+	return r.find(id)
+}
+
+func (r *KeyRing) find(id string) (*signingKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.id == id {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys.
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+	// Ed25519 keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSMaxAge is how long clients may cache the JWKS document.
+const JWKSMaxAge = config.JWKSMaxAge
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the public halves of every key that still verifies
+// tokens. It is empty when tokens are signed with HS256.
+func PublicKeys() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if keys == nil {
+		return set
+	}
+
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+
+	for _, key := range keys.keys {
+		jwk := JWK{
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Modulus = base64.RawURLEncoding.EncodeToString(
+				public.N.Bytes(),
+			)
+			jwk.Exponent = base64.RawURLEncoding.EncodeToString(
+				big.NewInt(int64(public.E)).Bytes(),
+			)
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,106 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// useRing signs with a fresh EdDSA ring for the rest of the test, accepting
+// HS256 tokens until hs256Until.
+func useRing(t *testing.T, hs256Until time.Time) *jwt.KeyRing {
+	ring, err := jwt.NewKeyRing(t.TempDir(), "EdDSA", time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	jwt.UseKeyRing(ring, hs256Until)
+	t.Cleanup(func() { jwt.UseKeyRing(nil, time.Time{}) })
+
+	return ring
+}
+
+func TestParseJWT_HS256DuringMigration(t *testing.T) {
+	legacy, err := jwt.GenerateJWT("1", 15, "user")
+	require.NoError(t, err)
+
+	useRing(t, time.Now().Add(time.Hour))
+
+	claims, err := jwt.ParseJWT(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+}
+
+func TestParseJWT_HS256AfterMigration(t *testing.T) {
+	legacy, err := jwt.GenerateJWT("1", 15, "user")
+	require.NoError(t, err)
+
+	useRing(t, time.Time{})
+
+	_, err = jwt.ParseJWT(legacy)
+	assert.Error(t, err)
+}
+
+func TestParseJWT_RingToken(t *testing.T) {
+	useRing(t, time.Time{})
+
+	token, err := jwt.GenerateJWT("1", 15, "user")
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+
+	jwks := jwt.PublicKeys()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+}
+
+// signWithNewKey returns a token signed with a key another instance added
+// to dir after ring loaded it.
+func signWithNewKey(t *testing.T, ring *jwt.KeyRing, dir string) string {
+	// A zero rotation period makes the other ring generate a key at once.
+	other, err := jwt.NewKeyRing(dir, "EdDSA", 0, time.Hour)
+	require.NoError(t, err)
+
+	jwt.UseKeyRing(other, time.Time{})
+	token, err := jwt.GenerateJWT("1", 15, "user")
+	require.NoError(t, err)
+	jwt.UseKeyRing(ring, time.Time{})
+
+	return token
+}
+
+func TestParseJWT_ReloadsOnUnknownKid(t *testing.T) {
+	dir := t.TempDir()
+	ring, err := jwt.NewKeyRing(dir, "EdDSA", time.Hour, time.Hour)
+	require.NoError(t, err)
+	jwt.UseKeyRing(ring, time.Time{})
+	t.Cleanup(func() { jwt.UseKeyRing(nil, time.Time{}) })
+
+	token := signWithNewKey(t, ring, dir)
+
+	claims, err := jwt.ParseJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Len(t, jwt.PublicKeys().Keys, 2)
+}
+
+func TestParseJWT_ReloadIsRateLimited(t *testing.T) {
+	dir := t.TempDir()
+	ring, err := jwt.NewKeyRing(dir, "EdDSA", time.Hour, time.Hour)
+	require.NoError(t, err)
+	jwt.UseKeyRing(ring, time.Time{})
+	t.Cleanup(func() { jwt.UseKeyRing(nil, time.Time{}) })
+
+	// The first unknown kid spends the reload.
+	first := signWithNewKey(t, ring, dir)
+	_, err = jwt.ParseJWT(first)
+	require.NoError(t, err)
+
+	second := signWithNewKey(t, ring, dir)
+	_, err = jwt.ParseJWT(second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/internal/config"
+)
+
+func signOneTimeToken(purpose, value string) string {
+	mac := hmac.New(sha256.New, []byte(config.Config.Server.Secret))
+	mac.Write([]byte(purpose + "." + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HashOneTimeToken returns the hex SHA-256 of a token. Only hashes are
+// stored, so a leaked table cannot be replayed.
+func HashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOneTimeToken returns a random token signed for purpose together
+// with the hash to store. Tokens signed for one purpose are rejected for
+// any other.
+func GenerateOneTimeToken(purpose string) (string, string, error) {
+	value, err := generateTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	token := value + "." + signOneTimeToken(purpose, value)
+	return token, HashOneTimeToken(token), nil
+}
+
+// VerifyOneTimeToken checks the signature of token for purpose and returns
+// the hash to look it up by. Whether it is unused and unexpired is up to
+// the store.
+func VerifyOneTimeToken(purpose, token string) (string, error) {
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal(
+		[]byte(signature), []byte(signOneTimeToken(purpose, value)),
+	) {
+		return "", errs.ErrInvalidOneTimeToken
+	}
+
+	return HashOneTimeToken(token), nil
+}
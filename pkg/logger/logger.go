@@ -1,9 +1,13 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"time"
+	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -13,28 +17,103 @@ const (
 	LevelDebug = "DEBUG"
 )
 
+// Values of the format passed to Setup.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var base atomic.Pointer[slog.Logger]
+
+func init() {
+	base.Store(
+		slog.New(
+			slog.NewTextHandler(
+				os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo},
+			),
+		),
+	)
+}
+
+// ParseLevel reads one of debug, info, warn or error, in any case.
+func ParseLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", level)
+	}
+	return parsed, nil
+}
+
+// Setup replaces the logger every function of the package writes to with
+// one printing format records of at least level to w.
+func Setup(w io.Writer, format, level string) error {
+	minLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	base.Store(slog.New(handler))
+	return nil
+}
+
+// Logger returns the application logger.
+func Logger() *slog.Logger {
+	return base.Load()
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, for FromContext.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of a request, with its request ID, route
+// and user, or the application logger outside of requests.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return Logger()
+}
+
 func Log(level, message string) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Printf("[%s]  %s - %s\n", level, timestamp, message)
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		parsed = slog.LevelInfo
+	}
+	Logger().Log(context.Background(), parsed, message)
 }
 
 func Info(message string) {
-	Log(LevelInfo, message)
+	Logger().Info(message)
 }
 
 func Error(message string) {
-	Log(LevelError, message)
+	Logger().Error(message)
 }
 
 func Warn(message string) {
-	Log(LevelWarn, message)
+	Logger().Warn(message)
 }
 
 func Debug(message string) {
-	Log(LevelDebug, message)
+	Logger().Debug(message)
 }
 
 func Fatal(message string) {
-	Log(LevelError, message)
+	Logger().Error(message)
 	os.Exit(1)
 }
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterMailer writes every message to w instead of delivering it. It is
+// meant for local development, where the links in the emails are copied
+// from the terminal or the file.
+type WriterMailer struct {
+	from string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+// NewFileMailer appends messages to the file at path, creating it if
+// needed.
+func NewFileMailer(path, from string) (*WriterMailer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterMailer(file, from), nil
+}
+
+func (m *WriterMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(
+		m.w, "From: %s\nTo: %s\nDate: %s\nSubject: %s\n\n%s\n\n",
+		m.from, msg.To, time.Now().Format(time.RFC1123Z), msg.Subject,
+		msg.Body,
+	)
+	return err
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin times the queries run through a *gorm.DB. Install it with
+// db.Use(metrics.GormPlugin{}).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").
+			Register("metrics:before_create", startQuery),
+		cb.Create().After("gorm:create").
+			Register("metrics:after_create", observeQuery("create")),
+		cb.Query().Before("gorm:query").
+			Register("metrics:before_query", startQuery),
+		cb.Query().After("gorm:query").
+			Register("metrics:after_query", observeQuery("query")),
+		cb.Update().Before("gorm:update").
+			Register("metrics:before_update", startQuery),
+		cb.Update().After("gorm:update").
+			Register("metrics:after_update", observeQuery("update")),
+		cb.Delete().Before("gorm:delete").
+			Register("metrics:before_delete", startQuery),
+		cb.Delete().After("gorm:delete").
+			Register("metrics:after_delete", observeQuery("delete")),
+		cb.Row().Before("gorm:row").
+			Register("metrics:before_row", startQuery),
+		cb.Row().After("gorm:row").
+			Register("metrics:after_row", observeQuery("row")),
+		cb.Raw().Before("gorm:raw").
+			Register("metrics:before_raw", startQuery),
+		cb.Raw().After("gorm:raw").
+			Register("metrics:after_raw", observeQuery("raw")),
+	)
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		dbQueryDuration.WithLabelValues(operation, table).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "market"
+
+// Registry holds every metric served by Handler. It is separate from the
+// default registry so that only what Register adds is exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status.",
+		},
+		[]string{"method", "route", "status"},
+	)
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+	dbQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "GORM query latency by operation and table.",
+			Buckets: []float64{
+				.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5,
+			},
+		},
+		[]string{"operation", "table"},
+	)
+	redisCommandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redis_command_duration_seconds",
+			Help:      "Redis command latency by command and result.",
+			Buckets: []float64{
+				.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5,
+			},
+		},
+		[]string{"command", "result"},
+	)
+)
+
+// Register adds the HTTP, database and Redis metrics of the package, the
+// Go runtime and process metrics and extra to Registry. Call it once.
+func Register(extra ...prometheus.Collector) {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		dbQueryDuration,
+		redisCommandDuration,
+	)
+	Registry.MustRegister(extra...)
+}
+
+// NewGauge returns a gauge in the namespace of the API whose value is read
+// from value on every scrape, for Register.
+func NewGauge(name, help string, value func() float64) prometheus.Collector {
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help},
+		value,
+	)
+}
+
+// ObserveHTTPRequest records a served request. route is the template the
+// request matched, such as /api/items/:id, which keeps the number of
+// series bounded.
+func ObserveHTTPRequest(
+	method, route string, status int, duration time.Duration,
+) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).
+		Observe(duration.Seconds())
+}
+
+// Handler serves the metrics of Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
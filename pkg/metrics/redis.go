@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook times the commands sent by a Redis client. Install it with
+// client.AddHook(metrics.RedisHook{}). Pipelined commands are counted one
+// by one, each with the latency of the whole pipeline.
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(
+		ctx context.Context, network, addr string,
+	) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd, err, time.Since(start))
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(
+	next redis.ProcessPipelineHook,
+) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		duration := time.Since(start)
+		// Pipelined commands carry their own errors by now.
+		for _, cmd := range cmds {
+			observeRedis(cmd, nil, duration)
+		}
+		return err
+	}
+}
+
+// observeRedis records cmd, which failed if either err, the error returned
+// by the hook chain, or its own error is set.
+func observeRedis(cmd redis.Cmder, err error, duration time.Duration) {
+	if err == nil {
+		err = cmd.Err()
+	}
+
+	// A missing key is an answer, not a failure.
+	result := "ok"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		result = "error"
+	}
+	redisCommandDuration.WithLabelValues(cmd.Name(), result).
+		Observe(duration.Seconds())
+}
@@ -0,0 +1,191 @@
+package payments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+)
+
+// FakeProvider keeps intents in memory and signs its own webhooks. It is
+// meant for local development and tests only.
+type FakeProvider struct {
+	secret string
+
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  webhookSecret,
+		intents: make(map[string]*Intent),
+	}
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateIntent(
+	ctx context.Context, orderID int, amount uint, currency string,
+) (*Intent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id, err := randomID(fmt.Sprintf("fake_pi_%d_", orderID))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomID("fake_secret_")
+	if err != nil {
+		return nil, err
+	}
+
+	intent := &Intent{
+		ID:           id,
+		ClientSecret: secret,
+		Amount:       amount,
+		Currency:     currency,
+		Status:       IntentRequiresPayment,
+	}
+
+	p.mu.Lock()
+	p.intents[id] = intent
+	p.mu.Unlock()
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) transition(
+	intentID string, from IntentStatus, to IntentStatus,
+) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, errs.ErrPaymentNotFound
+	}
+	if intent.Status != from {
+		return nil, fmt.Errorf(
+			"%w: intent is %s, expected %s",
+			errs.ErrPaymentProvider, intent.Status, from,
+		)
+	}
+	intent.Status = to
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, intentID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := p.transition(intentID, IntentAuthorized, IntentCaptured)
+	return err
+}
+
+func (p *FakeProvider) Refund(
+	ctx context.Context, intentID string, amount uint,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return errs.ErrPaymentNotFound
+	}
+	if intent.Status != IntentCaptured {
+		return fmt.Errorf(
+			"%w: intent is %s, expected %s",
+			errs.ErrPaymentProvider, intent.Status, IntentCaptured,
+		)
+	}
+	if amount > intent.Amount {
+		return fmt.Errorf(
+			"%w: refund exceeds captured amount", errs.ErrPaymentProvider,
+		)
+	}
+	intent.Status = IntentRefunded
+
+	return nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (
+	*Event, error,
+) {
+	if err := VerifySignature(payload, signature, p.secret); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, errs.ErrInvalidWebhook
+	}
+
+	return &event, nil
+}
+
+// Simulate acts as the customer paying (or failing to pay) an intent and
+// returns the signed webhook the gateway would deliver.
+func (p *FakeProvider) Simulate(intentID string, eventType EventType) (
+	[]byte, string, error,
+) {
+	event := Event{
+		Type:      eventType,
+		IntentID:  intentID,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	var intent *Intent
+	var err error
+	switch eventType {
+	case EventPaymentAuthorized:
+		intent, err = p.transition(
+			intentID, IntentRequiresPayment, IntentAuthorized,
+		)
+	case EventPaymentFailed:
+		intent, err = p.transition(intentID, IntentRequiresPayment, IntentFailed)
+		event.FailureReason = "card_declined"
+	default:
+		return nil, "", errs.ErrInvalidWebhook
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	event.Amount = intent.Amount
+
+	event.ID, err = randomID("fake_evt_")
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, SignPayload(payload, p.secret, time.Now()), nil
+}
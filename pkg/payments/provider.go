@@ -0,0 +1,57 @@
+package payments
+
+import "context"
+
+type IntentStatus string
+
+const (
+	IntentRequiresPayment IntentStatus = "requires_payment"
+	IntentAuthorized      IntentStatus = "authorized"
+	IntentCaptured        IntentStatus = "captured"
+	IntentFailed          IntentStatus = "failed"
+	IntentRefunded        IntentStatus = "refunded"
+)
+
+type EventType string
+
+const (
+	EventPaymentAuthorized EventType = "payment.authorized"
+	EventPaymentFailed     EventType = "payment.failed"
+)
+
+type Intent struct {
+	ID           string       `json:"id"`
+	ClientSecret string       `json:"client_secret"`
+	Amount       uint         `json:"amount"`
+	Currency     string       `json:"currency"`
+	Status       IntentStatus `json:"status"`
+}
+
+type Event struct {
+	ID            string    `json:"id"`
+	Type          EventType `json:"type"`
+	IntentID      string    `json:"intent_id"`
+	Amount        uint      `json:"amount"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     int64     `json:"created_at"`
+}
+
+// PaymentProvider is implemented by every payment gateway. Payments are
+// authorized by the customer outside of the API, reported back through a
+// signed webhook and captured by us afterwards. Calls that reach the gateway
+// must give up once ctx is done.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(
+		ctx context.Context, orderID int, amount uint, currency string,
+	) (*Intent, error)
+	Capture(ctx context.Context, intentID string) error
+	Refund(ctx context.Context, intentID string, amount uint) error
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// Simulator is implemented by providers that can emit webhooks themselves,
+// which lets the whole payment flow run without a real gateway.
+type Simulator interface {
+	Simulate(intentID string, eventType EventType) ([]byte, string, error)
+}
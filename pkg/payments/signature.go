@@ -0,0 +1,68 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+)
+
+// SignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>" where the
+// HMAC is computed over "<unix time>.<payload>".
+const SignatureHeader = "X-Payment-Signature"
+
+const signatureTolerance = 5 * time.Minute
+
+func computeSignature(payload []byte, timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func SignPayload(payload []byte, secret string, at time.Time) string {
+	timestamp := at.Unix()
+	return "t=" + strconv.FormatInt(timestamp, 10) +
+		",v1=" + computeSignature(payload, timestamp, secret)
+}
+
+func VerifySignature(payload []byte, header, secret string) error {
+	var timestamp int64
+	var signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errs.ErrInvalidSignature
+			}
+			timestamp = parsed
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == 0 || signature == "" {
+		return errs.ErrInvalidSignature
+	}
+
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > signatureTolerance || age < -signatureTolerance {
+		return errs.ErrInvalidSignature
+	}
+
+	expected := computeSignature(payload, timestamp, secret)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errs.ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -3,7 +3,6 @@ package redis
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,11 +11,38 @@ import (
 )
 
 type TokenStore interface {
-	SaveJWToken(userID int, token string) error
-	SaveJWTokens(userID int, accessToken, refreshToken string) error
-	DeleteJWToken(userID int, token string) error
-	DeleteJWTokens(userID int, accessToken, refreshToken string) error
-	ValidateJWToken(userID int, token string) (bool, error)
+	SaveJWToken(ctx context.Context, userID int, token string) error
+	DeleteJWToken(ctx context.Context, userID int, token string) error
+	DeleteJWTokens(
+		ctx context.Context, userID int, accessToken, refreshToken string,
+	) error
+	ValidateJWToken(ctx context.Context, userID int, token string) (bool, error)
+	// IsRotatedJWToken reports whether a refresh token was already swapped
+	// for a new pair of its session.
+	IsRotatedJWToken(ctx context.Context, userID int, token string) (bool, error)
+
+	// SaveSession records a new session together with its first token
+	// pair.
+	SaveSession(
+		ctx context.Context, session *Session, accessToken, refreshToken string,
+	) error
+	// RotateSession swaps refreshToken for a new pair of its session in one
+	// step, so a refresh token can be redeemed only once. It reports false,
+	// changing nothing, when refreshToken is no longer stored or its session
+	// was revoked meanwhile. Saving the new pair keeps the device the
+	// session was opened from.
+	RotateSession(
+		ctx context.Context, session *Session,
+		refreshToken, newAccessToken, newRefreshToken string,
+	) (bool, error)
+	ListSessions(ctx context.Context, userID int) ([]Session, error)
+	// DeleteSession revokes a session together with its token pair.
+	DeleteSession(ctx context.Context, userID int, sessionID string) error
+	// DeleteAllSessions revokes every session and token of the user.
+	DeleteAllSessions(ctx context.Context, userID int) error
+
+	// Ping checks that Redis answers.
+	Ping(ctx context.Context) error
 }
 
 type tokenStore struct {
@@ -27,7 +53,13 @@ func NewTokenStore(client *redis.Client) TokenStore {
 	return &tokenStore{redisClient: client}
 }
 
-func (ts *tokenStore) SaveJWToken(userID int, token string) error {
+func (ts *tokenStore) Ping(ctx context.Context) error {
+	return ts.redisClient.Ping(ctx).Err()
+}
+
+func (ts *tokenStore) SaveJWToken(
+	ctx context.Context, userID int, token string,
+) error {
 	claims, err := jwt.ParseJWT(token)
 	if err != nil {
 		return err
@@ -36,58 +68,58 @@ func (ts *tokenStore) SaveJWToken(userID int, token string) error {
 	if duration <= 0 {
 		return errors.New("token has already expired")
 	}
-	key := fmt.Sprintf("user:%d:jwt:%s", userID, claims.ID)
-	return ts.redisClient.Set(context.Background(), key, token, duration).Err()
+	key := tokenKey(userID, claims.ID)
+	return ts.redisClient.Set(ctx, key, token, duration).Err()
 }
 
-func (ts *tokenStore) SaveJWTokens(
-	userID int, accessToken, refreshToken string,
+func (ts *tokenStore) DeleteJWToken(
+	ctx context.Context, userID int, token string,
 ) error {
-	if err := ts.SaveJWToken(userID, accessToken); err != nil {
-		return err
-	}
-	if err := ts.SaveJWToken(userID, refreshToken); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (ts *tokenStore) DeleteJWToken(userID int, token string) error {
 	claims, err := jwt.ParseJWT(token)
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("user:%d:jwt:%s", userID, claims.ID)
-	return ts.redisClient.Del(context.Background(), key).Err()
+	key := tokenKey(userID, claims.ID)
+	return ts.redisClient.Del(ctx, key).Err()
 }
 
 func (ts *tokenStore) DeleteJWTokens(
-	userID int, accessToken, refreshToken string,
+	ctx context.Context, userID int, accessToken, refreshToken string,
 ) error {
-	if err := ts.DeleteJWToken(userID, accessToken); err != nil {
+	if err := ts.DeleteJWToken(ctx, userID, accessToken); err != nil {
 		return err
 	}
-	if err := ts.DeleteJWToken(userID, refreshToken); err != nil {
+	if err := ts.DeleteJWToken(ctx, userID, refreshToken); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (ts *tokenStore) ValidateJWToken(userID int, token string) (bool, error) {
+func (ts *tokenStore) ValidateJWToken(
+	ctx context.Context, userID int, token string,
+) (bool, error) {
 	claims, err := jwt.ParseJWT(token)
 	if err != nil {
 		return false, err
 	}
-	key := fmt.Sprintf("user:%d:jwt:%s", userID, claims.ID)
-	storedToken, err := ts.redisClient.Get(context.Background(), key).Result()
+	key := tokenKey(userID, claims.ID)
+	storedToken, err := ts.redisClient.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return false, nil
 		}
 		return false, err
 	}
-	if storedToken == token {
-		return true, nil
+	if storedToken != token {
+		return false, nil
+	}
+
+	if claims.SessionID != "" {
+		if err := ts.touchSession(
+			ctx, userID, claims.SessionID,
+		); err != nil {
+			return false, err
+		}
 	}
-	return false, nil
+	return true, nil
 }
@@ -0,0 +1,295 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginGuard counts failed logins per email and per client IP and tells
+// how long the next attempt has to wait. Unknown emails are counted like
+// known ones, so its answers do not reveal which accounts exist.
+type LoginGuard interface {
+	// Check returns the state of email and ip without counting an
+	// attempt. An empty ip checks only the email.
+	Check(ctx context.Context, email, ip string) (LoginStatus, error)
+	// Attempt checks email and ip like Check and, when the attempt may go
+	// ahead, counts it against email in the same step, so concurrent
+	// attempts cannot get past the lockout threshold together. Follow it
+	// with Reset when the password is right and RecordFailure otherwise.
+	Attempt(ctx context.Context, email, ip string) (LoginStatus, error)
+	// RecordFailure applies a failed attempt already counted by Attempt:
+	// it backs off or locks email, counts the failure against ip and
+	// returns the state it left.
+	RecordFailure(ctx context.Context, email, ip string) (LoginStatus, error)
+	// Reset forgets the failures of email after a successful login.
+	Reset(ctx context.Context, email string) error
+	// Unlock lifts the lockout of email and forgets its failures.
+	Unlock(ctx context.Context, email string) error
+}
+
+// LoginStatus is what a LoginGuard knows about the next attempt.
+type LoginStatus struct {
+	// Failures of the email within the failure window.
+	Failures int64
+	Locked   bool
+	// NewlyLocked is set by the failure that locked the account.
+	NewlyLocked bool
+	// RetryAfter is how long the next attempt has to wait; zero when it
+	// may go ahead.
+	RetryAfter time.Duration
+}
+
+// LoginGuardOptions mirror config.LoginConfig.
+type LoginGuardOptions struct {
+	FailureWindow   time.Duration
+	BackoffAfter    int64
+	IPBackoffAfter  int64
+	BackoffBase     time.Duration
+	BackoffMax      time.Duration
+	LockoutAfter    int64
+	LockoutDuration time.Duration
+}
+
+type loginGuard struct {
+	redisClient *redis.Client
+	opts        LoginGuardOptions
+}
+
+func NewLoginGuard(client *redis.Client, opts LoginGuardOptions) LoginGuard {
+	return &loginGuard{redisClient: client, opts: opts}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginFailuresKey(kind, value string) string {
+	return "login_failures:" + kind + ":" + value
+}
+
+func loginBackoffKey(kind, value string) string {
+	return "login_backoff:" + kind + ":" + value
+}
+
+func loginLockoutKey(email string) string {
+	return "login_lockout:" + email
+}
+
+// backoff is the wait after failures, doubling from base once after is
+// reached and capped at max.
+func backoff(failures, after int64, base, max time.Duration) time.Duration {
+	if failures < after {
+		return 0
+	}
+
+	delay := base
+	for i := after; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func (g *loginGuard) Check(
+	ctx context.Context, email, ip string,
+) (LoginStatus, error) {
+	email = normalizeEmail(email)
+
+	pipe := g.redisClient.Pipeline()
+	failures := pipe.Get(ctx, loginFailuresKey("email", email))
+	lockout := pipe.PTTL(ctx, loginLockoutKey(email))
+	waits := []*redis.DurationCmd{
+		lockout, pipe.PTTL(ctx, loginBackoffKey("email", email)),
+	}
+	if ip != "" {
+		waits = append(waits, pipe.PTTL(ctx, loginBackoffKey("ip", ip)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return LoginStatus{}, err
+	}
+
+	var status LoginStatus
+	if count, err := failures.Int64(); err == nil {
+		status.Failures = count
+	}
+	status.Locked = lockout.Val() > 0
+	for _, wait := range waits {
+		if wait.Val() > status.RetryAfter {
+			status.RetryAfter = wait.Val()
+		}
+	}
+
+	return status, nil
+}
+
+// attemptScript refuses an attempt while email is locked out or email or
+// ip backs off, and otherwise counts it, starting the failure window on
+// the first one. An attempt past the lockout threshold is not counted but
+// refused: the attempts already counted are still running and one of them
+// will lock the account unless the password turns out right.
+//
+// KEYS: email failures, lockout, email backoff and optionally ip backoff.
+// ARGV: failure window in ms, lockout threshold, wait in ms for an attempt
+// past the threshold.
+// Returns the failures, 1 when locked out and the wait in ms.
+var attemptScript = redis.NewScript(
+	`local lockout = redis.call("PTTL", KEYS[2])
+	if lockout > 0 then
+		return {tonumber(redis.call("GET", KEYS[1]) or "0"), 1, lockout}
+	end
+
+	local wait = 0
+	for i = 3, #KEYS do
+		local backoff = redis.call("PTTL", KEYS[i])
+		if backoff > wait then
+			wait = backoff
+		end
+	end
+	if wait > 0 then
+		return {tonumber(redis.call("GET", KEYS[1]) or "0"), 0, wait}
+	end
+
+	local failures = redis.call("INCR", KEYS[1])
+	if redis.call("PTTL", KEYS[1]) < 0 then
+		redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	end
+	if failures > tonumber(ARGV[2]) then
+		redis.call("DECR", KEYS[1])
+		return {failures - 1, 0, tonumber(ARGV[3])}
+	end
+	return {failures, 0, 0}`,
+)
+
+func (g *loginGuard) Attempt(
+	ctx context.Context, email, ip string,
+) (LoginStatus, error) {
+	email = normalizeEmail(email)
+
+	keys := []string{
+		loginFailuresKey("email", email),
+		loginLockoutKey(email),
+		loginBackoffKey("email", email),
+	}
+	if ip != "" {
+		keys = append(keys, loginBackoffKey("ip", ip))
+	}
+
+	result, err := attemptScript.Run(
+		ctx, g.redisClient, keys,
+		g.opts.FailureWindow.Milliseconds(), g.opts.LockoutAfter,
+		g.opts.BackoffBase.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return LoginStatus{}, err
+	}
+
+	return LoginStatus{
+		Failures:   result[0],
+		Locked:     result[1] == 1,
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+	}, nil
+}
+
+func (g *loginGuard) RecordFailure(
+	ctx context.Context, email, ip string,
+) (LoginStatus, error) {
+	email = normalizeEmail(email)
+
+	emailFailures, err := g.redisClient.Get(
+		ctx, loginFailuresKey("email", email),
+	).Int64()
+	if err != nil && err != redis.Nil {
+		return LoginStatus{}, err
+	}
+	status := LoginStatus{Failures: emailFailures}
+
+	if emailFailures >= g.opts.LockoutAfter {
+		locked, err := g.redisClient.SetNX(
+			ctx, loginLockoutKey(email), "1", g.opts.LockoutDuration,
+		).Result()
+		if err != nil {
+			return LoginStatus{}, err
+		}
+		// The count starts over once the lockout ends.
+		if err := g.redisClient.Del(
+			ctx, loginFailuresKey("email", email),
+		).Err(); err != nil {
+			return LoginStatus{}, err
+		}
+		status.Locked = true
+		status.NewlyLocked = locked
+		status.RetryAfter = g.opts.LockoutDuration
+	} else if delay := backoff(
+		emailFailures, g.opts.BackoffAfter,
+		g.opts.BackoffBase, g.opts.BackoffMax,
+	); delay > 0 {
+		if err := g.redisClient.Set(
+			ctx, loginBackoffKey("email", email), "1", delay,
+		).Err(); err != nil {
+			return LoginStatus{}, err
+		}
+		status.RetryAfter = delay
+	}
+
+	if ip == "" {
+		return status, nil
+	}
+
+	ipFailures, err := g.count(ctx, loginFailuresKey("ip", ip))
+	if err != nil {
+		return LoginStatus{}, err
+	}
+	if delay := backoff(
+		ipFailures, g.opts.IPBackoffAfter,
+		g.opts.BackoffBase, g.opts.BackoffMax,
+	); delay > 0 {
+		if err := g.redisClient.Set(
+			ctx, loginBackoffKey("ip", ip), "1", delay,
+		).Err(); err != nil {
+			return LoginStatus{}, err
+		}
+		if delay > status.RetryAfter {
+			status.RetryAfter = delay
+		}
+	}
+
+	return status, nil
+}
+
+// count increments a failure counter, starting its window on the first
+// failure.
+func (g *loginGuard) count(ctx context.Context, key string) (int64, error) {
+	pipe := g.redisClient.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, g.opts.FailureWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (g *loginGuard) Reset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	return g.redisClient.Del(
+		ctx,
+		loginFailuresKey("email", email),
+		loginBackoffKey("email", email),
+	).Err()
+}
+
+func (g *loginGuard) Unlock(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	return g.redisClient.Del(
+		ctx,
+		loginFailuresKey("email", email),
+		loginBackoffKey("email", email),
+		loginLockoutKey(email),
+	).Err()
+}
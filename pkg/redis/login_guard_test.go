@@ -0,0 +1,94 @@
+package redis_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+var guardOptions = redis.LoginGuardOptions{
+	FailureWindow:   15 * time.Minute,
+	BackoffAfter:    10,
+	IPBackoffAfter:  50,
+	BackoffBase:     time.Second,
+	BackoffMax:      time.Minute,
+	LockoutAfter:    3,
+	LockoutDuration: 30 * time.Minute,
+}
+
+func TestLoginGuardAttempt_ConcurrentStayUnderLockout(t *testing.T) {
+	_, client := newRedis(t)
+	guard := redis.NewLoginGuard(client, guardOptions)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status, err := guard.Attempt(ctx, "Martin@example.com", "10.0.0.1")
+			assert.NoError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !status.Locked && status.RetryAfter == 0 {
+				allowed++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int(guardOptions.LockoutAfter), allowed)
+}
+
+func TestLoginGuardAttempt_FailuresLock(t *testing.T) {
+	_, client := newRedis(t)
+	guard := redis.NewLoginGuard(client, guardOptions)
+
+	var status redis.LoginStatus
+	for i := int64(1); i <= guardOptions.LockoutAfter; i++ {
+		attempt, err := guard.Attempt(ctx, "martin@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		require.Zero(t, attempt.RetryAfter)
+		assert.Equal(t, i, attempt.Failures)
+
+		status, err = guard.RecordFailure(ctx, "martin@example.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	assert.True(t, status.NewlyLocked)
+
+	attempt, err := guard.Attempt(ctx, "martin@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, attempt.Locked)
+	assert.Equal(t, guardOptions.LockoutDuration, attempt.RetryAfter)
+}
+
+func TestLoginGuardAttempt_ResetAfterSuccess(t *testing.T) {
+	_, client := newRedis(t)
+	guard := redis.NewLoginGuard(client, guardOptions)
+
+	for i := int64(1); i < guardOptions.LockoutAfter; i++ {
+		_, err := guard.Attempt(ctx, "martin@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		_, err = guard.RecordFailure(ctx, "martin@example.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	_, err := guard.Attempt(ctx, "martin@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, guard.Reset(ctx, "martin@example.com"))
+
+	status, err := guard.Check(ctx, "martin@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, status.Failures)
+	assert.False(t, status.Locked)
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter counts requests in a sliding window.
+type RateLimiter interface {
+	// Allow counts a request for key unless limit requests were already
+	// allowed within the last window.
+	Allow(
+		ctx context.Context, key string, limit int, window time.Duration,
+	) (RateLimitResult, error)
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long until the oldest counted request leaves the
+	// window and frees a slot.
+	ResetAfter time.Duration
+}
+
+// slidingWindowScript keeps the timestamps of the allowed requests of a key
+// in a sorted set. It runs atomically, and on the Redis clock so that every
+// instance of the API agrees on the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, count, reset}
+`)
+
+type rateLimiter struct {
+	redisClient *redis.Client
+}
+
+func NewRateLimiter(client *redis.Client) RateLimiter {
+	return &rateLimiter{redisClient: client}
+}
+
+func rateLimitKey(key string) string {
+	return "rate_limit:" + key
+}
+
+func (l *rateLimiter) Allow(
+	ctx context.Context, key string, limit int, window time.Duration,
+) (RateLimitResult, error) {
+	// Requests landing in the same millisecond need distinct members.
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, err := slidingWindowScript.Run(
+		ctx, l.redisClient, []string{rateLimitKey(key)},
+		window.Milliseconds(), limit, hex.EncodeToString(b),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  max(limit-int(values[1]), 0),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
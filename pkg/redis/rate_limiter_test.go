@@ -0,0 +1,47 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	server, client := newRedis(t)
+	limiter := redis.NewRateLimiter(client)
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	server.SetTime(start)
+
+	for i := 1; i <= 3; i++ {
+		result, err := limiter.Allow(ctx, "public:ip:10.0.0.1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, 3-i, result.Remaining)
+		assert.Equal(t, time.Minute, result.ResetAfter)
+	}
+
+	server.SetTime(start.Add(20 * time.Second))
+	result, err := limiter.Allow(ctx, "public:ip:10.0.0.1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+	assert.Equal(t, 40*time.Second, result.ResetAfter)
+
+	// Other callers have windows of their own.
+	result, err = limiter.Allow(ctx, "public:ip:10.0.0.2", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Once the first requests leave the window, slots free up again.
+	server.SetTime(start.Add(time.Minute + time.Millisecond))
+	result, err = limiter.Allow(ctx, "public:ip:10.0.0.1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
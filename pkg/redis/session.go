@@ -0,0 +1,417 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+)
+
+// Session is one login of a user on one device: the access/refresh pair
+// issued at login and every pair refreshed from it. It is stored as a hash
+// that expires together with its refresh token.
+type Session struct {
+	ID             string    `redis:"id"`
+	UserID         int       `redis:"user_id"`
+	UserAgent      string    `redis:"user_agent"`
+	IP             string    `redis:"ip"`
+	MFA            bool      `redis:"mfa"`
+	CreatedAt      time.Time `redis:"created_at"`
+	LastUsedAt     time.Time `redis:"last_used_at"`
+	ExpiresAt      time.Time `redis:"expires_at"`
+	AccessTokenID  string    `redis:"access_token_id"`
+	RefreshTokenID string    `redis:"refresh_token_id"`
+}
+
+// NewSessionID returns a random session ID.
+func NewSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func sessionKey(userID int, sessionID string) string {
+	return fmt.Sprintf("user:%d:session:%s", userID, sessionID)
+}
+
+func tokenKey(userID int, tokenID string) string {
+	return fmt.Sprintf("user:%d:jwt:%s", userID, tokenID)
+}
+
+// rotatedKey holds the IDs of the refresh tokens a session has already
+// swapped for new pairs, to spot a stolen one being replayed.
+func rotatedKey(userID int, sessionID string) string {
+	return fmt.Sprintf("user:%d:rotated:%s", userID, sessionID)
+}
+
+// touchScript updates last_used_at only while the session still exists,
+// so a request racing a revocation cannot bring the session back.
+var touchScript = redis.NewScript(
+	`if redis.call("EXISTS", KEYS[1]) == 1 then
+		return redis.call("HSET", KEYS[1], "last_used_at", ARGV[1])
+	end
+	return 0`,
+)
+
+func (ts *tokenStore) touchSession(
+	ctx context.Context, userID int, sessionID string,
+) error {
+	return touchScript.Run(
+		ctx, ts.redisClient, []string{sessionKey(userID, sessionID)},
+		time.Now().UTC().Format(time.RFC3339Nano),
+	).Err()
+}
+
+// revokingKey counts the DeleteAllSessions calls running for the user.
+// While it is set no session is rotated, so no pair is created behind the
+// scan that collects the keys to delete.
+func revokingKey(userID int) string {
+	return fmt.Sprintf("user:%d:revoking", userID)
+}
+
+// revokingTimeout bounds how long a revocation that never finished, say
+// because the process died, blocks refreshes.
+const revokingTimeout = 30 * time.Second
+
+func (ts *tokenStore) SaveSession(
+	ctx context.Context, session *Session, accessToken, refreshToken string,
+) error {
+	access, err := jwt.ParseJWT(accessToken)
+	if err != nil {
+		return err
+	}
+	refresh, err := jwt.ParseJWT(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := refresh.ExpiresAt.Time
+	if !expiresAt.After(now) {
+		return errors.New("token has already expired")
+	}
+
+	key := sessionKey(session.UserID, session.ID)
+	_, err = ts.redisClient.TxPipelined(
+		ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(
+				ctx, tokenKey(session.UserID, access.ID), accessToken,
+				access.ExpiresAt.Sub(now),
+			)
+			pipe.Set(
+				ctx, tokenKey(session.UserID, refresh.ID), refreshToken,
+				expiresAt.Sub(now),
+			)
+			pipe.HSet(
+				ctx, key,
+				"id", session.ID,
+				"user_id", session.UserID,
+				"user_agent", session.UserAgent,
+				"ip", session.IP,
+				"mfa", session.MFA,
+				"created_at", now,
+				"last_used_at", now,
+				"expires_at", expiresAt,
+				"access_token_id", access.ID,
+				"refresh_token_id", refresh.ID,
+			)
+			pipe.ExpireAt(ctx, key, expiresAt)
+			return nil
+		},
+	)
+	return err
+}
+
+// rotateScript redeems a refresh token for a new pair. It gives up unless
+// the token is still stored, no revocation of the user is running and,
+// for a token of a session, the session still exists; otherwise the pair
+// being replaced is deleted, the token is remembered as rotated and the
+// new pair is saved in the session. Only a new session takes the device
+// and creation time.
+//
+// KEYS: refresh token, session, rotated tokens, revoking counter, new
+// access token, new refresh token.
+// ARGV: refresh token, its ID, "1" for a token of a session, token key
+// prefix, new access token, its TTL in ms, new refresh token, its TTL in
+// ms, session expiry in unix ms, then the session fields id, user_id,
+// user_agent, ip, mfa, now, expires_at, access_token_id, refresh_token_id.
+var rotateScript = redis.NewScript(
+	`if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	if tonumber(redis.call("GET", KEYS[4]) or "0") > 0 then
+		return 0
+	end
+	if ARGV[3] == "1" and redis.call("EXISTS", KEYS[2]) == 0 then
+		return 0
+	end
+
+	redis.call("DEL", KEYS[1])
+	local previous = redis.call("HGET", KEYS[2], "access_token_id")
+	if previous then
+		redis.call("DEL", ARGV[4] .. previous)
+	end
+	if ARGV[3] == "1" then
+		redis.call("SADD", KEYS[3], ARGV[2])
+		redis.call("PEXPIREAT", KEYS[3], ARGV[9])
+	end
+
+	redis.call("SET", KEYS[5], ARGV[5], "PX", ARGV[6])
+	redis.call("SET", KEYS[6], ARGV[7], "PX", ARGV[8])
+	redis.call("HSETNX", KEYS[2], "created_at", ARGV[15])
+	redis.call("HSETNX", KEYS[2], "user_agent", ARGV[12])
+	redis.call("HSETNX", KEYS[2], "ip", ARGV[13])
+	redis.call(
+		"HSET", KEYS[2],
+		"id", ARGV[10],
+		"user_id", ARGV[11],
+		"mfa", ARGV[14],
+		"last_used_at", ARGV[15],
+		"expires_at", ARGV[16],
+		"access_token_id", ARGV[17],
+		"refresh_token_id", ARGV[18]
+	)
+	redis.call("PEXPIREAT", KEYS[2], ARGV[9])
+	return 1`,
+)
+
+func (ts *tokenStore) RotateSession(
+	ctx context.Context, session *Session,
+	refreshToken, newAccessToken, newRefreshToken string,
+) (bool, error) {
+	presented, err := jwt.ParseJWT(refreshToken)
+	if err != nil {
+		return false, err
+	}
+	access, err := jwt.ParseJWT(newAccessToken)
+	if err != nil {
+		return false, err
+	}
+	refresh, err := jwt.ParseJWT(newRefreshToken)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	expiresAt := refresh.ExpiresAt.Time
+	if !expiresAt.After(now) {
+		return false, errors.New("token has already expired")
+	}
+
+	inSession := "0"
+	if presented.SessionID != "" {
+		inSession = "1"
+	}
+
+	rotated, err := rotateScript.Run(
+		ctx, ts.redisClient,
+		[]string{
+			tokenKey(session.UserID, presented.ID),
+			sessionKey(session.UserID, session.ID),
+			rotatedKey(session.UserID, session.ID),
+			revokingKey(session.UserID),
+			tokenKey(session.UserID, access.ID),
+			tokenKey(session.UserID, refresh.ID),
+		},
+		refreshToken,
+		presented.ID,
+		inSession,
+		tokenKey(session.UserID, ""),
+		newAccessToken,
+		access.ExpiresAt.Sub(now).Milliseconds(),
+		newRefreshToken,
+		expiresAt.Sub(now).Milliseconds(),
+		expiresAt.UnixMilli(),
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		session.MFA,
+		now,
+		expiresAt,
+		access.ID,
+		refresh.ID,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return rotated == 1, nil
+}
+
+func (ts *tokenStore) getSession(
+	ctx context.Context, userID int, sessionID string,
+) (*Session, error) {
+	cmd := ts.redisClient.HGetAll(ctx, sessionKey(userID, sessionID))
+	values, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errs.ErrSessionNotFound
+	}
+
+	var session Session
+	if err := cmd.Scan(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (ts *tokenStore) scanKeys(
+	ctx context.Context, pattern string,
+) ([]string, error) {
+	var keys []string
+	iter := ts.redisClient.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// ListSessions returns the live sessions of the user, most recently used
+// first.
+func (ts *tokenStore) ListSessions(
+	ctx context.Context, userID int,
+) ([]Session, error) {
+	keys, err := ts.scanKeys(ctx, fmt.Sprintf("user:%d:session:*", userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(keys))
+	prefix := sessionKey(userID, "")
+	for _, key := range keys {
+		session, err := ts.getSession(ctx, userID, key[len(prefix):])
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			// Expired between the scan and the read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(
+		sessions, func(i, j int) bool {
+			return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+		},
+	)
+	return sessions, nil
+}
+
+// deleteSessionScript deletes a session together with the pair it holds
+// at that moment, so a refresh running alongside leaves no pair behind.
+//
+// KEYS: session, rotated tokens. ARGV: token key prefix.
+var deleteSessionScript = redis.NewScript(
+	`local ids = redis.call(
+		"HMGET", KEYS[1], "access_token_id", "refresh_token_id"
+	)
+	if redis.call("DEL", KEYS[1]) == 0 then
+		return 0
+	end
+	for _, id in ipairs(ids) do
+		if id then
+			redis.call("DEL", ARGV[1] .. id)
+		end
+	end
+	redis.call("DEL", KEYS[2])
+	return 1`,
+)
+
+func (ts *tokenStore) DeleteSession(
+	ctx context.Context, userID int, sessionID string,
+) error {
+	deleted, err := deleteSessionScript.Run(
+		ctx, ts.redisClient,
+		[]string{
+			sessionKey(userID, sessionID), rotatedKey(userID, sessionID),
+		},
+		tokenKey(userID, ""),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return errs.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// releaseRevokingScript ends one revocation. The counter is removed once no
+// revocation is left or when it already expired; a negative count would
+// let refreshes through while a later revocation runs.
+var releaseRevokingScript = redis.NewScript(
+	`if redis.call("DECR", KEYS[1]) <= 0 then
+		redis.call("DEL", KEYS[1])
+	end
+	return 0`,
+)
+
+// DeleteAllSessions holds off refreshes of the user while it collects and
+// deletes the keys, so no session is rotated into keys the scan missed.
+func (ts *tokenStore) DeleteAllSessions(ctx context.Context, userID int) error {
+	revoking := revokingKey(userID)
+	_, err := ts.redisClient.TxPipelined(
+		ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, revoking)
+			pipe.Expire(ctx, revoking, revokingTimeout)
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer releaseRevokingScript.Run(
+		context.WithoutCancel(ctx), ts.redisClient, []string{revoking},
+	)
+
+	var keys []string
+	for _, pattern := range []string{
+		fmt.Sprintf("user:%d:session:*", userID),
+		fmt.Sprintf("user:%d:rotated:*", userID),
+		fmt.Sprintf("user:%d:jwt:*", userID),
+	} {
+		found, err := ts.scanKeys(ctx, pattern)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, found...)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+	return ts.redisClient.Del(ctx, keys...).Err()
+}
+
+func (ts *tokenStore) IsRotatedJWToken(
+	ctx context.Context, userID int, token string,
+) (bool, error) {
+	claims, err := jwt.ParseJWT(token)
+	if err != nil {
+		return false, err
+	}
+	if claims.SessionID == "" {
+		return false, nil
+	}
+
+	return ts.redisClient.SIsMember(
+		ctx, rotatedKey(userID, claims.SessionID), claims.ID,
+	).Result()
+}
@@ -0,0 +1,155 @@
+package redis_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/DaniilKalts/market-rest-api/internal/errors"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/jwt"
+	"github.com/DaniilKalts/market-rest-api/pkg/redis"
+)
+
+var ctx = context.Background()
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *goredis.Client) {
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func tokenPair(t *testing.T, userID int, sessionID string) (string, string) {
+	access, err := jwt.GenerateSessionJWT(
+		strconv.Itoa(userID), 15, "user", nil, sessionID, false,
+	)
+	require.NoError(t, err)
+	refresh, err := jwt.GenerateSessionJWT(
+		strconv.Itoa(userID), 1440, "user", nil, sessionID, false,
+	)
+	require.NoError(t, err)
+
+	return access, refresh
+}
+
+// openSession saves a session of user 1 and returns it with its refresh
+// token.
+func openSession(t *testing.T, store redis.TokenStore) (*redis.Session, string) {
+	session := &redis.Session{
+		ID: "session-1", UserID: 1, UserAgent: "curl", IP: "10.0.0.1",
+	}
+	access, refresh := tokenPair(t, session.UserID, session.ID)
+	require.NoError(t, store.SaveSession(ctx, session, access, refresh))
+
+	return session, refresh
+}
+
+func TestRotateSession_RedeemsOnce(t *testing.T) {
+	_, client := newRedis(t)
+	store := redis.NewTokenStore(client)
+	session, refresh := openSession(t, store)
+
+	access, next := tokenPair(t, session.UserID, session.ID)
+	rotated, err := store.RotateSession(ctx, session, refresh, access, next)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	again, againNext := tokenPair(t, session.UserID, session.ID)
+	rotated, err = store.RotateSession(ctx, session, refresh, again, againNext)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	valid, err := store.ValidateJWToken(ctx, session.UserID, access)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = store.ValidateJWToken(ctx, session.UserID, again)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	reused, err := store.IsRotatedJWToken(ctx, session.UserID, refresh)
+	require.NoError(t, err)
+	assert.True(t, reused)
+
+	sessions, err := store.ListSessions(ctx, session.UserID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "curl", sessions[0].UserAgent)
+	assert.Equal(t, "10.0.0.1", sessions[0].IP)
+}
+
+func TestRotateSession_AfterDeleteSession(t *testing.T) {
+	_, client := newRedis(t)
+	store := redis.NewTokenStore(client)
+	session, refresh := openSession(t, store)
+
+	require.NoError(t, store.DeleteSession(ctx, session.UserID, session.ID))
+
+	access, next := tokenPair(t, session.UserID, session.ID)
+	rotated, err := store.RotateSession(ctx, session, refresh, access, next)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	sessions, err := store.ListSessions(ctx, session.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestRotateSession_WhileRevoking(t *testing.T) {
+	server, client := newRedis(t)
+	store := redis.NewTokenStore(client)
+	session, refresh := openSession(t, store)
+
+	// A DeleteAllSessions call is between its scan and its delete.
+	server.Set("user:1:revoking", "1")
+
+	access, next := tokenPair(t, session.UserID, session.ID)
+	rotated, err := store.RotateSession(ctx, session, refresh, access, next)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	valid, err := store.ValidateJWToken(ctx, session.UserID, refresh)
+	require.NoError(t, err)
+	assert.True(t, valid, "the presented token must stay for the revocation")
+}
+
+func TestDeleteSession_RemovesCurrentPair(t *testing.T) {
+	_, client := newRedis(t)
+	store := redis.NewTokenStore(client)
+	session, refresh := openSession(t, store)
+
+	access, next := tokenPair(t, session.UserID, session.ID)
+	rotated, err := store.RotateSession(ctx, session, refresh, access, next)
+	require.NoError(t, err)
+	require.True(t, rotated)
+
+	require.NoError(t, store.DeleteSession(ctx, session.UserID, session.ID))
+
+	for _, token := range []string{access, next} {
+		valid, err := store.ValidateJWToken(ctx, session.UserID, token)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	}
+
+	err = store.DeleteSession(ctx, session.UserID, session.ID)
+	assert.ErrorIs(t, err, errs.ErrSessionNotFound)
+}
+
+func TestDeleteAllSessions_ReleasesRevoking(t *testing.T) {
+	server, client := newRedis(t)
+	store := redis.NewTokenStore(client)
+	openSession(t, store)
+
+	require.NoError(t, store.DeleteAllSessions(ctx, 1))
+
+	assert.False(t, server.Exists("user:1:revoking"))
+	sessions, err := store.ListSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
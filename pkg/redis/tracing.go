@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/DaniilKalts/market-rest-api/pkg/tracing"
+)
+
+type tracedTokenStore struct {
+	next TokenStore
+}
+
+// NewTracedTokenStore records a span for every call to next.
+func NewTracedTokenStore(next TokenStore) TokenStore {
+	return &tracedTokenStore{next: next}
+}
+
+func (ts *tracedTokenStore) SaveJWToken(
+	ctx context.Context, userID int, token string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.SaveJWToken")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.SaveJWToken(ctx, userID, token)
+}
+
+func (ts *tracedTokenStore) DeleteJWToken(
+	ctx context.Context, userID int, token string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.DeleteJWToken")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.DeleteJWToken(ctx, userID, token)
+}
+
+func (ts *tracedTokenStore) DeleteJWTokens(
+	ctx context.Context, userID int, accessToken string, refreshToken string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.DeleteJWTokens")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.DeleteJWTokens(ctx, userID, accessToken, refreshToken)
+}
+
+func (ts *tracedTokenStore) ValidateJWToken(
+	ctx context.Context, userID int, token string,
+) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.ValidateJWToken")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.ValidateJWToken(ctx, userID, token)
+}
+
+func (ts *tracedTokenStore) IsRotatedJWToken(
+	ctx context.Context, userID int, token string,
+) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.IsRotatedJWToken")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.IsRotatedJWToken(ctx, userID, token)
+}
+
+func (ts *tracedTokenStore) SaveSession(
+	ctx context.Context, session *Session, accessToken string, refreshToken string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.SaveSession")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.SaveSession(ctx, session, accessToken, refreshToken)
+}
+
+func (ts *tracedTokenStore) RotateSession(
+	ctx context.Context, session *Session,
+	refreshToken string, newAccessToken string, newRefreshToken string,
+) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.RotateSession")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.RotateSession(
+		ctx, session, refreshToken, newAccessToken, newRefreshToken,
+	)
+}
+
+func (ts *tracedTokenStore) ListSessions(
+	ctx context.Context, userID int,
+) (_ []Session, err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.ListSessions")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.ListSessions(ctx, userID)
+}
+
+func (ts *tracedTokenStore) DeleteSession(
+	ctx context.Context, userID int, sessionID string,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.DeleteSession")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.DeleteSession(ctx, userID, sessionID)
+}
+
+func (ts *tracedTokenStore) DeleteAllSessions(
+	ctx context.Context, userID int,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.DeleteAllSessions")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.DeleteAllSessions(ctx, userID)
+}
+
+func (ts *tracedTokenStore) Ping(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "TokenStore.Ping")
+	defer func() { tracing.End(span, err) }()
+
+	return ts.next.Ping(ctx)
+}
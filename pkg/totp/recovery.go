@@ -0,0 +1,35 @@
+package totp
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// recoveryAlphabet is the base32 alphabet: 32 symbols, so masking a random
+// byte picks each one equally often, and no 0/o or 1/l mix-ups.
+const recoveryAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// GenerateRecoveryCodes returns n random codes of the form xxxxx-xxxxx for
+// signing in without the authenticator.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	b := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = recoveryAlphabet[b[j]&31]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode lowercases code and strips the separator and
+// spaces, so codes typed either way hash the same.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,115 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The parameters every authenticator app understands: SHA-1, six digits
+// and a 30 second step (RFC 6238 defaults).
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	// skew is how many steps either side of now are still accepted, to
+	// allow for clock drift and slow typing.
+	skew = 1
+)
+
+var (
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	modulus  = uint32(math.Pow10(Digits))
+)
+
+// GenerateSecret returns a random 160-bit secret, base32 encoded as
+// authenticator apps expect it.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// URI that authenticator apps read from a QR
+// code.
+func URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%modulus)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(secret, "="))
+	return encoding.DecodeString(secret)
+}
+
+// Code returns the code for secret at t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, uint64(t.Unix())/uint64(Period.Seconds())), nil
+}
+
+// Validate reports whether code matches secret at t or one step either
+// side of it.
+func Validate(secret, code string, t time.Time) bool {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return false
+	}
+
+	counter := int64(t.Unix()) / int64(Period.Seconds())
+	for step := int64(-skew); step <= skew; step++ {
+		expected := codeAt(key, counter+step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func codeAt(key []byte, counter int64) string {
+	if counter < 0 {
+		return ""
+	}
+	return code(key, uint64(counter))
+}
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormSpanKey   = "tracing:span"
+	gormParentKey = "tracing:parent"
+)
+
+// GormPlugin records a span for every query run through a *gorm.DB with a
+// context, as a child of the span in that context. Install it with
+// db.Use(tracing.GormPlugin{}).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").
+			Register("tracing:before_create", startQuery("create")),
+		cb.Create().After("gorm:create").
+			Register("tracing:after_create", endQuery),
+		cb.Query().Before("gorm:query").
+			Register("tracing:before_query", startQuery("query")),
+		cb.Query().After("gorm:query").
+			Register("tracing:after_query", endQuery),
+		cb.Update().Before("gorm:update").
+			Register("tracing:before_update", startQuery("update")),
+		cb.Update().After("gorm:update").
+			Register("tracing:after_update", endQuery),
+		cb.Delete().Before("gorm:delete").
+			Register("tracing:before_delete", startQuery("delete")),
+		cb.Delete().After("gorm:delete").
+			Register("tracing:after_delete", endQuery),
+		cb.Row().Before("gorm:row").
+			Register("tracing:before_row", startQuery("row")),
+		cb.Row().After("gorm:row").
+			Register("tracing:after_row", endQuery),
+		cb.Raw().Before("gorm:raw").
+			Register("tracing:before_raw", startQuery("raw")),
+		cb.Raw().After("gorm:raw").
+			Register("tracing:after_raw", endQuery),
+	)
+}
+
+func startQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+
+		db.InstanceSet(gormParentKey, db.Statement.Context)
+		ctx, span := Start(
+			db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation", operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endQuery(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	// Queries run later on this statement belong to the caller, not here.
+	if parent, ok := db.InstanceGet(gormParentKey); ok {
+		if ctx, ok := parent.(context.Context); ok {
+			db.Statement.Context = ctx
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	// Finding nothing is an answer the repositories handle, not a failure.
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
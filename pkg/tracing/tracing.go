@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/DaniilKalts/market-rest-api"
+
+// Values of Options.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Options mirror config.TracingConfig.
+type Options struct {
+	Exporter    string
+	ServiceName string
+	// OTLPEndpoint is the URL of the collector, such as
+	// http://localhost:4318. Empty uses the OTEL_EXPORTER_OTLP_* variables.
+	OTLPEndpoint string
+	// SampleRatio is the share of new traces recorded. Requests continuing
+	// a trace follow the sampling decision of their caller.
+	SampleRatio float64
+	// Stdout receives the spans of the stdout exporter.
+	Stdout io.Writer
+}
+
+// Setup installs the W3C trace context propagator and a tracer provider
+// exporting to opts.Exporter. The returned function flushes the spans
+// still buffered; call it before the process exits. With ExporterNone
+// spans are not recorded, but trace context is still propagated.
+func Setup(
+	ctx context.Context, opts Options,
+) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{}, propagation.Baggage{},
+		),
+	)
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(
+			stdouttrace.WithWriter(opts.Stdout), stdouttrace.WithPrettyPrint(),
+		)
+	case ExporterOTLP:
+		var httpOpts []otlptracehttp.Option
+		if opts.OTLPEndpoint != "" {
+			httpOpts = append(
+				httpOpts, otlptracehttp.WithEndpointURL(opts.OTLPEndpoint),
+			)
+		}
+		exporter, err = otlptracehttp.New(ctx, httpOpts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL, semconv.ServiceName(opts.ServiceName),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(
+			sdktrace.ParentBased(
+				sdktrace.TraceIDRatioBased(opts.SampleRatio),
+			),
+		),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(
+	ctx context.Context, name string, opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End ends span, marking it failed when err is set.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}